@@ -0,0 +1,103 @@
+package main
+
+import (
+    "fmt"
+    "net"
+    "strconv"
+    "strings"
+)
+
+// ASNInfo décrit le système autonome propriétaire d'une adresse.
+type ASNInfo struct {
+    Number uint32 `json:"number"`
+    Org    string `json:"org,omitempty"`
+}
+
+func (a ASNInfo) String() string {
+    if a.Org == "" {
+        return fmt.Sprintf("AS%d", a.Number)
+    }
+    return fmt.Sprintf("AS%d %s", a.Number, a.Org)
+}
+
+// ASNSource résout l'ASN d'une IP. L'implémentation par défaut interroge le
+// WHOIS DNS de Team Cymru ; une base MaxMind ASN peut la remplacer.
+type ASNSource interface {
+    LookupASN(ip string) (ASNInfo, error)
+}
+
+// cymruASN interroge origin(6).asn.cymru.com puis asn.cymru.com (sans clé d'API).
+type cymruASN struct{}
+
+func (cymruASN) LookupASN(ip string) (ASNInfo, error) {
+    parsed := net.ParseIP(ip)
+    if parsed == nil {
+        return ASNInfo{}, fmt.Errorf("invalid IP %q", ip)
+    }
+
+    // Réponse: "15169 | 8.8.8.0/24 | US | arin | 2000-03-30"
+    fields, err := cymruTXT(cymruOriginName(parsed))
+    if err != nil {
+        return ASNInfo{}, err
+    }
+    // Plusieurs AS peuvent annoncer le préfixe : on garde le premier
+    asField := strings.Fields(fields[0])
+    if len(asField) == 0 {
+        return ASNInfo{}, fmt.Errorf("empty ASN for %s", ip)
+    }
+    number, err := strconv.ParseUint(asField[0], 10, 32)
+    if err != nil {
+        return ASNInfo{}, fmt.Errorf("invalid ASN %q: %v", asField[0], err)
+    }
+    info := ASNInfo{Number: uint32(number)}
+
+    // Réponse: "15169 | US | arin | 2000-03-30 | GOOGLE - Google LLC, US"
+    if fields, err := cymruTXT(fmt.Sprintf("AS%d.asn.cymru.com", number)); err == nil && len(fields) >= 5 {
+        info.Org = cymruOrgName(fields[4])
+    }
+    return info, nil
+}
+
+// cymruOriginName construit le nom à interroger : octets (IPv4) ou
+// quartets (IPv6) inversés, comme pour in-addr.arpa / ip6.arpa.
+func cymruOriginName(ip net.IP) string {
+    if v4 := ip.To4(); v4 != nil {
+        return fmt.Sprintf("%d.%d.%d.%d.origin.asn.cymru.com", v4[3], v4[2], v4[1], v4[0])
+    }
+
+    const hexDigits = "0123456789abcdef"
+    v6 := ip.To16()
+    nibbles := make([]string, 0, 32)
+    for i := len(v6) - 1; i >= 0; i-- {
+        nibbles = append(nibbles, string(hexDigits[v6[i]&0x0f]), string(hexDigits[v6[i]>>4]))
+    }
+    return strings.Join(nibbles, ".") + ".origin6.asn.cymru.com"
+}
+
+// cymruTXT retourne les champs (séparés par "|") du premier enregistrement TXT.
+func cymruTXT(name string) ([]string, error) {
+    records, err := net.LookupTXT(name)
+    if err != nil {
+        return nil, err
+    }
+    if len(records) == 0 {
+        return nil, fmt.Errorf("no TXT record for %s", name)
+    }
+    fields := strings.Split(records[0], "|")
+    for i := range fields {
+        fields[i] = strings.TrimSpace(fields[i])
+    }
+    return fields, nil
+}
+
+// cymruOrgName extrait le nom lisible de "GOOGLE - Google LLC, US".
+func cymruOrgName(raw string) string {
+    name := raw
+    if i := strings.Index(name, " - "); i >= 0 {
+        name = name[i+3:]
+    }
+    if i := strings.LastIndex(name, ", "); i >= 0 && len(name)-i == 4 {
+        name = name[:i]
+    }
+    return name
+}
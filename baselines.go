@@ -0,0 +1,91 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "strings"
+    "time"
+)
+
+// loadProviderBaselines lit les délais fixes par fournisseur (--provider-baselines),
+// un objet JSON associant un fournisseur (champ Provider des serveurs, sans
+// tenir compte de la casse) à une durée : {"Cloudflare": "300us", "Vultr": "2ms"}.
+func loadProviderBaselines(path string) (map[string]time.Duration, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    var raw map[string]string
+    if err := json.Unmarshal(data, &raw); err != nil {
+        return nil, fmt.Errorf("%s: %v", path, err)
+    }
+    baselines := make(map[string]time.Duration, len(raw))
+    for provider, value := range raw {
+        d, err := time.ParseDuration(value)
+        if err != nil || d < 0 {
+            return nil, fmt.Errorf("%s: %s: invalid baseline %q", path, provider, value)
+        }
+        baselines[strings.ToLower(provider)] = d
+    }
+    return baselines, nil
+}
+
+// baselineFor retourne le délai fixe à retirer des RTT du serveur : celui de
+// son fournisseur s'il est connu, sinon la baseline globale (--baseline).
+func (o Options) baselineFor(s Server) time.Duration {
+    if d, ok := o.ProviderBaselines[strings.ToLower(s.Provider)]; ok && s.Provider != "" {
+        return d
+    }
+    return o.Baseline
+}
+
+// fillProviders renseigne le fournisseur des mesures de sessions antérieures
+// au champ Provider, d'après le serveur de même nom et même IP de la base.
+func fillProviders(measurements []Measurement, servers []Server) {
+    providers := make(map[[2]string]string, len(servers))
+    for _, s := range servers {
+        providers[[2]string{s.Name, s.IP}] = s.Provider
+    }
+    for i := range measurements {
+        if s := &measurements[i].Server; s.Provider == "" {
+            s.Provider = providers[[2]string{s.Name, s.IP}]
+        }
+    }
+}
+
+// Part des serveurs les mieux classés au-delà de laquelle un fournisseur est
+// signalé comme dominant
+const dominantProviderShare = 0.5
+
+// providerOf retourne le fournisseur d'un serveur : son champ Provider, ou à
+// défaut (base personnalisée, session ancienne) le début de son nom jusqu'au
+// premier tiret ou espace ("OVH-Strasbourg", "Google DNS").
+func providerOf(s Server) string {
+    if s.Provider != "" {
+        return s.Provider
+    }
+    if i := strings.IndexAny(s.Name, "- "); i > 0 {
+        return s.Name[:i]
+    }
+    return s.Name
+}
+
+// dominantProvider retourne le fournisseur qui exploite au moins
+// dominantProviderShare des serveurs de results (plus d'un serveur), et
+// leur nombre ; 0 s'il n'y en a pas.
+func dominantProvider(results []Result) (string, int) {
+    counts := make(map[string]int)
+    best, bestCount := "", 0
+    for _, r := range results {
+        p := providerOf(r.Server)
+        counts[p]++
+        if counts[p] > bestCount || (counts[p] == bestCount && p < best) {
+            best, bestCount = p, counts[p]
+        }
+    }
+    if bestCount < 2 || float64(bestCount) < dominantProviderShare*float64(len(results)) {
+        return "", 0
+    }
+    return best, bestCount
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+    "math"
+
+    "triangula/geo"
+)
+
+// BoundingBox est la zone commune aux boîtes latitude/longitude des serveurs
+// (position du serveur ± sa distance estimée), méthode grossière de dernier
+// recours. Ses dimensions, en km, tiennent lieu d'incertitude.
+type BoundingBox struct {
+    Center   Location `json:"center"`
+    South    float64  `json:"south"`
+    North    float64  `json:"north"`
+    West     float64  `json:"west"`
+    East     float64  `json:"east"`
+    WidthKm  float64  `json:"width_km"`
+    HeightKm float64  `json:"height_km"`
+    Servers  int      `json:"servers"` // boîtes retenues dans l'intersection
+    Skipped  int      `json:"skipped"` // boîtes écartées faute de recouvrement
+}
+
+// boundingBoxIntersection intersecte les boîtes des serveurs dans l'ordre du
+// classement. Une boîte qui viderait l'intersection est écartée : le
+// résultat est toujours borné, au pire la boîte du meilleur serveur. Les
+// longitudes sont relatives au meilleur serveur pour traverser
+// l'antiméridien ; une boîte qui couvre un pôle couvre toutes les longitudes.
+func boundingBoxIntersection(results []Result) BoundingBox {
+    if len(results) == 0 {
+        return BoundingBox{}
+    }
+    kmPerDeg := geo.EarthRadius * math.Pi / 180
+    ref := results[0].Server.Lon
+
+    var box BoundingBox
+    for i, r := range results {
+        dLat := r.Distance / kmPerDeg
+        south, north := r.Server.Lat-dLat, r.Server.Lat+dLat
+        west, east := -180.0, 180.0
+        if south > -90 && north < 90 {
+            dLon := dLat / math.Cos(r.Server.Lat*math.Pi/180)
+            lon := normalizeLon(r.Server.Lon - ref)
+            west, east = math.Max(lon-dLon, -180), math.Min(lon+dLon, 180)
+        }
+        south, north = math.Max(south, -90), math.Min(north, 90)
+
+        if i > 0 {
+            south, north = math.Max(south, box.South), math.Min(north, box.North)
+            west, east = math.Max(west, box.West), math.Min(east, box.East)
+            if south > north || west > east {
+                box.Skipped++
+                continue
+            }
+        }
+        box.South, box.North, box.West, box.East = south, north, west, east
+        box.Servers++
+    }
+
+    lat := (box.South + box.North) / 2
+    box.Center = Location{Lat: lat, Lon: normalizeLon(ref + (box.West+box.East)/2)}
+    box.HeightKm = (box.North - box.South) * kmPerDeg
+    box.WidthKm = (box.East - box.West) * kmPerDeg * math.Cos(lat*math.Pi/180)
+    box.West, box.East = normalizeLon(ref+box.West), normalizeLon(ref+box.East)
+    return box
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "sort"
+    "strings"
+    "time"
+)
+
+// Sous-commande de caractérisation du réseau local, sans cible
+const benchmarkCommand = "benchmark"
+
+// Région des serveurs sans continent (entrées anycast "Global")
+const regionAnycast = "Anycast"
+
+// RTTDistribution résume les RTT d'une région.
+type RTTDistribution struct {
+    Region    string  `json:"region"`
+    Servers   int     `json:"servers"`
+    Responded int     `json:"responded"`
+    MinMs     float64 `json:"min_ms,omitempty"`
+    MedianMs  float64 `json:"median_ms,omitempty"`
+    P90Ms     float64 `json:"p90_ms,omitempty"`
+    MaxMs     float64 `json:"max_ms,omitempty"`
+}
+
+// BenchmarkReport est le résultat de la sous-commande benchmark.
+type BenchmarkReport struct {
+    Timestamp   time.Time         `json:"timestamp"`
+    Servers     int               `json:"servers"`
+    Measured    int               `json:"measured"` // mesures menées à terme (voir SweepSummary)
+    Responded   int               `json:"responded"`
+    Interrupted bool              `json:"interrupted,omitempty"`
+    PacketSize  int               `json:"packet_size,omitempty"` // 0 : taille par défaut
+    Continents  []RTTDistribution `json:"continents"`
+    Unreachable []string          `json:"unreachable"` // continents et pays sans aucune réponse
+}
+
+// percentile retourne le centile p (0-1) de durées triées, par rang le
+// plus proche.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+    i := int(float64(len(sorted))*p+0.5) - 1
+    if i < 0 {
+        i = 0
+    }
+    if i >= len(sorted) {
+        i = len(sorted) - 1
+    }
+    return sorted[i]
+}
+
+// runBenchmark pinge toute la base et regroupe les RTT par continent.
+func runBenchmark(ctx context.Context, servers []Server, opts Options) BenchmarkReport {
+    measurements := sweepServers(ctx, servers, 0, opts)
+    summary := summarizeSweep(measurements)
+    report := BenchmarkReport{
+        Timestamp:   time.Now(),
+        Servers:     summary.Total,
+        Measured:    summary.Measured,
+        Responded:   summary.Responded,
+        PacketSize:  opts.PacketSize,
+        Unreachable: []string{},
+    }
+
+    rtts := make(map[string][]time.Duration)
+    counts := make(map[string]int)
+    countryResponded := make(map[string]int)
+    var countries []string
+    for _, m := range measurements {
+        if m.Cancelled {
+            continue
+        }
+        region := continentOf(m.Server.Country)
+        if region == "" {
+            region = regionAnycast
+        }
+        counts[region]++
+        if _, ok := countryResponded[m.Server.Country]; !ok {
+            countries = append(countries, m.Server.Country)
+            countryResponded[m.Server.Country] = 0
+        }
+        if m.Error == "" {
+            rtts[region] = append(rtts[region], m.RTT)
+            countryResponded[m.Server.Country]++
+        }
+    }
+
+    var regions []string
+    for region := range counts {
+        regions = append(regions, region)
+    }
+    sort.Strings(regions)
+    for _, region := range regions {
+        d := RTTDistribution{Region: region, Servers: counts[region], Responded: len(rtts[region])}
+        if samples := rtts[region]; len(samples) > 0 {
+            sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+            d.MinMs = milliseconds(samples[0])
+            d.MedianMs = milliseconds(percentile(samples, 0.5))
+            d.P90Ms = milliseconds(percentile(samples, 0.9))
+            d.MaxMs = milliseconds(samples[len(samples)-1])
+        } else {
+            report.Unreachable = append(report.Unreachable, region)
+        }
+        report.Continents = append(report.Continents, d)
+    }
+
+    sort.Strings(countries)
+    for _, country := range countries {
+        region := continentOf(country)
+        if countryResponded[country] == 0 && len(rtts[region]) > 0 {
+            report.Unreachable = append(report.Unreachable, country)
+        }
+    }
+    return report
+}
+
+// writeBenchmark écrit le rapport en texte, Markdown ou JSON (--output).
+func writeBenchmark(w io.Writer, report BenchmarkReport, format string) error {
+    if format == outputJSON {
+        enc := json.NewEncoder(w)
+        enc.SetIndent("", "  ")
+        return enc.Encode(report)
+    }
+
+    if format == outputMarkdown {
+        fmt.Fprintf(w, "## %s\n\n", strings.TrimSpace(tr("bench.title")))
+        fmt.Fprintln(w, tr("bench.mdHeader"))
+        fmt.Fprintln(w, "|---|---:|---:|---:|---:|---:|---:|")
+    } else {
+        fmt.Fprintln(w, "\n"+strings.Repeat("=", 80))
+        fmt.Fprintln(w, tr("bench.title"))
+        fmt.Fprintln(w, strings.Repeat("=", 80))
+        fmt.Fprintln(w, tr("bench.header"))
+    }
+    for _, d := range report.Continents {
+        if format == outputMarkdown {
+            fmt.Fprintf(w, "| %s | %d | %d | %.1f | %.1f | %.1f | %.1f |\n",
+                d.Region, d.Servers, d.Responded, d.MinMs, d.MedianMs, d.P90Ms, d.MaxMs)
+        } else {
+            fmt.Fprintf(w, "%-15s %5d %5d %9.1f %9.1f %9.1f %9.1f\n",
+                d.Region, d.Servers, d.Responded, d.MinMs, d.MedianMs, d.P90Ms, d.MaxMs)
+        }
+    }
+
+    fmt.Fprintln(w)
+    if report.Interrupted {
+        fmt.Fprintf(w, tr("results.interrupted"), report.Measured)
+    }
+    fmt.Fprintf(w, tr("bench.total"), report.Responded, report.Servers)
+    if len(report.Unreachable) > 0 {
+        fmt.Fprintf(w, tr("bench.unreachable"), strings.Join(report.Unreachable, ", "))
+    }
+    return nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "net"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// rttCache conserve les RTT mesurés vers les cibles pendant ttl, pour ne pas
+// remesurer la même cible d'une itération à l'autre (--watch, --serve).
+// Un cache nil est désactivé.
+type rttCache struct {
+    ttl     time.Duration
+    mu      sync.Mutex
+    entries map[string]rttEntry
+}
+
+type rttEntry struct {
+    stats PingStats
+    at    time.Time
+}
+
+// newRTTCache retourne un cache de durée ttl, ou nil si ttl <= 0.
+func newRTTCache(ttl time.Duration) *rttCache {
+    if ttl <= 0 {
+        return nil
+    }
+    return &rttCache{ttl: ttl, entries: make(map[string]rttEntry)}
+}
+
+// cacheKey distingue les mesures ICMP de celles d'un port TCP précis.
+func cacheKey(target Target) string {
+    if target.Port != 0 {
+        return net.JoinHostPort(target.IP, strconv.Itoa(target.Port))
+    }
+    return target.IP
+}
+
+// Get retourne la mesure encore valide pour la cible et son âge.
+func (c *rttCache) Get(target Target) (PingStats, time.Duration, bool) {
+    if c == nil {
+        return PingStats{}, 0, false
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    entry, ok := c.entries[cacheKey(target)]
+    if !ok {
+        return PingStats{}, 0, false
+    }
+    age := time.Since(entry.at)
+    if age > c.ttl {
+        delete(c.entries, cacheKey(target))
+        return PingStats{}, 0, false
+    }
+    return entry.stats, age, true
+}
+
+func (c *rttCache) Put(target Target, stats PingStats) {
+    if c == nil {
+        return
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.entries[cacheKey(target)] = rttEntry{stats: stats, at: time.Now()}
+}
+
+// Durée par défaut de conservation des résolutions DNS (--dns-cache-ttl)
+const defaultDNSCacheTTL = 5 * time.Minute
+
+// dnsCache conserve pendant ttl les adresses résolues par lookup
+// (cibles et serveurs de référence désignés par un nom), pour ne pas
+// solliciter le résolveur à chaque requête de --serve ou campagne. Partagé
+// par les workers : les accès sont protégés par mu. Un cache nil est
+// désactivé et interroge toujours le résolveur.
+type dnsCache struct {
+    ttl     time.Duration
+    lookup  func(host string) ([]net.IP, error) // net.LookupIP, sauf dans les tests
+    mu      sync.Mutex
+    entries map[string]dnsEntry
+}
+
+type dnsEntry struct {
+    ips []net.IP
+    at  time.Time
+}
+
+// newDNSCache retourne un cache de durée ttl, ou nil si ttl <= 0.
+func newDNSCache(ttl time.Duration) *dnsCache {
+    if ttl <= 0 {
+        return nil
+    }
+    return &dnsCache{ttl: ttl, lookup: net.LookupIP, entries: make(map[string]dnsEntry)}
+}
+
+// LookupIP est net.LookupIP derrière le cache. Les échecs ne sont pas
+// conservés : un nom momentanément irrésoluble est redemandé au prochain appel.
+func (c *dnsCache) LookupIP(host string) ([]net.IP, error) {
+    if c == nil {
+        return net.LookupIP(host)
+    }
+    c.mu.Lock()
+    entry, ok := c.entries[host]
+    if ok && time.Since(entry.at) > c.ttl {
+        delete(c.entries, host)
+        ok = false
+    }
+    c.mu.Unlock()
+    if ok {
+        return entry.ips, nil
+    }
+
+    // Résolution hors verrou : deux workers peuvent résoudre le même nom en
+    // parallèle, mais une requête lente ne bloque pas les autres
+    ips, err := c.lookup(host)
+    if err != nil {
+        return nil, err
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.entries[host] = dnsEntry{ips: ips, at: time.Now()}
+    return ips, nil
+}
+
+// ipv6OnlyError signale un nom qui ne se résout qu'en IPv6 (enregistrements
+// AAAA seuls). Les serveurs de référence sont mesurés en IPv4 : la latence
+// d'une cible jointe en IPv6 suit d'autres routes et ne leur est pas
+// directement comparable.
+type ipv6OnlyError struct {
+    Host  string
+    Addrs []string
+}
+
+func (e *ipv6OnlyError) Error() string {
+    return fmt.Sprintf("%s resolves only to IPv6 (%s)", e.Host, strings.Join(e.Addrs, ", "))
+}
+
+// ResolveIPv4 retourne l'adresse IPv4 de host, ou une *ipv6OnlyError si le
+// nom n'a que des adresses IPv6 ; une réponse sans aucune adresse est une
+// erreur. Une adresse IP (v4 ou v6) est retournée telle quelle, sans passer
+// par le résolveur : c'est un choix explicite.
+func (c *dnsCache) ResolveIPv4(host string) (string, error) {
+    if ip := net.ParseIP(host); ip != nil {
+        return ip.String(), nil
+    }
+
+    ips, err := c.LookupIP(host)
+    if err != nil {
+        return "", err
+    }
+    if len(ips) == 0 {
+        return "", fmt.Errorf("%s: no address", host)
+    }
+    v6 := &ipv6OnlyError{Host: host}
+    for _, ip := range ips {
+        if ip.To4() != nil {
+            return ip.String(), nil
+        }
+        v6.Addrs = append(v6.Addrs, ip.String())
+    }
+    return "", v6
+}
+
+// Resolve retourne l'adresse IP de host, en privilégiant l'IPv4. Une
+// adresse IP est retournée telle quelle, sans passer par le résolveur.
+func (c *dnsCache) Resolve(host string) (string, error) {
+    ip, err := c.ResolveIPv4(host)
+    var v6 *ipv6OnlyError
+    if errors.As(err, &v6) {
+        return v6.Addrs[0], nil
+    }
+    return ip, err
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+    "errors"
+    "net"
+    "testing"
+    "time"
+)
+
+// fakeDNSCache retourne un cache dont le résolveur répond avec records.
+func fakeDNSCache(records map[string][]string) *dnsCache {
+    c := newDNSCache(time.Minute)
+    c.lookup = func(host string) ([]net.IP, error) {
+        addrs, ok := records[host]
+        if !ok {
+            return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+        }
+        ips := make([]net.IP, len(addrs))
+        for i, a := range addrs {
+            ips[i] = net.ParseIP(a)
+        }
+        return ips, nil
+    }
+    return c
+}
+
+func TestResolveIPv4(t *testing.T) {
+    cache := fakeDNSCache(map[string][]string{
+        "dual.example":   {"2001:db8::1", "192.0.2.1"},
+        "v4.example":     {"192.0.2.2"},
+        "v6only.example": {"2001:db8::2", "2001:db8::3"},
+        "empty.example":  {},
+    })
+    tests := []struct {
+        host   string
+        want   string
+        v6Only bool
+        fails  bool
+    }{
+        {host: "dual.example", want: "192.0.2.1"},
+        {host: "v4.example", want: "192.0.2.2"},
+        {host: "v6only.example", v6Only: true},
+        {host: "missing.example", fails: true},
+        {host: "empty.example", fails: true},
+        {host: "198.51.100.7", want: "198.51.100.7"},
+        {host: "2001:db8::9", want: "2001:db8::9"},
+    }
+    for _, tt := range tests {
+        got, err := cache.ResolveIPv4(tt.host)
+        var v6 *ipv6OnlyError
+        switch {
+        case tt.v6Only:
+            if !errors.As(err, &v6) {
+                t.Errorf("ResolveIPv4(%q) error = %v, want *ipv6OnlyError", tt.host, err)
+            } else if len(v6.Addrs) != 2 || v6.Host != tt.host {
+                t.Errorf("ResolveIPv4(%q) error = %+v", tt.host, v6)
+            }
+        case tt.fails:
+            if err == nil || errors.As(err, &v6) {
+                t.Errorf("ResolveIPv4(%q) error = %v, want a lookup error", tt.host, err)
+            }
+        case err != nil || got != tt.want:
+            t.Errorf("ResolveIPv4(%q) = %q, %v, want %q", tt.host, got, err, tt.want)
+        }
+    }
+}
+
+func TestResolveFallsBackToIPv6(t *testing.T) {
+    cache := fakeDNSCache(map[string][]string{"v6only.example": {"2001:db8::2", "2001:db8::3"}})
+    got, err := cache.Resolve("v6only.example")
+    if err != nil || got != "2001:db8::2" {
+        t.Errorf("Resolve = %q, %v, want the first IPv6 address", got, err)
+    }
+}
+
+func TestResolveWithoutAddress(t *testing.T) {
+    cache := fakeDNSCache(map[string][]string{"empty.example": {}})
+    if got, err := cache.Resolve("empty.example"); err == nil {
+        t.Errorf("Resolve = %q, want an error for a lookup without addresses", got)
+    }
+}
+
+func TestDNSCacheReusesLookups(t *testing.T) {
+    cache := fakeDNSCache(map[string][]string{"v4.example": {"192.0.2.2"}})
+    lookup, calls := cache.lookup, 0
+    cache.lookup = func(host string) ([]net.IP, error) {
+        calls++
+        return lookup(host)
+    }
+    for i := 0; i < 3; i++ {
+        if _, err := cache.Resolve("v4.example"); err != nil {
+            t.Fatal(err)
+        }
+    }
+    if calls != 1 {
+        t.Errorf("resolver called %d times, want 1", calls)
+    }
+}
+
+func TestNewTargetIPv6Only(t *testing.T) {
+    cache := fakeDNSCache(map[string][]string{"v6only.example": {"2001:db8::2"}})
+    _, err := newTarget("v6only.example", 0, cache)
+    var v6 *ipv6OnlyError
+    if !errors.As(err, &v6) {
+        t.Errorf("newTarget error = %v, want *ipv6OnlyError", err)
+    }
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+    "math"
+
+    "triangula/geo"
+)
+
+// Nombre d'emplacements distincts dont les cercles sont intersectés (méthode 4)
+const circleServers = 5
+
+// point est une position projetée sur un plan tangent, en km (x vers l'est,
+// y vers le nord).
+type point struct{ X, Y float64 }
+
+// tangentPlane projette les positions sur le plan tangent à une origine, par
+// projection équirectangulaire : fidèle à quelques centaines de km près,
+// déformée au-delà.
+type tangentPlane struct {
+    Origin   Location
+    kmPerDeg float64
+    cosLat   float64
+}
+
+// newTangentPlane centre le plan sur la position moyenne, les longitudes
+// étant prises relativement à la première pour traverser l'antiméridien.
+func newTangentPlane(locations []Location) tangentPlane {
+    ref := locations[0].Lon
+    var lat, dLon float64
+    for _, l := range locations {
+        lat += l.Lat
+        dLon += normalizeLon(l.Lon - ref)
+    }
+    n := float64(len(locations))
+    origin := Location{Lat: lat / n, Lon: normalizeLon(ref + dLon/n)}
+    return tangentPlane{
+        Origin:   origin,
+        kmPerDeg: geo.EarthRadius * math.Pi / 180,
+        cosLat:   math.Max(math.Cos(origin.Lat*math.Pi/180), 1e-9),
+    }
+}
+
+func (t tangentPlane) project(l Location) point {
+    return point{
+        X: normalizeLon(l.Lon-t.Origin.Lon) * t.kmPerDeg * t.cosLat,
+        Y: (l.Lat - t.Origin.Lat) * t.kmPerDeg,
+    }
+}
+
+func (t tangentPlane) unproject(p point) Location {
+    return Location{
+        Lat: math.Max(-90, math.Min(90, t.Origin.Lat+p.Y/t.kmPerDeg)),
+        Lon: normalizeLon(t.Origin.Lon + p.X/(t.kmPerDeg*t.cosLat)),
+    }
+}
+
+// intersectCircles retourne les deux points d'intersection des cercles
+// (c1, r1) et (c2, r2), false s'ils ne se coupent pas (trop éloignés, l'un
+// contenant l'autre, ou concentriques).
+func intersectCircles(c1 point, r1 float64, c2 point, r2 float64) (point, point, bool) {
+    dx, dy := c2.X-c1.X, c2.Y-c1.Y
+    d := math.Hypot(dx, dy)
+    if d == 0 || d > r1+r2 || d < math.Abs(r1-r2) {
+        return point{}, point{}, false
+    }
+    // a : distance de c1 à la corde commune, h : demi-longueur de la corde
+    a := (r1*r1 - r2*r2 + d*d) / (2 * d)
+    h := math.Sqrt(math.Max(r1*r1-a*a, 0))
+    mx, my := c1.X+a*dx/d, c1.Y+a*dy/d
+    return point{mx - h*dy/d, my + h*dx/d}, point{mx + h*dy/d, my - h*dx/d}, true
+}
+
+// circleIntersect projette les circleServers meilleurs emplacements distincts
+// sur le plan tangent à leur position moyenne et intersecte leurs cercles de
+// distance deux à deux. De chaque paire, le point d'intersection le plus
+// proche des autres cercles est retenu ; la position est la moyenne de ces
+// points. false si aucune paire de cercles ne se coupe : les distances sont
+// alors incompatibles entre elles.
+func circleIntersect(results []Result) (Location, bool) {
+    // Des serveurs au même emplacement donneraient des cercles concentriques :
+    // seul le mieux classé de chaque emplacement est retenu
+    var anchors []Result
+    var locations []Location
+    seen := make(map[Location]bool)
+    for _, r := range results {
+        loc := serverLocation(r.Server)
+        if seen[loc] {
+            continue
+        }
+        seen[loc] = true
+        anchors = append(anchors, r)
+        locations = append(locations, loc)
+        if len(anchors) == circleServers {
+            break
+        }
+    }
+    n := len(anchors)
+    if n < 2 {
+        return Location{}, false
+    }
+
+    plane := newTangentPlane(locations)
+    centers := make([]point, n)
+    for i, l := range locations {
+        centers[i] = plane.project(l)
+    }
+
+    // Écart quadratique d'un point aux cercles autres que i et j
+    misfit := func(p point, i, j int) float64 {
+        var sum float64
+        for k := range centers {
+            if k != i && k != j {
+                r := math.Hypot(p.X-centers[k].X, p.Y-centers[k].Y) - anchors[k].Distance
+                sum += r * r
+            }
+        }
+        return sum
+    }
+
+    var sum point
+    var count int
+    for i := 0; i < n; i++ {
+        for j := i + 1; j < n; j++ {
+            p, q, ok := intersectCircles(centers[i], anchors[i].Distance, centers[j], anchors[j].Distance)
+            if !ok {
+                continue
+            }
+            if misfit(q, i, j) < misfit(p, i, j) {
+                p = q
+            }
+            sum.X += p.X
+            sum.Y += p.Y
+            count++
+        }
+    }
+    if count == 0 {
+        return Location{}, false
+    }
+    return plane.unproject(point{sum.X / float64(count), sum.Y / float64(count)}), true
+}
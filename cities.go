@@ -0,0 +1,97 @@
+package main
+
+import (
+    "math"
+    "sort"
+
+    "triangula/geo"
+)
+
+// City est une ville connue, dérivée de la base de serveurs.
+type City struct {
+    Name    string
+    Country string
+    Lat     float64
+    Lon     float64
+}
+
+// knownCities extrait les villes distinctes de la base, hors entrées "Global"
+// dont la ville n'est pas une vraie localisation.
+func knownCities(servers []Server) []City {
+    seen := make(map[string]bool)
+    var cities []City
+    for _, s := range servers {
+        if s.Country == "Global" || s.City == "" {
+            continue
+        }
+        key := s.Country + "/" + s.City
+        if seen[key] {
+            continue
+        }
+        seen[key] = true
+        cities = append(cities, City{Name: s.City, Country: s.Country, Lat: s.Lat, Lon: s.Lon})
+    }
+    return cities
+}
+
+// nearestCity retourne la ville connue la plus proche de loc et sa distance en km.
+func nearestCity(loc Location, cities []City) (City, float64, bool) {
+    best, bestDist := City{}, math.Inf(1)
+    for _, c := range cities {
+        if d := geo.Distance(loc.Lat, loc.Lon, c.Lat, c.Lon); d < bestDist {
+            best, bestDist = c, d
+        }
+    }
+    return best, bestDist, len(cities) > 0
+}
+
+const (
+    // Nombre de serveurs de référence les plus proches consultés
+    countryAnchors = 10
+    // Part minimale du pays majoritaire pour conclure sans ambiguïté
+    countryMajority = 0.6
+)
+
+// CountryShare est la part des ancres proches situées dans un pays.
+type CountryShare struct {
+    Country string
+    Share   float64
+}
+
+// inferCountry vote parmi les ancres les plus proches de loc et retourne les
+// pays par part décroissante (à égalité, ordre alphabétique).
+func inferCountry(loc Location, servers []Server) []CountryShare {
+    type anchor struct {
+        country string
+        dist    float64
+    }
+    var anchors []anchor
+    for _, s := range servers {
+        if s.Country == "Global" || s.Country == "" {
+            continue
+        }
+        anchors = append(anchors, anchor{s.Country, geo.Distance(loc.Lat, loc.Lon, s.Lat, s.Lon)})
+    }
+    sort.Slice(anchors, func(i, j int) bool {
+        return anchors[i].dist < anchors[j].dist
+    })
+    if len(anchors) > countryAnchors {
+        anchors = anchors[:countryAnchors]
+    }
+
+    counts := make(map[string]int)
+    for _, a := range anchors {
+        counts[a.country]++
+    }
+    shares := make([]CountryShare, 0, len(counts))
+    for country, n := range counts {
+        shares = append(shares, CountryShare{country, float64(n) / float64(len(anchors))})
+    }
+    sort.Slice(shares, func(i, j int) bool {
+        if shares[i].Share != shares[j].Share {
+            return shares[i].Share > shares[j].Share
+        }
+        return shares[i].Country < shares[j].Country
+    })
+    return shares
+}
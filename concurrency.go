@@ -0,0 +1,181 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "strconv"
+    "sync"
+)
+
+// Valeur de --concurrency confiant le nombre de workers au contrôleur
+const concurrencyAuto = "auto"
+
+// Réglage de --concurrency=auto : le nombre de workers part de
+// autoConcurrencyMin et double tant que la perte et la gigue relative
+// (gigue / RTT) des serveurs ayant répondu restent proches de celles de la
+// première fenêtre, mesurée à faible parallélisme. Au-delà, le lien local est
+// supposé saturé : le nombre est divisé par deux, puis ne remonte plus que de
+// autoConcurrencyMin par fenêtre.
+const (
+    autoConcurrencyMin    = 8
+    autoConcurrencyMax    = 256
+    autoConcurrencyWindow = 8    // mesures minimales par fenêtre (au moins le nombre de workers)
+    autoLossMargin        = 0.1  // hausse de perte tolérée
+    autoJitterRatio       = 1.5  // hausse relative de la gigue tolérée
+    autoJitterFloor       = 0.02 // gigue relative de référence minimale
+)
+
+// concurrencyLimit borne le nombre de mesures de serveurs menées en même
+// temps par sweepServers (--concurrency). Elle peut être partagée entre
+// campagnes (--watch, --serve) : la limite porte alors sur l'ensemble des
+// mesures en cours, et le contrôleur automatique garde ce qu'il a appris.
+// Une limite nil laisse un worker par serveur.
+type concurrencyLimit struct {
+    mu      sync.Mutex
+    active  int
+    limit   int
+    settled int           // limite en vigueur au dernier lancement
+    wake    chan struct{} // fermé à chaque fin de mesure
+
+    auto       bool
+    ramping    bool // doublement jusqu'au premier recul
+    window     []concurrencySample
+    stale      int // mesures lancées avant le dernier ajustement, encore à ignorer
+    calibrated bool
+    baseLoss   float64
+    baseJitter float64
+}
+
+type concurrencySample struct {
+    loss   float64
+    jitter float64 // relative au RTT
+}
+
+// parseConcurrency interprète --concurrency : vide pour un worker par
+// serveur, un entier strictement positif ou concurrencyAuto.
+func parseConcurrency(s string) (*concurrencyLimit, error) {
+    if s == "" {
+        return nil, nil
+    }
+    c := &concurrencyLimit{wake: make(chan struct{})}
+    if s == concurrencyAuto {
+        c.auto, c.ramping, c.limit = true, true, autoConcurrencyMin
+        return c, nil
+    }
+    n, err := strconv.Atoi(s)
+    if err != nil || n <= 0 {
+        return nil, fmt.Errorf("invalid concurrency %q", s)
+    }
+    c.limit = n
+    return c, nil
+}
+
+// acquire attend qu'un worker se libère ; false si ctx est annulé avant.
+func (c *concurrencyLimit) acquire(ctx context.Context) bool {
+    if c == nil {
+        return ctx.Err() == nil
+    }
+    for {
+        c.mu.Lock()
+        if c.active < c.limit {
+            c.active++
+            c.settled = c.limit
+            c.mu.Unlock()
+            return true
+        }
+        wake := c.wake
+        c.mu.Unlock()
+
+        select {
+        case <-wake:
+        case <-ctx.Done():
+            return false
+        }
+    }
+}
+
+// release libère le worker de la mesure m, qui alimente le contrôleur
+// automatique.
+func (c *concurrencyLimit) release(m Measurement) {
+    if c == nil {
+        return
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    c.active--
+    if c.auto {
+        c.observe(m)
+    }
+    close(c.wake)
+    c.wake = make(chan struct{})
+}
+
+// observe ajoute la mesure à la fenêtre et, une fois celle-ci pleine, ajuste
+// la limite. Les échecs (serveur éteint, annulation) ne disent rien de la
+// saturation du lien et sont ignorés, comme les mesures lancées avant un
+// ajustement, qui refléteraient encore l'ancienne limite. Appelé sous mu.
+func (c *concurrencyLimit) observe(m Measurement) {
+    if c.stale > 0 {
+        c.stale--
+        return
+    }
+    if m.Error != "" || m.RTT <= 0 {
+        return
+    }
+    c.window = append(c.window, concurrencySample{loss: m.Server.Loss, jitter: float64(m.Jitter) / float64(m.RTT)})
+    if len(c.window) < max(c.limit, autoConcurrencyWindow) {
+        return
+    }
+
+    var loss, jitter float64
+    for _, s := range c.window {
+        loss += s.loss
+        jitter += s.jitter
+    }
+    loss /= float64(len(c.window))
+    jitter /= float64(len(c.window))
+    c.window = c.window[:0]
+    if !c.calibrated {
+        c.baseLoss, c.baseJitter, c.calibrated = loss, max(jitter, autoJitterFloor), true
+    }
+
+    previous := c.limit
+    switch {
+    case loss > c.baseLoss+autoLossMargin || jitter > c.baseJitter*autoJitterRatio:
+        c.limit, c.ramping = max(autoConcurrencyMin, c.limit/2), false
+    case c.ramping:
+        c.limit = min(autoConcurrencyMax, c.limit*2)
+    default:
+        c.limit = min(autoConcurrencyMax, c.limit+autoConcurrencyMin)
+    }
+    if c.limit != previous {
+        c.stale = c.active
+        slog.Debug("concurrency adjusted", "from", previous, "to", c.limit, "loss", loss, "jitter", jitter,
+            "base_loss", c.baseLoss, "base_jitter", c.baseJitter)
+    }
+}
+
+// Settled retourne le nombre de workers retenu par --concurrency=auto (la
+// limite au dernier lancement de mesure) ; 0 pour une limite fixe ou nil.
+func (c *concurrencyLimit) Settled() int {
+    if c == nil || !c.auto {
+        return 0
+    }
+    c.mu.Lock()
+    defer c.mu.Unlock()
+    return c.settled
+}
+
+// Workers retourne le nombre maximal de workers pour n serveurs : n sans
+// limite, autoConcurrencyMax en mode automatique.
+func (c *concurrencyLimit) Workers(n int) int {
+    switch {
+    case c == nil:
+        return n
+    case c.auto:
+        return min(n, autoConcurrencyMax)
+    default:
+        return min(n, c.limit)
+    }
+}
@@ -0,0 +1,628 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "io"
+    "log/slog"
+    "math/rand"
+    "os"
+    "strings"
+    "text/template"
+    "time"
+
+    "triangula/geo"
+)
+
+// config regroupe les options de la ligne de commande (un champ par option,
+// sous le nom de l'option) et les valeurs que parseConfig en déduit.
+type config struct {
+    Command string // sous-commande (list-servers, benchmark...) ; vide : analyse d'une cible
+    Target  string // cible passée en argument ; vide : demandée sur l'entrée standard
+
+    Lang                  string
+    ICMPSocket            string
+    Units                 string
+    TemplatePath          string
+    Maps                  string
+    ASN                   bool
+    TimezonesPath         string
+    GeoIPPath             string
+    Compare               string
+    SaveSession           string
+    Replay                string
+    Agent                 bool
+    AgentName             string
+    Out                   string
+    OutDir                string
+    Force                 bool
+    VantageSpec           string
+    MyLocationSpec        string
+    Coordinate            string
+    Seed                  int64
+    Strict                bool
+    Count                 int
+    TargetCount           int
+    AdaptiveStdDev        time.Duration
+    MinCount              int
+    PacketSize            int
+    SourceSpec            string
+    Interface             string
+    Timeout               time.Duration
+    ModelName             string
+    VelocityFactor        float64
+    Baseline              time.Duration
+    CalibrationPath       string
+    DBURL                 string
+    DBPubKey              string
+    DBChecksum            string
+    ProviderBaselinesPath string
+    Deadline              time.Duration
+    Backend               string
+    HopDelay              time.Duration
+    Traceroute            bool
+    TargetCacheTTL        time.Duration
+    DNSCacheTTL           time.Duration
+    ServerCacheTTL        time.Duration
+    TCPFallback           bool
+    Serve                 string
+    ConcurrencySpec       string
+    ServeConcurrency      int
+    LogLevel              string
+    LogFormat             string
+    SolverName            string
+    IRLSLoss              string
+    IRLSThreshold         float64
+    Geometry              string
+    Weighting             string
+    ASCIIMap              bool
+    ASCIIMapWidth         int
+    Heatmap               string
+    ResidualsCSV          string
+    HeatmapRadius         float64
+    HeatmapStep           float64
+    DB                    string
+    History               string
+    MinLocations          int
+    DropInconsistent      bool
+    DedupeLocations       bool
+    Collapse              string
+    ContinentSolve        string
+    Rank                  string
+    Proximity             string
+    ProximityThresholds   string
+    Output                string
+    Reachability          bool
+    List                  bool
+    Region                string
+    RegionThreshold       float64
+    Watch                 time.Duration
+    ProcessNoise          float64
+    MeasurementNoise      float64
+    DryRun                bool
+    Verify                bool
+    MonteCarlo            int
+    Sample                int
+    Include               patternList
+    Exclude               patternList
+
+    // Valeurs déduites des options
+    Source            string // adresse source de --source ou --interface
+    Sampling          sequentialSampling
+    Measurer          Measurer // nil : backend icmp
+    BackendSet        bool     // --backend explicite : les sondes de la base sont ignorées
+    ProximityScale    proximityScale
+    Concurrency       *concurrencyLimit
+    Template          *template.Template // --template ; nil : rapport intégré
+    Model             geo.DistanceModel
+    Solver            geo.Solver
+    ProviderBaselines map[string]time.Duration
+    Skews             map[string]time.Duration
+    Zones             *timezoneIndex
+    Vantage           *Location
+    Comparisons       []comparisonSource
+
+    // Hors mode texte, stdout ne porte que le rapport : bannière,
+    // progression et messages passent sur stderr
+    Console  io.Writer
+    Progress *os.File
+}
+
+// errUsage signale une option invalide, déjà expliquée sur stderr.
+var errUsage = exitWith(exitUsage)
+
+// parseConfig lit et valide la ligne de commande, et fixe la langue, le
+// journal et les préférences d'affichage globales. Une option invalide est
+// expliquée sur stderr et errUsage retourné ; close libère ensuite les
+// sources de comparaison ouvertes.
+func parseConfig() (*config, error) {
+    c := &config{}
+    flag.StringVar(&c.Lang, "lang", "", "interface language (en, fr); defaults to $LANG, then en")
+    flag.StringVar(&c.ICMPSocket, "icmp-socket", icmpSocketAuto, "ICMP socket of the icmp backend ("+icmpSocketAuto+": by platform and privileges, "+icmpSocketRaw+": needs root, CAP_NET_RAW or Administrator, "+icmpSocketDatagram+": unprivileged, macOS or Linux with net.ipv4.ping_group_range)")
+    flag.StringVar(&c.Units, "units", unitKm, "distance display unit (km, mi)")
+    flag.StringVar(&c.TemplatePath, "template", "", "render the text report with this Go text/template file, or "+defaultTemplateName+" for the built-in layout (report.tmpl)")
+    flag.StringVar(&c.Maps, "maps", defaultMaps, "map links in reports, comma-separated ("+mapGoogle+", "+mapOSM+", "+mapOSMArea+": OpenStreetMap framed on the uncertainty radius)")
+    flag.BoolVar(&c.ASN, "asn", false, "look up the target's ASN and owner (extra DNS round trip)")
+    flag.StringVar(&c.TimezonesPath, "timezones", "", "timezone polygons (GeoJSON with a tzid property, e.g. timezone-boundary-builder) for a precise timezone guess; default is a longitude-based approximation")
+    flag.StringVar(&c.GeoIPPath, "geoip", "", "path to a GeoLite2-City.mmdb to compare against (requires -tags geoip)")
+    flag.StringVar(&c.Compare, "compare", "", "compare the estimates against these geolocation sources, comma-separated ("+strings.Join(geoLocatorNames(), ", ")+")")
+    flag.StringVar(&c.SaveSession, "save-session", "", "write raw measurements to this JSON file")
+    flag.StringVar(&c.Replay, "replay", "", "replay a saved session instead of measuring")
+    flag.BoolVar(&c.Agent, "agent", false, "agent mode: measure the target and the database, write a report for --coordinate to --out and exit")
+    flag.StringVar(&c.AgentName, "agent-name", hostname(), "name of this vantage point in agent reports")
+    flag.StringVar(&c.Out, "out", "", "agent report path (with --agent), or calibration file path (calibrate)")
+    flag.StringVar(&c.OutDir, "out-dir", "", "write the target's session (.json), region (.geojson), heatmap (.csv) and Markdown report (.md) to this directory, named after the target")
+    flag.BoolVar(&c.Force, "force", false, "overwrite existing files in --out-dir")
+    flag.StringVar(&c.VantageSpec, "vantage", "", "location (lat,lon) of this vantage point, recorded in agent reports and --save-session")
+    flag.StringVar(&c.MyLocationSpec, "my-location", "", "alias of --vantage, your own location (lat,lon): shown with each top server's distance from you, on --ascii-map and in --region")
+    flag.StringVar(&c.Coordinate, "coordinate", "", "fuse agent reports matching this pattern (e.g. 'reports/*.json') into one multilateration and exit")
+    flag.Int64Var(&c.Seed, "seed", 0, "seed for randomized steps (0 = random, or the replayed session's seed)")
+    flag.BoolVar(&c.Strict, "strict", false, "exit with a nonzero code when the result is unreliable (see README)")
+    flag.IntVar(&c.Count, "count", defaultServerPingCount, "pings per reference server")
+    flag.IntVar(&c.TargetCount, "target-count", defaultTargetPingCount, "pings to the target")
+    flag.DurationVar(&c.AdaptiveStdDev, "adaptive-stddev", 0, "stop pinging a server or the target once the RTT std dev drops below this (e.g. 2ms); --count and --target-count become maximums (icmp backend, DNS and TCP probes); 0 = fixed counts")
+    flag.IntVar(&c.MinCount, "min-count", defaultMinPingCount, "replies required before --adaptive-stddev may stop a measurement (at least 2)")
+    flag.IntVar(&c.PacketSize, "packet-size", 0, fmt.Sprintf("ICMP payload size in bytes (%d-%d); 0 = backend default", minPacketSize, maxPacketSize))
+    flag.StringVar(&c.SourceSpec, "source", "", "source IP address of the probes (multi-homed hosts, multi-vantage setups)")
+    flag.StringVar(&c.Interface, "interface", "", "send the probes from this network interface's address (e.g. eth1); exclusive with --source")
+    flag.DurationVar(&c.Timeout, "timeout", defaultPingTimeout, "maximum duration of a single server/target measurement")
+    flag.StringVar(&c.ModelName, "model", geo.DefaultDistanceModel, "RTT to distance model ("+strings.Join(geo.DistanceModels(), ", ")+")")
+    flag.Float64Var(&c.VelocityFactor, "velocity-factor", geo.DefaultVelocityFactor, "propagation speed as a fraction of c for the "+geo.DefaultDistanceModel+" model, in (0,1]")
+    flag.DurationVar(&c.Baseline, "baseline", 0, "fixed latency (processing, last mile) subtracted before converting RTT to distance")
+    flag.StringVar(&c.CalibrationPath, "calibration", "", "per-server skews written by the calibrate subcommand, subtracted from the servers' RTTs")
+    flag.StringVar(&c.DBURL, "db-url", "", "server manifest URL fetched by the update-db subcommand (its ed25519 signature is read from the same URL + .sig)")
+    flag.StringVar(&c.DBPubKey, "db-pubkey", "", "base64 ed25519 public key verifying the update-db manifest signature")
+    flag.StringVar(&c.DBChecksum, "db-sha256", "", "expected SHA-256 (hex) of the update-db manifest, instead of or in addition to --db-pubkey")
+    flag.StringVar(&c.ProviderBaselinesPath, "provider-baselines", "", "JSON file of per-provider fixed latencies (e.g. {\"Cloudflare\": \"300us\"}) used instead of --baseline for those providers' servers")
+    flag.DurationVar(&c.Deadline, "deadline", 0, "overall time limit for the measurement phase (e.g. 90s); 0 = none")
+    flag.StringVar(&c.Backend, "backend", backendICMP, "ping backend ("+backendICMP+": ICMP sockets, "+backendSystem+": system ping command)")
+    flag.DurationVar(&c.HopDelay, "hop-delay", 0, "estimated processing delay per router hop, subtracted times the hop count from traceroute-refined RTTs (e.g. 100us); 0 = off")
+    flag.BoolVar(&c.Traceroute, "traceroute", false, "refine the best servers' distances from traceroute divergence points (slower, needs root)")
+    flag.DurationVar(&c.TargetCacheTTL, "target-cache-ttl", 0, "reuse a target's RTT measured less than this long ago (watch, serve); 0 = always re-measure")
+    flag.DurationVar(&c.DNSCacheTTL, "dns-cache-ttl", defaultDNSCacheTTL, "reuse DNS resolutions of the target and named servers for this long (serve, repeated sweeps); 0 = always resolve")
+    flag.DurationVar(&c.ServerCacheTTL, "server-cache-ttl", 0, "reuse a reference server's RTT measured less than this long ago, for any target (watch, serve); 0 = always re-measure")
+    flag.BoolVar(&c.TCPFallback, "tcp-fallback", false, "time TCP connects when the target ignores ICMP (implied by target:port)")
+    flag.StringVar(&c.Serve, "serve", "", "run as an HTTP service on this address (e.g. :8080)")
+    flag.StringVar(&c.ConcurrencySpec, "concurrency", "", "maximum reference servers measured at once: a number, or "+concurrencyAuto+" to ramp up while loss and jitter stay steady; empty = all at once")
+    flag.IntVar(&c.ServeConcurrency, "serve-concurrency", defaultServeConcurrency, "maximum simultaneous triangulations in serve mode (HTTP and WebSocket)")
+    flag.StringVar(&c.LogLevel, "log-level", "info", "diagnostic log level (debug, info, warn, error)")
+    flag.StringVar(&c.LogFormat, "log-format", "text", "diagnostic log format (text, json)")
+    flag.StringVar(&c.SolverName, "solver", geo.DefaultSolver, "least-squares solver for method 3 ("+strings.Join(geo.Solvers(), ", ")+")")
+    flag.StringVar(&c.IRLSLoss, "irls-loss", geo.LossHuber, "robust loss of the irls solver ("+geo.LossHuber+", "+geo.LossTukey+")")
+    flag.Float64Var(&c.IRLSThreshold, "irls-threshold", geo.DefaultIRLSThreshold, "residual (km) beyond which the irls solver discounts a server")
+    flag.StringVar(&c.Geometry, "geometry", geometryDelta, "target-to-server distances fitted by method 3 ("+geometryDelta+": lower bound, kept as default although biased low; "+geometryBounds+": triangle-inequality ring)")
+    flag.StringVar(&c.Weighting, "weighting", weightingEqual, "residual weighting for the least-squares fit ("+weightingEqual+", or a comma-separated list of "+weightingInverseVariance+" and "+weightingColocation+")")
+    flag.BoolVar(&c.ASCIIMap, "ascii-map", false, "draw a world map with the estimate and the multilateration servers (text output)")
+    flag.IntVar(&c.ASCIIMapWidth, "ascii-map-width", defaultASCIIMapWidth, fmt.Sprintf("width of --ascii-map in columns (%d-%d)", minASCIIMapWidth, maxASCIIMapWidth))
+    flag.StringVar(&c.Heatmap, "heatmap", "", "write a CSV grid (lat,lon,score) of the fit residual around the estimate to this file")
+    flag.StringVar(&c.ResidualsCSV, "residuals-csv", "", "write each triangulation server's measured distance, distance bounds, distance to the estimate, residual, weight and exclusion to this CSV file")
+    flag.Float64Var(&c.HeatmapRadius, "heatmap-radius", defaultHeatmapRadiusKm, "heatmap extent around the estimate (km)")
+    flag.Float64Var(&c.HeatmapStep, "heatmap-step", defaultHeatmapStep, "heatmap resolution (degrees)")
+    flag.StringVar(&c.DB, "db", "", "append each run (estimate and per-server RTTs) to this SQLite history database")
+    flag.StringVar(&c.History, "history", "", "print how this target's estimated location moved over time (requires --db) and exit")
+    flag.IntVar(&c.MinLocations, "min-locations", defaultMinLocations, "minimum number of distinct server locations that must respond before triangulating")
+    flag.BoolVar(&c.DropInconsistent, "drop-inconsistent", false, "drop servers whose RTT is physically incompatible with their claimed location (anycast or wrong coordinates) before triangulating")
+    flag.BoolVar(&c.DedupeLocations, "dedupe-locations", false, "triangulate with one server per distinct location (the lowest RTT of each co-located group)")
+    flag.StringVar(&c.Collapse, "collapse", collapseNone, "merge servers before triangulating ("+collapseNone+", "+collapseCity+": one virtual server per city, with the lowest RTT among its providers)")
+    flag.StringVar(&c.ContinentSolve, "continent-solve", continentSolveOff, "also triangulate with the servers of the continent picked by latency similarity ("+continentSolveOff+", "+continentSolveLocal+", "+continentSolveFused+": blend it with the global solve by the similarity confidence)")
+    flag.StringVar(&c.Rank, "rank", rankDelta, "server ranking ("+rankDelta+", "+rankDeltaJitter+": steady servers rank closer)")
+    flag.StringVar(&c.Proximity, "proximity", proximityFixed, "proximity indicator scale ("+proximityFixed+": --proximity-thresholds, "+proximityAdaptive+": quartiles of the observed scores)")
+    flag.StringVar(&c.ProximityThresholds, "proximity-thresholds", defaultProximityThresholds, "upper bounds (ms) of the [+++], [++ ] and [+  ] proximity indicators")
+    flag.StringVar(&c.Output, "output", outputText, "report format ("+outputText+", "+outputMarkdown+", "+outputNDJSON+": one JSON event per line as measurements complete; "+outputJSON+" with --list)")
+    flag.BoolVar(&c.Reachability, "reachability", false, "ping every server once with a short timeout, print which are up by region and exit; same as the reachability subcommand")
+    flag.BoolVar(&c.List, "list", false, "print the server database as a table (or JSON with --output=json) and exit; same as the list-servers subcommand")
+    flag.StringVar(&c.Region, "region", "", "write the probable region (convex hull of well-fitting grid cells) as GeoJSON to this file")
+    flag.Float64Var(&c.RegionThreshold, "region-threshold", defaultRegionThresholdKm, "max RMS residual above the best fit (km) for a grid cell to join the region")
+    flag.DurationVar(&c.Watch, "watch", 0, "after the first analysis, re-measure the target at this interval until interrupted (e.g. 1m)")
+    flag.Float64Var(&c.ProcessNoise, "process-noise", defaultProcessNoiseKm, "watch smoothing: expected position drift between iterations (km, std dev)")
+    flag.Float64Var(&c.MeasurementNoise, "measurement-noise", defaultMeasurementNoiseKm, "watch smoothing: spread of a single estimate (km, std dev)")
+    flag.BoolVar(&c.DryRun, "dry-run", false, "resolve the target, print the measurement plan (servers, concurrency, estimated duration, flags) and exit without pinging")
+    flag.BoolVar(&c.Verify, "verify", false, fmt.Sprintf("re-ping the responding server nearest to the estimate %d times and flag the estimate as suspect if its distance disagrees beyond the confidence radius", verifyPingCount))
+    flag.IntVar(&c.MonteCarlo, "monte-carlo", 0, "estimate an uncertainty ellipse from N jitter-perturbed re-solves (0 = off)")
+    flag.IntVar(&c.Sample, "sample", 0, "measure only N servers, drawn a few per continent and country (reproducible with --seed); 0 = all")
+    flag.Var(&c.Include, "include", "only use servers whose name matches this glob or /regex/ (repeatable)")
+    flag.Var(&c.Exclude, "exclude", "skip servers whose name matches this glob or /regex/ (repeatable)")
+    flag.Parse()
+    // Sous-commandes (list-servers, benchmark, reachability...) ou cible : les options
+    // peuvent suivre
+    c.Command = flag.Arg(0)
+    switch c.Command {
+    case listServersCommand, benchmarkCommand, calibrateCommand, updateDBCommand, reachabilityCommand:
+    case "":
+    default:
+        c.Command, c.Target = "", flag.Arg(0)
+    }
+    if flag.NArg() > 0 {
+        flag.CommandLine.Parse(flag.Args()[1:])
+    }
+    if c.Command == listServersCommand {
+        c.List = true
+    }
+    if c.Command == reachabilityCommand {
+        c.Reachability = true
+    }
+    setLanguage(detectLanguage(c.Lang))
+    // Une langue inconnue est refusée si elle est demandée explicitement ;
+    // $LANG, lui, retombe silencieusement sur l'anglais
+    if _, ok := languageCode(c.Lang); c.Lang != "" && !ok {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidLang"), c.Lang)
+        return nil, errUsage
+    }
+
+    if err := setupLogger(c.LogLevel, c.LogFormat); err != nil {
+        fmt.Fprintf(os.Stderr, tr("flag.invalid"), err)
+        return nil, errUsage
+    }
+
+    if c.Reachability {
+        // Un seul paquet par serveur, avec un délai court sauf --timeout explicite
+        c.Count = 1
+        if !flagSet("timeout") {
+            c.Timeout = reachabilityTimeout
+        }
+    }
+    if err := c.validateMeasurement(); err != nil {
+        return nil, err
+    }
+    if err := c.validateOutput(); err != nil {
+        return nil, err
+    }
+    if err := c.validateAnalysis(); err != nil {
+        return nil, err
+    }
+    if err := c.loadFiles(); err != nil {
+        return nil, err
+    }
+    return c, nil
+}
+
+// flagSet indique si l'option name figure sur la ligne de commande.
+func flagSet(name string) bool {
+    set := false
+    flag.Visit(func(f *flag.Flag) { set = set || f.Name == name })
+    return set
+}
+
+// validateMeasurement valide les options des mesures et construit le
+// backend.
+func (c *config) validateMeasurement() error {
+    if c.Count <= 0 || c.TargetCount <= 0 {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidCount"), c.Count, c.TargetCount)
+        return errUsage
+    }
+    if c.Timeout <= 0 {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidTimeout"), c.Timeout)
+        return errUsage
+    }
+
+    if c.PacketSize != 0 && (c.PacketSize < minPacketSize || c.PacketSize > maxPacketSize) {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidPacketSize"), c.PacketSize, minPacketSize, maxPacketSize)
+        return errUsage
+    }
+    if c.PacketSize+icmpOverhead > typicalMTU {
+        slog.Warn("packet size exceeds a typical MTU, probes may be fragmented or dropped",
+            "size", c.PacketSize, "ip_packet", c.PacketSize+icmpOverhead, "mtu", typicalMTU)
+    }
+
+    var err error
+    if c.Source, err = resolveSource(c.SourceSpec, c.Interface); err != nil {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidSource"), err)
+        return errUsage
+    }
+
+    if c.AdaptiveStdDev < 0 || (c.AdaptiveStdDev > 0 && c.MinCount < 2) {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidAdaptive"), c.AdaptiveStdDev, c.MinCount)
+        return errUsage
+    }
+    c.Sampling = sequentialSampling{MinCount: c.MinCount, TargetStdDev: c.AdaptiveStdDev}
+
+    switch c.Backend {
+    case backendICMP:
+    case backendSystem:
+        c.Measurer = systemMeasurer{Timeout: c.Timeout, Size: c.PacketSize, Source: c.Source}
+    default:
+        fmt.Fprintf(os.Stderr, tr("flag.invalidBackend"), c.Backend)
+        return errUsage
+    }
+    // Un backend choisi explicitement mesure tous les serveurs, sans les
+    // sondes de la base
+    c.BackendSet = flagSet("backend")
+    if c.Watch < 0 || c.ProcessNoise < 0 || c.MeasurementNoise <= 0 {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidWatch"), c.Watch, c.ProcessNoise, c.MeasurementNoise)
+        return errUsage
+    }
+    if c.Concurrency, err = parseConcurrency(c.ConcurrencySpec); err != nil {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidConcurrency"), c.ConcurrencySpec)
+        return errUsage
+    }
+    if c.HopDelay < 0 {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidHopDelay"), c.HopDelay)
+        return errUsage
+    }
+    if !setICMPSocket(c.ICMPSocket) {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidICMPSocket"), c.ICMPSocket)
+        return errUsage
+    }
+    return nil
+}
+
+// validateOutput valide les options du rapport, charge le gabarit et
+// choisit la sortie de la console.
+func (c *config) validateOutput() error {
+    var err error
+    c.ProximityScale, err = parseProximityThresholds(c.ProximityThresholds)
+    if err != nil || (c.Proximity != proximityFixed && c.Proximity != proximityAdaptive) {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidProximity"), c.Proximity, c.ProximityThresholds)
+        return errUsage
+    }
+    if c.Rank != rankDelta && c.Rank != rankDeltaJitter {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidRank"), c.Rank)
+        return errUsage
+    }
+    switch c.Output {
+    case outputText, outputMarkdown, outputNDJSON, outputJSON:
+    default:
+        fmt.Fprintf(os.Stderr, tr("flag.invalidOutput"), c.Output)
+        return errUsage
+    }
+    if c.Output == outputJSON && !c.List && !c.Reachability && c.Command != benchmarkCommand {
+        fmt.Fprintln(os.Stderr, tr("flag.jsonNeedsList"))
+        return errUsage
+    }
+    if c.TemplatePath != "" {
+        if c.Output != outputText {
+            fmt.Fprintln(os.Stderr, tr("flag.templateOutput"))
+            return errUsage
+        }
+        if c.Template, err = loadReportTemplate(c.TemplatePath); err != nil {
+            fmt.Fprintf(os.Stderr, tr("flag.invalidTemplate"), err)
+            return errUsage
+        }
+    }
+    c.Console, c.Progress = io.Writer(os.Stdout), os.Stdout
+    if c.structured() {
+        c.Console, c.Progress = os.Stderr, os.Stderr
+    }
+
+    if c.RegionThreshold < 0 {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidRegion"), c.RegionThreshold)
+        return errUsage
+    }
+    if c.HeatmapRadius <= 0 || c.HeatmapStep <= 0 {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidHeatmap"), c.HeatmapRadius, c.HeatmapStep)
+        return errUsage
+    }
+    if c.ASCIIMapWidth < minASCIIMapWidth || c.ASCIIMapWidth > maxASCIIMapWidth {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidASCIIMap"), c.ASCIIMapWidth, minASCIIMapWidth, maxASCIIMapWidth)
+        return errUsage
+    }
+    if c.ASCIIMap && c.structured() {
+        fmt.Fprintln(os.Stderr, tr("flag.asciiMapText"))
+        return errUsage
+    }
+    if c.MonteCarlo < 0 {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidMonteCarlo"), c.MonteCarlo)
+        return errUsage
+    }
+    if !setDistanceUnit(c.Units) {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidUnits"), c.Units)
+        return errUsage
+    }
+    if !setMapProviders(c.Maps) {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidMaps"), c.Maps)
+        return errUsage
+    }
+    return nil
+}
+
+// validateAnalysis valide les options de la triangulation et construit le
+// modèle de distance et le solveur.
+func (c *config) validateAnalysis() error {
+    if c.Collapse != collapseNone && c.Collapse != collapseCity {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidCollapse"), c.Collapse)
+        return errUsage
+    }
+    switch c.ContinentSolve {
+    case continentSolveOff, continentSolveLocal, continentSolveFused:
+    default:
+        fmt.Fprintf(os.Stderr, tr("flag.invalidContinentSolve"), c.ContinentSolve)
+        return errUsage
+    }
+    if c.MinLocations < 3 {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidMinLocations"), c.MinLocations)
+        return errUsage
+    }
+
+    var ok bool
+    if c.Model, ok = geo.LookupDistanceModel(c.ModelName); !ok {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidModel"), c.ModelName, strings.Join(geo.DistanceModels(), ", "))
+        return errUsage
+    }
+    if c.VelocityFactor <= 0 || c.VelocityFactor > 1 {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidVelocity"), c.VelocityFactor)
+        return errUsage
+    }
+    if c.VelocityFactor != geo.DefaultVelocityFactor {
+        if c.ModelName != geo.DefaultDistanceModel {
+            fmt.Fprintf(os.Stderr, tr("flag.velocityModel"), geo.DefaultDistanceModel, c.ModelName)
+            return errUsage
+        }
+        c.Model = geo.LinearFiber(c.VelocityFactor)
+    }
+
+    if c.Solver, ok = geo.LookupSolver(c.SolverName); !ok {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidSolver"), c.SolverName, strings.Join(geo.Solvers(), ", "))
+        return errUsage
+    }
+    if _, ok := c.Solver.(geo.IRLS); ok {
+        if (c.IRLSLoss != geo.LossHuber && c.IRLSLoss != geo.LossTukey) || c.IRLSThreshold <= 0 {
+            fmt.Fprintf(os.Stderr, tr("flag.invalidIRLS"), c.IRLSLoss, c.IRLSThreshold)
+            return errUsage
+        }
+        c.Solver = geo.IRLS{Threshold: c.IRLSThreshold, Loss: c.IRLSLoss}
+    }
+
+    if c.Geometry != geometryDelta && c.Geometry != geometryBounds {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidGeometry"), c.Geometry)
+        return errUsage
+    }
+    if !validWeighting(c.Weighting) {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidWeighting"), c.Weighting)
+        return errUsage
+    }
+    if c.Sample < 0 {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidSample"), c.Sample)
+        return errUsage
+    }
+    return nil
+}
+
+// loadFiles charge les fichiers désignés par les options (délais,
+// calibration, fuseaux, sources de comparaison) et vérifie les options que
+// réclament les sous-commandes.
+func (c *config) loadFiles() error {
+    var err error
+    if c.ProviderBaselinesPath != "" {
+        if c.ProviderBaselines, err = loadProviderBaselines(c.ProviderBaselinesPath); err != nil {
+            fmt.Fprintf(os.Stderr, tr("flag.invalid"), err)
+            return errUsage
+        }
+    }
+    if c.CalibrationPath != "" {
+        if c.Skews, err = loadCalibration(c.CalibrationPath); err != nil {
+            fmt.Fprintf(os.Stderr, tr("flag.invalid"), err)
+            return errUsage
+        }
+    }
+    // --my-location est un synonyme de --vantage ; les deux ne peuvent
+    // désigner des positions différentes
+    if c.MyLocationSpec != "" {
+        if c.VantageSpec != "" {
+            vantage, err := parseVantage(c.VantageSpec)
+            if err != nil {
+                fmt.Fprintf(os.Stderr, tr("flag.invalid"), err)
+                return errUsage
+            }
+            mine, err := parseVantage(c.MyLocationSpec)
+            if err != nil {
+                fmt.Fprintf(os.Stderr, tr("flag.invalid"), err)
+                return errUsage
+            }
+            if vantage != mine {
+                fmt.Fprintf(os.Stderr, tr("flag.vantageConflict"), c.VantageSpec, c.MyLocationSpec)
+                return errUsage
+            }
+        }
+        c.VantageSpec = c.MyLocationSpec
+    }
+    if c.Command == calibrateCommand && (c.VantageSpec == "" || c.Out == "") {
+        fmt.Fprintln(os.Stderr, tr("flag.calibrateNeeds"))
+        return errUsage
+    }
+    if c.Agent && (c.Out == "" || c.Replay != "") {
+        fmt.Fprintln(os.Stderr, tr("flag.agentNeedsOut"))
+        return errUsage
+    }
+    if c.TimezonesPath != "" {
+        if c.Zones, err = loadTimezones(c.TimezonesPath); err != nil {
+            fmt.Fprintf(os.Stderr, tr("tz.loadError"), err)
+            return errUsage
+        }
+    }
+    if c.VantageSpec != "" {
+        loc, err := parseVantage(c.VantageSpec)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, tr("flag.invalid"), err)
+            return errUsage
+        }
+        c.Vantage = &loc
+    }
+    if c.History != "" && c.DB == "" {
+        fmt.Fprintln(os.Stderr, tr("flag.historyNeedsDB"))
+        return errUsage
+    }
+    if c.Command == updateDBCommand && (c.DBURL == "" || (c.DBPubKey == "" && c.DBChecksum == "")) {
+        fmt.Fprintln(os.Stderr, tr("flag.updateDBNeeds"))
+        return errUsage
+    }
+    if c.Serve != "" && c.ServeConcurrency <= 0 {
+        fmt.Fprintf(os.Stderr, tr("flag.invalidServeConcurrency"), c.ServeConcurrency)
+        return errUsage
+    }
+
+    // Ouvertes en dernier : une option invalide ne laisse rien à fermer
+    if c.GeoIPPath != "" {
+        locator, err := openGeoIP(c.GeoIPPath)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, tr("geoip.openError"), err)
+            return errUsage
+        }
+        c.Comparisons = append(c.Comparisons, comparisonSource{Name: "MaxMind", Locator: locator})
+    }
+    if c.Compare != "" {
+        for _, name := range strings.Split(c.Compare, ",") {
+            locator, err := openGeoLocator(strings.TrimSpace(name))
+            if err != nil {
+                c.close()
+                fmt.Fprintf(os.Stderr, tr("flag.invalidCompare"), err, strings.Join(geoLocatorNames(), ", "))
+                return errUsage
+            }
+            c.Comparisons = append(c.Comparisons, comparisonSource{Name: strings.TrimSpace(name), Locator: locator})
+        }
+    }
+    return nil
+}
+
+// close ferme les sources de comparaison qui le demandent.
+func (c *config) close() {
+    for _, s := range c.Comparisons {
+        if closer, ok := s.Locator.(io.Closer); ok {
+            closer.Close()
+        }
+    }
+}
+
+// structured indique si le rapport est un format structuré (markdown,
+// ndjson) ou un gabarit plutôt que l'affichage texte.
+func (c *config) structured() bool {
+    return c.Output != outputText || c.Template != nil
+}
+
+// options construit les Options des mesures et de la triangulation des
+// serveurs retenus.
+func (c *config) options(servers []Server, seed int64) Options {
+    opts := Options{
+        Measurer:    c.Measurer,
+        Rand:        rand.New(rand.NewSource(seed)),
+        Progress:    c.Progress,
+        TCPFallback: c.TCPFallback,
+        Count:       c.Count,
+        TargetCount: c.TargetCount,
+        Timeout:     c.Timeout,
+        PacketSize:  c.PacketSize,
+        Source:      c.Source,
+        Model:       c.Model,
+        Baseline:    c.Baseline,
+        HopDelay:    c.HopDelay,
+        Solver:      c.Solver,
+        Weighting:   c.Weighting,
+        Geometry:    c.Geometry,
+        Rank:        c.Rank,
+
+        ProviderBaselines: c.ProviderBaselines,
+        Skews:             c.Skews,
+        DedupeLocations:   c.DedupeLocations,
+        Collapse:          c.Collapse,
+        MinLocations:      c.MinLocations,
+        Colocation:        colocationCounts(servers),
+        TargetCache:       newRTTCache(c.TargetCacheTTL),
+        DNSCache:          newDNSCache(c.DNSCacheTTL),
+        Concurrency:       c.Concurrency,
+        ServerTTL:         c.ServerCacheTTL,
+        Sampling:          c.Sampling,
+    }.withDefaults()
+    if !c.BackendSet && c.Replay == "" {
+        opts.Probes = serverProbes(servers, opts.DNSCache)
+    }
+    return opts
+}
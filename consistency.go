@@ -0,0 +1,154 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "log/slog"
+    "time"
+
+    "triangula/geo"
+)
+
+// Inconsistency est un serveur dont la position déclarée est physiquement
+// incompatible avec son RTT : il répond plus vite que la lumière dans le
+// vide ne le permettrait depuis le poste de mesure (ou, faute de position
+// du poste, qu'avec un autre serveur). Adresse anycast ou coordonnées
+// erronées dans la base.
+type Inconsistency struct {
+    Server   Server
+    RTT      time.Duration
+    MinRTT   time.Duration // RTT minimal compatible avec la position déclarée
+    Other    *Server       // serveur de la paire en conflit ; nil : comparé au poste
+    OtherRTT time.Duration
+}
+
+// minRTTFor retourne le RTT minimal pour parcourir km à la vitesse de la
+// lumière dans le vide, à l'aller et au retour.
+func minRTTFor(km float64) time.Duration {
+    return time.Duration(2 * km / geo.SpeedOfLight * float64(time.Second))
+}
+
+// validCoordinates indique si la position du serveur est dans les bornes
+// de latitude et de longitude.
+func validCoordinates(s Server) bool {
+    return s.Lat >= -90 && s.Lat <= 90 && s.Lon >= -180 && s.Lon <= 180
+}
+
+// withValidCoordinates écarte, en les signalant, les serveurs dont la
+// position est hors bornes.
+func withValidCoordinates(servers []Server) []Server {
+    kept := servers[:0:0]
+    for _, s := range servers {
+        if !validCoordinates(s) {
+            slog.Error("server dropped: invalid coordinates", "server", s.Name, "ip", s.IP, "lat", s.Lat, "lon", s.Lon)
+            continue
+        }
+        kept = append(kept, s)
+    }
+    return kept
+}
+
+// findInconsistencies repère les serveurs ayant répondu dont le RTT est
+// incompatible avec leur position. Avec la position du poste, chaque serveur
+// est comparé à sa distance au poste. Sinon, l'inégalité triangulaire
+// d(A,B) <= d(M,A) + d(M,B) doit tenir pour chaque paire : le serveur
+// impliqué dans le plus de paires en conflit est signalé, puis le suivant,
+// jusqu'à ce qu'aucun conflit ne reste. Les serveurs anycast connus, déjà
+// traités à part, ne sont pas examinés.
+func findInconsistencies(measurements []Measurement, vantage *Location) []Inconsistency {
+    var located []Measurement
+    for _, m := range measurements {
+        if m.Error == "" && !isAnycast(m.Server) {
+            located = append(located, m)
+        }
+    }
+
+    var flagged []Inconsistency
+    if vantage != nil {
+        for _, m := range located {
+            minRTT := minRTTFor(geo.Distance(vantage.Lat, vantage.Lon, m.Server.Lat, m.Server.Lon))
+            if m.RTT < minRTT {
+                flagged = append(flagged, Inconsistency{Server: m.Server, RTT: m.RTT, MinRTT: minRTT})
+            }
+        }
+        return flagged
+    }
+
+    distances := newDistanceMatrix(measuredServers(located))
+    conflicts := make([][]int, len(located))
+    for i := range located {
+        for j := i + 1; j < len(located); j++ {
+            if located[i].RTT+located[j].RTT < minRTTFor(distances.Get(i, j)) {
+                conflicts[i] = append(conflicts[i], j)
+                conflicts[j] = append(conflicts[j], i)
+            }
+        }
+    }
+    removed := make([]bool, len(located))
+    for {
+        worst, count := -1, 0
+        for i, others := range conflicts {
+            n := 0
+            for _, j := range others {
+                if !removed[j] {
+                    n++
+                }
+            }
+            if !removed[i] && n > count {
+                worst, count = i, n
+            }
+        }
+        if worst < 0 {
+            return flagged
+        }
+        removed[worst] = true
+
+        m := located[worst]
+        for _, j := range conflicts[worst] {
+            other := located[j]
+            if removed[j] {
+                continue
+            }
+            flagged = append(flagged, Inconsistency{Server: m.Server, RTT: m.RTT, MinRTT: minRTTFor(distances.Get(worst, j)) - other.RTT,
+                Other: &other.Server, OtherRTT: other.RTT})
+            break
+        }
+    }
+}
+
+// dropInconsistent retire des mesures les serveurs signalés.
+func dropInconsistent(measurements []Measurement, flagged []Inconsistency) []Measurement {
+    drop := make(map[[2]string]bool, len(flagged))
+    for _, f := range flagged {
+        drop[[2]string{f.Server.Name, f.Server.IP}] = true
+    }
+    kept := measurements[:0:0]
+    for _, m := range measurements {
+        if !drop[[2]string{m.Server.Name, m.Server.IP}] {
+            kept = append(kept, m)
+        }
+    }
+    return kept
+}
+
+// displayInconsistencies signale chaque serveur incohérent et sa preuve.
+func displayInconsistencies(w io.Writer, flagged []Inconsistency, dropped bool) {
+    if len(flagged) == 0 {
+        return
+    }
+    fmt.Fprintln(w)
+    fmt.Fprintf(w, tr("incons.header"), len(flagged))
+    for _, f := range flagged {
+        if f.Other == nil {
+            fmt.Fprintf(w, tr("incons.vantage"), f.Server.Name, f.Server.IP, f.Server.City, f.RTT, f.MinRTT)
+        } else {
+            fmt.Fprintf(w, tr("incons.pair"), f.Server.Name, f.Server.IP, f.Server.City, f.RTT,
+                f.Other.Name, f.Other.City, f.OtherRTT, f.MinRTT)
+        }
+    }
+    if dropped {
+        fmt.Fprintln(w, tr("incons.dropped"))
+    } else {
+        fmt.Fprintln(w, tr("incons.hint"))
+    }
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "strings"
+
+    "triangula/geo"
+)
+
+// Modes de la résolution continentale (--continent-solve)
+const (
+    continentSolveOff   = "off"
+    continentSolveLocal = "local" // résout aussi sur le seul continent de la cible
+    continentSolveFused = "fused" // et fusionne avec la résolution globale
+)
+
+// Part de l'estimation continentale dans la fusion, selon la confiance de
+// la similarité de latence qui a désigné le continent
+var continentFusionWeights = map[string]float64{
+    similarityHigh:   0.8,
+    similarityMedium: 0.6,
+    similarityLow:    0.4,
+}
+
+// errNoContinent : aucun serveur localisé ne désigne de continent
+var errNoContinent = errors.New("no continent matched")
+
+// ContinentEstimate compare la résolution restreinte aux serveurs du
+// continent le plus probable à la résolution globale (méthode 3).
+type ContinentEstimate struct {
+    Continent   string    `json:"continent"`
+    Confidence  string    `json:"confidence"` // confiance de la similarité (similarity*)
+    Servers     int       `json:"servers"`    // serveurs du continent ayant répondu
+    Local       Location  `json:"local"`
+    Global      Location  `json:"global"`
+    GapKm       float64   `json:"gap_km"`
+    Fused       *Location `json:"fused,omitempty"`
+    LocalWeight float64   `json:"local_weight,omitempty"`
+}
+
+// continentSolve désigne le continent de la cible par similarité de
+// latence, puis refait la triangulation avec les seuls serveurs de ce
+// continent (anycast exclus), pour que des serveurs lointains et bruités
+// n'attirent pas l'estimation. Avec fuse, les deux positions sont
+// combinées selon continentFusionWeights. errNoContinent si aucun continent
+// n'est désigné ; les erreurs d'estimatePositions si les serveurs du
+// continent ne suffisent pas.
+func continentSolve(results []Result, global Estimates, opts Options, fuse bool) (ContinentEstimate, error) {
+    match, ok := matchSimilarity(results)
+    if !ok || continentOf(match.Country) == "" {
+        return ContinentEstimate{}, errNoContinent
+    }
+    ce := ContinentEstimate{
+        Continent:  continentOf(match.Country),
+        Confidence: match.Confidence,
+        Global:     global.LeastSquares,
+    }
+
+    var local []Result
+    for _, r := range results {
+        if !isAnycast(r.Server) && continentOf(r.Server.Country) == ce.Continent {
+            local = append(local, r)
+        }
+    }
+    ce.Servers = len(local)
+    est, err := estimatePositions(local, opts)
+    if err != nil {
+        return ce, err
+    }
+    ce.Local = est.LeastSquares
+    ce.GapKm = geo.Distance(ce.Local.Lat, ce.Local.Lon, ce.Global.Lat, ce.Global.Lon)
+
+    if fuse {
+        ce.LocalWeight = continentFusionWeights[ce.Confidence]
+        fused := interpolate(ce.Global, ce.Local, ce.LocalWeight)
+        ce.Fused = &fused
+    }
+    return ce, nil
+}
+
+// GlobalWeight retourne la part de la résolution globale dans la fusion.
+func (ce ContinentEstimate) GlobalWeight() float64 {
+    return 1 - ce.LocalWeight
+}
+
+// interpolate retourne le point à la fraction t de a vers b, barycentre des
+// deux positions (exact pour t = 0 ou 1, proche du grand cercle entre deux
+// positions voisines) ; a pour deux positions antipodales.
+func interpolate(a, b Location, t float64) Location {
+    if loc, ok := geo.Centroid([]Location{a, b}, []float64{1 - t, t}); ok {
+        return loc
+    }
+    return a
+}
+
+// displayContinentSolve affiche la résolution continentale à côté de la
+// résolution globale et la retourne ; nil si elle n'a pas abouti.
+func displayContinentSolve(results []Result, global Estimates, opts Options, fuse bool, servers []Server) *ContinentEstimate {
+    fmt.Println("\n" + strings.Repeat("=", 80))
+    fmt.Println(tr("continent.title"))
+    fmt.Println(strings.Repeat("=", 80))
+
+    ce, err := continentSolve(results, global, opts, fuse)
+    if errors.Is(err, errNoContinent) {
+        fmt.Println(tr("continent.none"))
+        return nil
+    }
+    fmt.Printf(tr("continent.match"), ce.Continent, tr("similarity."+ce.Confidence))
+    if err != nil {
+        fmt.Printf(tr("continent.tooFew"), ce.Continent, ce.Servers)
+        return nil
+    }
+    cities := knownCities(servers)
+    precision := assessCoherence(results).Precision
+
+    fmt.Printf(tr("continent.local"), ce.Servers, ce.Local.Lat, ce.Local.Lon)
+    displayNearestCity(ce.Local, cities)
+    fmt.Printf(tr("continent.global"), ce.Global.Lat, ce.Global.Lon)
+    fmt.Printf(tr("continent.gap"), formatDistance(ce.GapKm))
+    if ce.Fused == nil {
+        displayMapLinks(ce.Local, precision)
+        return &ce
+    }
+    fmt.Printf(tr("continent.fused"), ce.LocalWeight*100, ce.GlobalWeight()*100, ce.Fused.Lat, ce.Fused.Lon)
+    displayNearestCity(*ce.Fused, cities)
+    displayMapLinks(*ce.Fused, precision)
+    return &ce
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+    "math/rand"
+    "sort"
+)
+
+// Continent des pays de la base ; les entrées "Global" (anycast) n'en ont pas.
+var countryContinents = map[string]string{
+    "France":       "Europe",
+    "Germany":      "Europe",
+    "UK":           "Europe",
+    "Netherlands":  "Europe",
+    "Spain":        "Europe",
+    "Italy":        "Europe",
+    "Switzerland":  "Europe",
+    "Sweden":       "Europe",
+    "Poland":       "Europe",
+    "USA":          "North America",
+    "Canada":       "North America",
+    "Brazil":       "South America",
+    "Argentina":    "South America",
+    "Chile":        "South America",
+    "Japan":        "Asia",
+    "Singapore":    "Asia",
+    "India":        "Asia",
+    "South Korea":  "Asia",
+    "Hong Kong":    "Asia",
+    "UAE":          "Asia",
+    "Israel":       "Asia",
+    "Australia":    "Oceania",
+    "New Zealand":  "Oceania",
+    "South Africa": "Africa",
+    "Egypt":        "Africa",
+}
+
+// continentOf retourne le continent d'un pays, "" s'il est inconnu.
+func continentOf(country string) string {
+    return countryContinents[country]
+}
+
+// RegionCoverage compte les serveurs d'un continent mesurés et ayant répondu.
+type RegionCoverage struct {
+    Region    string
+    Attempted int
+    Responded int
+}
+
+// regionCoverage regroupe les mesures par continent (regionAnycast pour les
+// serveurs sans continent), par ordre alphabétique. Les mesures interrompues
+// avant la fin ne comptent pas.
+func regionCoverage(measurements []Measurement) []RegionCoverage {
+    byRegion := make(map[string]*RegionCoverage)
+    var regions []string
+    for _, m := range measurements {
+        if m.Cancelled {
+            continue
+        }
+        region := continentOf(m.Server.Country)
+        if region == "" {
+            region = regionAnycast
+        }
+        c, ok := byRegion[region]
+        if !ok {
+            c = &RegionCoverage{Region: region}
+            byRegion[region] = c
+            regions = append(regions, region)
+        }
+        c.Attempted++
+        if m.Error == "" {
+            c.Responded++
+        }
+    }
+    sort.Strings(regions)
+    coverage := make([]RegionCoverage, len(regions))
+    for i, region := range regions {
+        coverage[i] = *byRegion[region]
+    }
+    return coverage
+}
+
+// sampleServers tire n serveurs répartis entre continents puis entre pays :
+// chaque tour prend un serveur par continent, en alternant les pays de
+// chaque continent, ce qui donne une bien meilleure géométrie qu'un tirage
+// uniforme dominé par les grands centres. Les entrées sans continent
+// (anycast "Global") ne sont pas tirées. rng fixe le tirage (--seed).
+func sampleServers(servers []Server, n int, rng *rand.Rand) []Server {
+    byContinent := make(map[string]map[string][]Server)
+    for _, s := range servers {
+        continent := continentOf(s.Country)
+        if continent == "" {
+            continue
+        }
+        if byContinent[continent] == nil {
+            byContinent[continent] = make(map[string][]Server)
+        }
+        byContinent[continent][s.Country] = append(byContinent[continent][s.Country], s)
+    }
+
+    // File de chaque continent : pays mélangés, servis à tour de rôle
+    var continents []string
+    for c := range byContinent {
+        continents = append(continents, c)
+    }
+    sort.Strings(continents)
+    rng.Shuffle(len(continents), func(i, j int) { continents[i], continents[j] = continents[j], continents[i] })
+
+    queues := make([][]Server, len(continents))
+    for i, c := range continents {
+        var countries []string
+        for country := range byContinent[c] {
+            countries = append(countries, country)
+        }
+        sort.Strings(countries)
+        rng.Shuffle(len(countries), func(a, b int) { countries[a], countries[b] = countries[b], countries[a] })
+        for _, country := range countries {
+            list := byContinent[c][country]
+            rng.Shuffle(len(list), func(a, b int) { list[a], list[b] = list[b], list[a] })
+        }
+        for round := 0; ; round++ {
+            added := false
+            for _, country := range countries {
+                if list := byContinent[c][country]; round < len(list) {
+                    queues[i] = append(queues[i], list[round])
+                    added = true
+                }
+            }
+            if !added {
+                break
+            }
+        }
+    }
+
+    var sample []Server
+    for round := 0; len(sample) < n; round++ {
+        added := false
+        for i := range queues {
+            if round < len(queues[i]) && len(sample) < n {
+                sample = append(sample, queues[i][round])
+                added = true
+            }
+        }
+        if !added {
+            break
+        }
+    }
+    return sample
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strconv"
+    "strings"
+
+    "triangula/geo"
+)
+
+// VantageEstimate est la contribution d'un point de mesure à la
+// coordination.
+type VantageEstimate struct {
+    Report       Session
+    Path         string
+    Results      []Result
+    Estimates    Estimates
+    Triangulated bool
+}
+
+// CombinedEstimate est la position obtenue en ajustant ensemble les
+// distances de tous les points de mesure.
+type CombinedEstimate struct {
+    Location Location
+    Anchors  int
+    RMSKm    float64
+}
+
+// hostname est le nom d'agent par défaut.
+func hostname() string {
+    if name, err := os.Hostname(); err == nil {
+        return name
+    }
+    return "agent"
+}
+
+// parseVantage lit une position "lat,lon" (--vantage).
+func parseVantage(s string) (Location, error) {
+    latText, lonText, ok := strings.Cut(s, ",")
+    if !ok {
+        return Location{}, fmt.Errorf("%q: expected lat,lon", s)
+    }
+    lat, err := strconv.ParseFloat(strings.TrimSpace(latText), 64)
+    if err != nil || lat < -90 || lat > 90 {
+        return Location{}, fmt.Errorf("%q: invalid latitude", s)
+    }
+    lon, err := strconv.ParseFloat(strings.TrimSpace(lonText), 64)
+    if err != nil || lon < -180 || lon > 180 {
+        return Location{}, fmt.Errorf("%q: invalid longitude", s)
+    }
+    return Location{Lat: lat, Lon: lon}, nil
+}
+
+// loadReports charge les rapports d'agents désignés par un motif
+// (reports/*.json). Tous doivent viser la même adresse.
+func loadReports(pattern string) ([]Session, []string, error) {
+    paths, err := filepath.Glob(pattern)
+    if err != nil {
+        return nil, nil, err
+    }
+    if len(paths) == 0 {
+        return nil, nil, fmt.Errorf("%s: no report matches", pattern)
+    }
+    sort.Strings(paths)
+
+    reports := make([]Session, len(paths))
+    for i, path := range paths {
+        if reports[i], err = loadSession(path); err != nil {
+            return nil, nil, err
+        }
+        if reports[i].Target.IP != reports[0].Target.IP {
+            return nil, nil, fmt.Errorf("%s: target %s differs from %s in %s",
+                path, reports[i].Target.IP, reports[0].Target.IP, paths[0])
+        }
+    }
+    return reports, paths, nil
+}
+
+// coordinate fusionne les rapports : chaque point de mesure contribue les
+// distances de ses multilatServers meilleurs serveurs, plus la distance
+// directe à la cible si sa position est connue, et le solveur les ajuste
+// ensemble. L'amorce est la multilatération de l'ensemble des résultats.
+func coordinate(reports []Session, paths []string, opts Options) ([]VantageEstimate, CombinedEstimate, error) {
+    opts = opts.withDefaults()
+
+    var vantages []VantageEstimate
+    var points []geo.Anchor
+    var merged []Result
+    for i, report := range reports {
+        v := VantageEstimate{Report: report, Path: paths[i]}
+        v.Results = buildResults(report.Measurements, report.Target.RTT, opts)
+        est, err := estimatePositions(v.Results, opts)
+        v.Estimates, v.Triangulated = est, err == nil
+        vantages = append(vantages, v)
+
+        n := multilatServers
+        if len(v.Results) < n {
+            n = len(v.Results)
+        }
+        points = append(points, anchors(v.Results[:n], opts)...)
+        merged = append(merged, v.Results[:n]...)
+        if report.Vantage != nil {
+            direct := opts.Model.Distance(report.Target.RTT, opts.Baseline)
+            anchor := geo.Anchor{Location: *report.Vantage, Distance: direct, Weight: 1}
+            if opts.Geometry == geometryBounds {
+                anchor.MaxDistance = direct
+            }
+            points = append(points, anchor)
+        }
+    }
+    if len(points) < 3 {
+        return vantages, CombinedEstimate{}, errNotEnoughServers
+    }
+
+    sortResults(merged, opts.Rank)
+    initial := multilateralTriangulation(merged, len(merged))
+    loc := opts.Solver.Solve(points, initial)
+    return vantages, CombinedEstimate{Location: loc, Anchors: len(points), RMSKm: geo.RMSResidual(points, loc)}, nil
+}
+
+// displayCoordination affiche l'estimation de chaque point de mesure puis
+// la position combinée.
+func displayCoordination(vantages []VantageEstimate, combined CombinedEstimate, err error, servers []Server) {
+    fmt.Println("\n" + strings.Repeat("=", 80))
+    fmt.Printf(tr("coord.title"), len(vantages), vantages[0].Report.Target.Label())
+    fmt.Println(strings.Repeat("=", 80))
+
+    cities := knownCities(servers)
+    for _, v := range vantages {
+        name := v.Report.Agent
+        if name == "" {
+            name = filepath.Base(v.Path)
+        }
+        where := tr("coord.unknownVantage")
+        if v.Report.Vantage != nil {
+            where = fmt.Sprintf("%.4f, %.4f", v.Report.Vantage.Lat, v.Report.Vantage.Lon)
+        }
+        fmt.Printf(tr("coord.vantage"), name, where, v.Report.Target.RTT, len(v.Results))
+        if v.Triangulated {
+            loc := v.Estimates.LeastSquares
+            fmt.Printf(tr("coord.vantageEstimate"), loc.Lat, loc.Lon)
+        } else {
+            fmt.Println(tr("coord.vantageNone"))
+        }
+    }
+
+    fmt.Println(tr("coord.combined"))
+    fmt.Println(strings.Repeat("-", 80))
+    if err != nil {
+        fmt.Println(tr("tri.notEnough"))
+        return
+    }
+    loc := combined.Location
+    fmt.Printf(tr("coord.anchors"), combined.Anchors, formatDistance(combined.RMSKm))
+    fmt.Printf(tr("tri.position2"), loc.Lat, loc.Lon)
+    displayNearestCity(loc, cities)
+    displayCountry(loc, servers)
+    displayMapLinks(loc, combined.RMSKm)
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+    "crypto/ed25519"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/hex"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log/slog"
+    "net"
+    "net/http"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Sous-commande de mise à jour de la base depuis un manifeste distant
+const updateDBCommand = "update-db"
+
+const (
+    // Taille maximale d'un manifeste ou de sa signature téléchargés
+    maxManifestSize = 10 << 20
+    // Durée maximale d'un téléchargement
+    manifestTimeout = 30 * time.Second
+    // Extension de la signature, publiée à côté du manifeste
+    manifestSignatureSuffix = ".sig"
+    // Version du format des manifestes, indépendante de celle des sessions
+    // (reportFormat) ; un manifeste plus récent est refusé
+    manifestFormat = 1
+)
+
+// ServerManifest est la base publiée à --db-url : la liste des serveurs
+// (mêmes champs que --list --output=json). Sa signature ed25519, encodée en
+// base64, est publiée à la même adresse suffixée de .sig.
+type ServerManifest struct {
+    Format    int       `json:"format"`
+    Timestamp time.Time `json:"timestamp"`
+    Servers   []Server  `json:"servers"`
+}
+
+// serverCachePath retourne le chemin de la base téléchargée par update-db,
+// qui remplace la base intégrée aux exécutions suivantes.
+func serverCachePath() (string, error) {
+    dir, err := os.UserCacheDir()
+    if err != nil {
+        return "", err
+    }
+    return filepath.Join(dir, "triangula", "servers.json"), nil
+}
+
+var (
+    serverDatabaseOnce sync.Once
+    serverDatabase     []Server
+)
+
+// getServerDatabase retourne la base téléchargée par update-db si elle
+// existe et est valide, sinon la base intégrée. Chargée une seule fois.
+func getServerDatabase() []Server {
+    serverDatabaseOnce.Do(func() {
+        serverDatabase = withValidCoordinates(embeddedServerDatabase())
+        path, err := serverCachePath()
+        if err != nil {
+            return
+        }
+        data, err := os.ReadFile(path)
+        if errors.Is(err, os.ErrNotExist) {
+            return
+        }
+        var manifest ServerManifest
+        if err == nil {
+            manifest, err = parseManifest(data)
+        }
+        if err != nil {
+            slog.Warn("ignoring downloaded server database, using the embedded one", "path", path, "error", err)
+            return
+        }
+        slog.Debug("using downloaded server database", "path", path, "servers", len(manifest.Servers),
+            "timestamp", manifest.Timestamp)
+        serverDatabase = manifest.Servers
+    })
+    return serverDatabase
+}
+
+// parseManifest décode et valide un manifeste : format connu, au moins 3
+// serveurs, chacun nommé, d'adresse IP ou de nom d'hôte valide, de
+// coordonnées plausibles, de sonde connue, sans doublon nom/adresse.
+func parseManifest(data []byte) (ServerManifest, error) {
+    var manifest ServerManifest
+    if err := json.Unmarshal(data, &manifest); err != nil {
+        return manifest, err
+    }
+    if manifest.Format > manifestFormat {
+        return manifest, fmt.Errorf("format %d is not supported (this version reads up to %d)",
+            manifest.Format, manifestFormat)
+    }
+    if len(manifest.Servers) < 3 {
+        return manifest, fmt.Errorf("only %d servers", len(manifest.Servers))
+    }
+    seen := make(map[[2]string]bool, len(manifest.Servers))
+    for i, s := range manifest.Servers {
+        key := [2]string{s.Name, s.IP}
+        switch {
+        case strings.TrimSpace(s.Name) == "":
+            return manifest, fmt.Errorf("server %d: missing name", i+1)
+        case net.ParseIP(s.IP) == nil && !validHostname(s.IP):
+            return manifest, fmt.Errorf("%s: invalid address %q", s.Name, s.IP)
+        case s.Lat < -90 || s.Lat > 90 || s.Lon < -180 || s.Lon > 180:
+            return manifest, fmt.Errorf("%s: invalid coordinates %g,%g", s.Name, s.Lat, s.Lon)
+        case seen[key]:
+            return manifest, fmt.Errorf("%s (%s): duplicate entry", s.Name, s.IP)
+        }
+        if _, err := parseProbe(s.Probe); err != nil {
+            return manifest, fmt.Errorf("%s: %v", s.Name, err)
+        }
+        seen[key] = true
+        // Seuls les champs descriptifs sont repris du manifeste
+        manifest.Servers[i] = Server{Name: s.Name, IP: s.IP, Country: s.Country, City: s.City,
+            Lat: s.Lat, Lon: s.Lon, Provider: s.Provider, Probe: s.Probe}
+    }
+    return manifest, nil
+}
+
+// verifyManifest vérifie le manifeste avec la clé publique ed25519 (base64)
+// et la signature publiée, et/ou avec son empreinte SHA-256 (hexadécimal).
+// Au moins l'une des deux vérifications est exigée.
+func verifyManifest(data, signature []byte, publicKey, checksum string) error {
+    if publicKey == "" && checksum == "" {
+        return errors.New("no public key or checksum to verify the manifest against")
+    }
+    if checksum != "" {
+        sum := sha256.Sum256(data)
+        if !strings.EqualFold(hex.EncodeToString(sum[:]), checksum) {
+            return errors.New("manifest checksum mismatch")
+        }
+    }
+    if publicKey != "" {
+        key, err := base64.StdEncoding.DecodeString(publicKey)
+        if err != nil || len(key) != ed25519.PublicKeySize {
+            return fmt.Errorf("invalid public key %q", publicKey)
+        }
+        sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(signature)))
+        if err != nil || !ed25519.Verify(key, data, sig) {
+            return errors.New("invalid manifest signature")
+        }
+    }
+    return nil
+}
+
+// fetch télécharge url, dans la limite de maxManifestSize.
+func fetch(client *http.Client, url string) ([]byte, error) {
+    resp, err := client.Get(url)
+    if err != nil {
+        return nil, err
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("%s: %s", url, resp.Status)
+    }
+    data, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+    if err != nil {
+        return nil, err
+    }
+    if len(data) > maxManifestSize {
+        return nil, fmt.Errorf("%s: larger than %d bytes", url, maxManifestSize)
+    }
+    return data, nil
+}
+
+// DatabaseDiff résume les changements d'une mise à jour de la base, par
+// nom de serveur.
+type DatabaseDiff struct {
+    Added   []string
+    Removed []string
+    Changed []string // adresse, position, pays, ville ou fournisseur modifiés
+}
+
+func diffDatabases(old, updated []Server) DatabaseDiff {
+    byName := make(map[string]Server, len(old))
+    for _, s := range old {
+        byName[s.Name] = s
+    }
+    var diff DatabaseDiff
+    kept := make(map[string]bool, len(updated))
+    for _, s := range updated {
+        kept[s.Name] = true
+        prev, ok := byName[s.Name]
+        switch {
+        case !ok:
+            diff.Added = append(diff.Added, s.Name)
+        case prev.IP != s.IP || prev.Lat != s.Lat || prev.Lon != s.Lon || prev.Country != s.Country ||
+            prev.City != s.City || prev.Provider != s.Provider || prev.Probe != s.Probe:
+            diff.Changed = append(diff.Changed, s.Name)
+        }
+    }
+    for _, s := range old {
+        if !kept[s.Name] {
+            diff.Removed = append(diff.Removed, s.Name)
+        }
+    }
+    sort.Strings(diff.Added)
+    sort.Strings(diff.Removed)
+    sort.Strings(diff.Changed)
+    return diff
+}
+
+// updateDatabase télécharge le manifeste de url (et sa signature si
+// publicKey est fourni), le vérifie, le valide puis l'écrit dans le cache
+// local. Retourne la base remplacée et la nouvelle.
+func updateDatabase(url, publicKey, checksum string) ([]Server, []Server, error) {
+    old := getServerDatabase()
+    client := &http.Client{Timeout: manifestTimeout}
+    data, err := fetch(client, url)
+    if err != nil {
+        return nil, nil, err
+    }
+    var signature []byte
+    if publicKey != "" {
+        if signature, err = fetch(client, url+manifestSignatureSuffix); err != nil {
+            return nil, nil, err
+        }
+    }
+    if err := verifyManifest(data, signature, publicKey, checksum); err != nil {
+        return nil, nil, err
+    }
+    manifest, err := parseManifest(data)
+    if err != nil {
+        return nil, nil, fmt.Errorf("%s: %v", url, err)
+    }
+
+    path, err := serverCachePath()
+    if err != nil {
+        return nil, nil, err
+    }
+    if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+        return nil, nil, err
+    }
+    // Écriture puis renommage : une exécution concurrente ne lit jamais un
+    // fichier à moitié écrit
+    tmp := path + ".tmp"
+    if err := os.WriteFile(tmp, data, 0644); err != nil {
+        return nil, nil, err
+    }
+    if err := os.Rename(tmp, path); err != nil {
+        return nil, nil, err
+    }
+    return old, manifest.Servers, nil
+}
+
+// displayDatabaseDiff affiche le résumé d'une mise à jour de la base.
+func displayDatabaseDiff(w io.Writer, diff DatabaseDiff, total int) {
+    fmt.Fprintf(w, tr("db.updated"), total, len(diff.Added), len(diff.Removed), len(diff.Changed))
+    for _, group := range []struct {
+        label string
+        names []string
+    }{{"db.added", diff.Added}, {"db.removed", diff.Removed}, {"db.changed", diff.Changed}} {
+        if len(group.names) > 0 {
+            fmt.Fprintf(w, tr(group.label), strings.Join(group.names, ", "))
+        }
+    }
+}
@@ -0,0 +1,62 @@
+package main
+
+import "triangula/geo"
+
+// DistanceMatrix garde les distances (km) entre des serveurs, calculées une
+// fois à la construction au lieu d'être recalculées par chaque méthode ou
+// affichage. Elle n'est plus modifiée ensuite : plusieurs goroutines peuvent
+// la lire sans verrou.
+type DistanceMatrix struct {
+    n  int
+    km []float64 // triangle supérieur strict, ligne par ligne
+}
+
+// newDistanceMatrix calcule les distances entre servers, indexées dans
+// l'ordre de la liste.
+func newDistanceMatrix(servers []Server) *DistanceMatrix {
+    m := &DistanceMatrix{n: len(servers), km: make([]float64, len(servers)*(len(servers)-1)/2)}
+    k := 0
+    for i := range servers {
+        for j := i + 1; j < len(servers); j++ {
+            a, b := servers[i], servers[j]
+            m.km[k] = geo.Distance(a.Lat, a.Lon, b.Lat, b.Lon)
+            k++
+        }
+    }
+    return m
+}
+
+// Len retourne le nombre de serveurs de la matrice.
+func (m *DistanceMatrix) Len() int {
+    return m.n
+}
+
+// Get retourne la distance (km) entre les serveurs i et j, 0 si i == j.
+func (m *DistanceMatrix) Get(i, j int) float64 {
+    if i == j {
+        return 0
+    }
+    if i > j {
+        i, j = j, i
+    }
+    return m.km[i*m.n-i*(i+1)/2+j-i-1]
+}
+
+// Max retourne la plus grande distance entre deux serveurs, 0 s'il y en a
+// moins de deux.
+func (m *DistanceMatrix) Max() float64 {
+    var spread float64
+    for _, d := range m.km {
+        spread = max(spread, d)
+    }
+    return spread
+}
+
+// resultServers retourne les serveurs des résultats, dans le même ordre.
+func resultServers(results []Result) []Server {
+    servers := make([]Server, len(results))
+    for i, r := range results {
+        servers[i] = r.Server
+    }
+    return servers
+}
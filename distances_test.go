@@ -0,0 +1,46 @@
+package main
+
+import (
+    "testing"
+
+    "triangula/geo"
+)
+
+func TestDistanceMatrix(t *testing.T) {
+    locations := []Location{
+        {Lat: 48.8566, Lon: 2.3522},
+        {Lat: 51.5074, Lon: -0.1278},
+        {Lat: 35.6762, Lon: 139.6503},
+        {Lat: -33.8688, Lon: 151.2093},
+        {Lat: 48.8566, Lon: 2.3522}, // colocalisé avec le premier
+    }
+    for n := 0; n <= len(locations); n++ {
+        servers := make([]Server, n)
+        for i := range servers {
+            servers[i] = Server{Name: "s", Lat: locations[i].Lat, Lon: locations[i].Lon}
+        }
+        m := newDistanceMatrix(servers)
+        if m.Len() != n {
+            t.Errorf("%d servers: Len = %d", n, m.Len())
+        }
+        var spread float64
+        for i := 0; i < n; i++ {
+            if d := m.Get(i, i); d != 0 {
+                t.Errorf("%d servers: Get(%d, %d) = %v, want 0", n, i, i, d)
+            }
+            for j := 0; j < n; j++ {
+                a, b := locations[i], locations[j]
+                want := geo.Distance(a.Lat, a.Lon, b.Lat, b.Lon)
+                if got := m.Get(i, j); got != m.Get(j, i) {
+                    t.Errorf("%d servers: Get(%d, %d) = %v but Get(%d, %d) = %v", n, i, j, got, j, i, m.Get(j, i))
+                } else if i != j && got != want {
+                    t.Errorf("%d servers: Get(%d, %d) = %v, want %v", n, i, j, got, want)
+                }
+                spread = max(spread, m.Get(i, j))
+            }
+        }
+        if m.Max() != spread {
+            t.Errorf("%d servers: Max = %v, want %v", n, m.Max(), spread)
+        }
+    }
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net"
+    "time"
+
+    "golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+    // Nom demandé par DNSPing : très demandé, donc dans le cache de tout
+    // résolveur, pour que le temps de réponse soit celui du réseau et non
+    // d'une résolution récursive
+    dnsPingName = "example.com."
+    // Attente maximale de la réponse à une requête
+    dnsPingQueryTimeout = 2 * time.Second
+)
+
+// DNSPing envoie count requêtes DNS (type A, UDP) au résolveur et retourne
+// les statistiques de leur temps de réponse. Une réponse tronquée compte
+// comme une réponse : seul son délai importe. Une requête sans réponse
+// dans le délai est perdue ; l'erreur n'est retournée que si toutes le sont.
+func DNSPing(ctx context.Context, server string, count int, timeout time.Duration) (PingStats, error) {
+    return dnsMeasurer{Timeout: timeout}.Measure(ctx, server, count)
+}
+
+// dnsMeasurer mesure le RTT d'un résolveur par des requêtes DNS (voir DNSPing).
+type dnsMeasurer struct {
+    Timeout  time.Duration      // durée maximale de la mesure
+    Source   string             // adresse source ; vide : route par défaut
+    Sampling sequentialSampling // arrêt anticipé ; désactivé par défaut
+}
+
+func (m dnsMeasurer) Measure(ctx context.Context, ip string, count int) (PingStats, error) {
+    if count <= 0 {
+        return PingStats{}, fmt.Errorf("dns ping %s: count must be positive, got %d", ip, count)
+    }
+    ctx, cancel := context.WithTimeout(ctx, m.Timeout)
+    defer cancel()
+
+    dialer := net.Dialer{}
+    if m.Source != "" {
+        dialer.LocalAddr = &net.UDPAddr{IP: net.ParseIP(m.Source)}
+    }
+    conn, err := dialer.DialContext(ctx, "udp", net.JoinHostPort(ip, "53"))
+    if err != nil {
+        return PingStats{}, err
+    }
+    defer conn.Close()
+
+    // Fermer la socket débloque la lecture en cours à l'annulation
+    done := make(chan struct{})
+    defer close(done)
+    go func() {
+        select {
+        case <-ctx.Done():
+            conn.Close()
+        case <-done:
+        }
+    }()
+
+    var samples []time.Duration
+    var lastErr error
+    sent := 0
+    for ; sent < count && !m.Sampling.done(samples); sent++ {
+        rtt, err := dnsQuery(conn, uint16(sent+1))
+        if ctx.Err() != nil {
+            if errors.Is(ctx.Err(), context.DeadlineExceeded) && len(samples) > 0 {
+                break
+            }
+            return PingStats{}, ctx.Err()
+        }
+        if err != nil {
+            lastErr = err
+            continue
+        }
+        samples = append(samples, rtt)
+    }
+
+    if len(samples) == 0 {
+        return PingStats{}, fmt.Errorf("%s (dns): %v", tr("ping.noReply"), lastErr)
+    }
+    return statsFromSamples(samples, sent), nil
+}
+
+// dnsQuery envoie une requête d'identifiant id et attend la réponse
+// correspondante ; les datagrammes étrangers (anciennes réponses en retard)
+// sont ignorés.
+func dnsQuery(conn net.Conn, id uint16) (time.Duration, error) {
+    name, err := dnsmessage.NewName(dnsPingName)
+    if err != nil {
+        return 0, err
+    }
+    query := dnsmessage.Message{
+        Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+        Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}},
+    }
+    packet, err := query.Pack()
+    if err != nil {
+        return 0, err
+    }
+
+    start := time.Now()
+    conn.SetDeadline(start.Add(dnsPingQueryTimeout))
+    if _, err := conn.Write(packet); err != nil {
+        return 0, err
+    }
+    buf := make([]byte, 512)
+    for {
+        n, err := conn.Read(buf)
+        if err != nil {
+            return 0, err
+        }
+        var parser dnsmessage.Parser
+        header, err := parser.Start(buf[:n])
+        if err != nil || !header.Response || header.ID != id {
+            continue
+        }
+        return time.Since(start), nil
+    }
+}
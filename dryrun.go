@@ -0,0 +1,103 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "io"
+    "time"
+)
+
+// Intervalle entre deux pings d'une même mesure (défaut de go-ping et de
+// ping(8))
+const pingInterval = time.Second
+
+// Délai entre deux lancements de workers dans sweepServers
+const sweepStagger = 10 * time.Millisecond
+
+// MeasurementPlan décrit ce que ferait une exécution (--dry-run).
+type MeasurementPlan struct {
+    Target      Target
+    Resolved    bool
+    Servers     int
+    Concurrency int
+    TargetTime  time.Duration // mesure de la cible
+    SweepTime   time.Duration // balayage des serveurs
+    ExtraTime   time.Duration // traceroute, au pire
+    Deadline    time.Duration // 0 : aucune
+    Flags       []string      // options passées explicitement, nom=valeur
+}
+
+// Estimated retourne la durée estimée de la phase de mesure, bornée par
+// --deadline, plus le traceroute éventuel.
+func (p MeasurementPlan) Estimated() time.Duration {
+    measure := p.TargetTime + p.SweepTime
+    if p.Deadline > 0 && measure > p.Deadline {
+        measure = p.Deadline
+    }
+    return measure + p.ExtraTime
+}
+
+// measureDuration estime la durée d'une mesure de count pings : un ping
+// par intervalle, au plus timeout.
+func measureDuration(count int, timeout time.Duration) time.Duration {
+    d := time.Duration(count-1)*pingInterval + pingInterval/2
+    if d > timeout {
+        d = timeout
+    }
+    return d
+}
+
+// planMeasurements calcule le plan de mesure sans émettre de paquet.
+// sweepServers lance un worker par serveur, au plus opts.Concurrency actifs
+// en même temps ; avec --concurrency=auto, la durée suppose le maximum atteint.
+func planMeasurements(target Target, resolved bool, servers []Server, opts Options,
+    deadline time.Duration, withTraceroute bool) MeasurementPlan {
+    opts = opts.withDefaults()
+
+    plan := MeasurementPlan{
+        Target:      target,
+        Resolved:    resolved,
+        Servers:     len(servers),
+        Concurrency: opts.Concurrency.Workers(len(servers)),
+        TargetTime:  measureDuration(opts.TargetCount, opts.Timeout),
+        Deadline:    deadline,
+    }
+    if len(servers) > 0 {
+        waves := (len(servers) + plan.Concurrency - 1) / plan.Concurrency
+        plan.SweepTime = time.Duration(len(servers)-1)*sweepStagger + time.Duration(waves)*measureDuration(opts.Count, opts.Timeout)
+    }
+    if withTraceroute {
+        plan.ExtraTime = time.Duration((1+tracerouteServers)*tracerouteMaxHops) * tracerouteHopTimeout
+    }
+    flag.Visit(func(f *flag.Flag) {
+        plan.Flags = append(plan.Flags, fmt.Sprintf("--%s=%s", f.Name, f.Value))
+    })
+    return plan
+}
+
+// printPlan affiche le plan de mesure.
+func printPlan(w io.Writer, plan MeasurementPlan) {
+    fmt.Fprintln(w, tr("plan.title"))
+    if plan.Resolved {
+        fmt.Fprintf(w, tr("plan.target"), plan.Target.Label())
+    } else {
+        fmt.Fprintf(w, tr("plan.unresolved"), plan.Target.Input)
+    }
+    fmt.Fprintf(w, tr("plan.servers"), plan.Servers, plan.Concurrency)
+    fmt.Fprintf(w, tr("plan.duration"), plan.Estimated().Round(100*time.Millisecond),
+        plan.TargetTime.Round(100*time.Millisecond), plan.SweepTime.Round(100*time.Millisecond))
+    if plan.Deadline > 0 {
+        fmt.Fprintf(w, tr("plan.deadline"), plan.Deadline)
+    }
+    if plan.ExtraTime > 0 {
+        fmt.Fprintf(w, tr("plan.traceroute"), plan.ExtraTime)
+    }
+    if len(plan.Flags) == 0 {
+        fmt.Fprintln(w, tr("plan.noFlags"))
+        return
+    }
+    fmt.Fprintln(w, tr("plan.flags"))
+    for _, f := range plan.Flags {
+        fmt.Fprintf(w, "    %s\n", f)
+    }
+}
@@ -0,0 +1,43 @@
+package main
+
+import "fmt"
+
+// Codes de sortie du processus. Les codes 3 et 4 ne sont utilisés qu'avec
+// --strict, pour qu'un script détecte une triangulation peu fiable sans
+// analyser la sortie.
+const (
+    exitOK            = 0   // estimation fiable (ou mode non strict)
+    exitError         = 1   // erreur d'exécution (cible injoignable, fichier illisible...)
+    exitUsage         = 2   // option invalide
+    exitWeakCoherence = 3   // cohérence FAIBLE
+    exitTooFewServers = 4   // moins de 3 serveurs ou d'emplacements distincts, ou trop regroupés
+    exitInterrupted   = 130 // Ctrl-C avant la mesure de la cible, ou second Ctrl-C
+)
+
+// strictExitCode retourne le code de sortie correspondant à la qualité du
+// résultat, minLocations étant le nombre minimal d'emplacements distincts.
+func strictExitCode(results []Result, minLocations int) int {
+    if len(results) < 3 || distinctLocations(results) < minLocations || geometricSpread(results) < minGeometricSpreadKm {
+        return exitTooFewServers
+    }
+    if assessCoherence(results).Level == coherenceWeak {
+        return exitWeakCoherence
+    }
+    return exitOK
+}
+
+// exitStatus est l'erreur qui remonte jusqu'à main pour terminer le
+// processus avec un code donné, le message ayant déjà été affiché.
+type exitStatus int
+
+func (s exitStatus) Error() string {
+    return fmt.Sprintf("exit status %d", int(s))
+}
+
+// exitWith retourne l'exitStatus du code, nil pour exitOK.
+func exitWith(code int) error {
+    if code == exitOK {
+        return nil
+    }
+    return exitStatus(code)
+}
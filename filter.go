@@ -0,0 +1,83 @@
+package main
+
+import (
+    "fmt"
+    "path"
+    "regexp"
+    "strings"
+)
+
+// namePattern sélectionne des serveurs par nom : motif shell (path.Match,
+// insensible à la casse) ou expression régulière entre barres obliques
+// (/^Test-/).
+type namePattern struct {
+    text  string
+    regex *regexp.Regexp
+}
+
+func parseNamePattern(text string) (namePattern, error) {
+    if len(text) >= 2 && strings.HasPrefix(text, "/") && strings.HasSuffix(text, "/") {
+        re, err := regexp.Compile(text[1 : len(text)-1])
+        if err != nil {
+            return namePattern{}, fmt.Errorf("%q: %v", text, err)
+        }
+        return namePattern{text: text, regex: re}, nil
+    }
+    if _, err := path.Match(text, ""); err != nil {
+        return namePattern{}, fmt.Errorf("%q: %v", text, err)
+    }
+    return namePattern{text: strings.ToLower(text)}, nil
+}
+
+func (p namePattern) matches(name string) bool {
+    if p.regex != nil {
+        return p.regex.MatchString(name)
+    }
+    ok, _ := path.Match(p.text, strings.ToLower(name))
+    return ok
+}
+
+// patternList est une option répétable (--exclude a --exclude b).
+type patternList []namePattern
+
+func (l *patternList) String() string {
+    texts := make([]string, len(*l))
+    for i, p := range *l {
+        texts[i] = p.text
+    }
+    return strings.Join(texts, ",")
+}
+
+func (l *patternList) Set(text string) error {
+    p, err := parseNamePattern(text)
+    if err != nil {
+        return err
+    }
+    *l = append(*l, p)
+    return nil
+}
+
+func (l patternList) matches(name string) bool {
+    for _, p := range l {
+        if p.matches(name) {
+            return true
+        }
+    }
+    return false
+}
+
+// filterServers garde les serveurs retenus par include (tous si vide) et
+// non écartés par exclude. Retourne aussi le nombre de serveurs écartés.
+func filterServers(servers []Server, include, exclude patternList) ([]Server, int) {
+    var kept []Server
+    for _, s := range servers {
+        if len(include) > 0 && !include.matches(s.Name) {
+            continue
+        }
+        if exclude.matches(s.Name) {
+            continue
+        }
+        kept = append(kept, s)
+    }
+    return kept, len(servers) - len(kept)
+}
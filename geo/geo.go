@@ -0,0 +1,125 @@
+// Package geo regroupe les calculs géographiques de Triangula : distances
+// sur la sphère terrestre, conversion RTT -> distance et trilatération.
+package geo
+
+import (
+    "math"
+    "time"
+)
+
+const (
+    // SpeedOfLight est la vitesse de la lumière dans le vide, en km/s.
+    SpeedOfLight = 299792.458
+    // DefaultVelocityFactor est le facteur de vélocité de la fibre optique
+    // (vitesse de propagation rapportée à c).
+    DefaultVelocityFactor = 0.67
+    // FiberSpeed est la vitesse de propagation dans la fibre optique, en km/s.
+    FiberSpeed = SpeedOfLight * DefaultVelocityFactor
+    // EarthRadius est le rayon moyen de la Terre, en km.
+    EarthRadius = 6371.0
+)
+
+// Location est une position géographique en degrés décimaux.
+type Location struct {
+    Lat float64 `json:"lat"`
+    Lon float64 `json:"lon"`
+}
+
+// Distance retourne la distance orthodromique (formule de Haversine) en km
+// entre deux points exprimés en degrés.
+func Distance(lat1, lon1, lat2, lon2 float64) float64 {
+    dLat := (lat2 - lat1) * math.Pi / 180
+    dLon := (lon2 - lon1) * math.Pi / 180
+
+    a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+        math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
+            math.Sin(dLon/2)*math.Sin(dLon/2)
+
+    c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+    return EarthRadius * c
+}
+
+// RTTToDistance convertit un temps aller-retour en distance (km) parcourue
+// dans la fibre, soit la moitié du trajet total.
+func RTTToDistance(rtt time.Duration) float64 {
+    return RTTToDistanceAt(rtt, DefaultVelocityFactor)
+}
+
+// RTTToDistanceAt est RTTToDistance pour un support de facteur de vélocité
+// quelconque (cuivre, micro-ondes, fibre à faible latence...).
+func RTTToDistanceAt(rtt time.Duration, velocityFactor float64) float64 {
+    seconds := rtt.Seconds()
+    // Division par 2 car RTT = aller-retour
+    return (seconds * SpeedOfLight * velocityFactor) / 2
+}
+
+// GeoToCartesian projette une position (degrés) en coordonnées ECEF sur la
+// sphère de rayon EarthRadius, en km.
+func GeoToCartesian(lat, lon float64) (x, y, z float64) {
+    latRad := lat * math.Pi / 180
+    lonRad := lon * math.Pi / 180
+
+    x = EarthRadius * math.Cos(latRad) * math.Cos(lonRad)
+    y = EarthRadius * math.Cos(latRad) * math.Sin(lonRad)
+    z = EarthRadius * math.Sin(latRad)
+    return
+}
+
+// CartesianToGeo est l'inverse de GeoToCartesian. Seule la direction du
+// vecteur compte : il n'a pas besoin d'être normalisé.
+func CartesianToGeo(x, y, z float64) (lat, lon float64) {
+    lon = math.Atan2(y, x) * 180 / math.Pi
+    hyp := math.Sqrt(x*x + y*y)
+    lat = math.Atan2(z, hyp) * 180 / math.Pi
+    return
+}
+
+// Longueur (km) en deçà de laquelle un barycentre ECEF est considéré nul :
+// sa direction, et donc la position, n'est alors pas définie
+const minCentroidNorm = 1e-6
+
+// Centroid retourne le barycentre des points pondéré par weights (nil :
+// poids égaux), calculé sur les vecteurs ECEF puis ramené à la surface :
+// des points de part et d'autre de l'antiméridien donnent un barycentre dans
+// le Pacifique, et non près du méridien d'origine. false si le barycentre
+// est au centre de la Terre (points antipodaux) ou s'il n'y a aucun point.
+func Centroid(points []Location, weights []float64) (Location, bool) {
+    var x, y, z, total float64
+    for i, p := range points {
+        w := 1.0
+        if weights != nil {
+            w = weights[i]
+        }
+        px, py, pz := GeoToCartesian(p.Lat, p.Lon)
+        x, y, z, total = x+px*w, y+py*w, z+pz*w, total+w
+    }
+    if total <= 0 || math.Sqrt(x*x+y*y+z*z)/total < minCentroidNorm {
+        return Location{}, false
+    }
+    // Seule la direction compte : CartesianToGeo n'exige pas un vecteur unitaire
+    lat, lon := CartesianToGeo(x, y, z)
+    return Location{Lat: lat, Lon: lon}, true
+}
+
+// Trilaterate estime une position à partir de trois points de référence et
+// de leurs distances estimées (km) : barycentre des vecteurs ECEF pondéré
+// par 1/(d+1), ramené à la surface de la sphère. Si ce barycentre est au
+// centre de la Terre (points antipodaux), le barycentre non pondéré est
+// utilisé, puis à défaut le point de référence le plus proche.
+func Trilaterate(p1, p2, p3 Location, d1, d2, d3 float64) Location {
+    points := []Location{p1, p2, p3}
+    // +1 pour éviter division par 0
+    if loc, ok := Centroid(points, []float64{1 / (d1 + 1), 1 / (d2 + 1), 1 / (d3 + 1)}); ok {
+        return loc
+    }
+    if loc, ok := Centroid(points, nil); ok {
+        return loc
+    }
+    nearest := p1
+    if d2 < d1 && d2 <= d3 {
+        nearest = p2
+    } else if d3 < d1 && d3 < d2 {
+        nearest = p3
+    }
+    return nearest
+}
@@ -0,0 +1,182 @@
+package geo
+
+import (
+    "math"
+    "testing"
+    "time"
+)
+
+// near indique si got est à moins de tolerance de want.
+func near(got, want, tolerance float64) bool {
+    return math.Abs(got-want) <= tolerance
+}
+
+func TestDistance(t *testing.T) {
+    tests := []struct {
+        name       string
+        lat1, lon1 float64
+        lat2, lon2 float64
+        want       float64 // km
+        tolerance  float64
+    }{
+        {name: "Paris-Londres", lat1: 48.8566, lon1: 2.3522, lat2: 51.5074, lon2: -0.1278, want: 344, tolerance: 1},
+        {name: "même point", lat1: 48.8566, lon1: 2.3522, lat2: 48.8566, lon2: 2.3522, want: 0, tolerance: 1e-9},
+        {name: "un degré à l'équateur", lat1: 0, lon1: 0, lat2: 0, lon2: 1, want: EarthRadius * math.Pi / 180, tolerance: 1e-6},
+        {name: "pôle à pôle", lat1: 90, lon1: 0, lat2: -90, lon2: 0, want: EarthRadius * math.Pi, tolerance: 1e-6},
+        {name: "antipodes", lat1: 0, lon1: 0, lat2: 0, lon2: 180, want: EarthRadius * math.Pi, tolerance: 1e-6},
+        {name: "antiméridien", lat1: 0, lon1: 179.5, lat2: 0, lon2: -179.5, want: EarthRadius * math.Pi / 180, tolerance: 1e-6},
+        {name: "pôle, longitude indifférente", lat1: 90, lon1: 0, lat2: 90, lon2: 120, want: 0, tolerance: 1e-6},
+    }
+    for _, tt := range tests {
+        got := Distance(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+        if !near(got, tt.want, tt.tolerance) {
+            t.Errorf("%s: Distance = %.6f km, want %.6f", tt.name, got, tt.want)
+        }
+        if back := Distance(tt.lat2, tt.lon2, tt.lat1, tt.lon1); !near(back, got, 1e-9) {
+            t.Errorf("%s: Distance is not symmetric (%.9f, %.9f)", tt.name, got, back)
+        }
+    }
+}
+
+func TestCartesianRoundTrip(t *testing.T) {
+    tests := []Location{
+        {Lat: 0, Lon: 0},
+        {Lat: 48.8566, Lon: 2.3522},
+        {Lat: -33.8688, Lon: 151.2093},
+        {Lat: 45, Lon: 180},
+        {Lat: -45, Lon: -179.9},
+        {Lat: 89.9, Lon: 10},
+    }
+    for _, p := range tests {
+        x, y, z := GeoToCartesian(p.Lat, p.Lon)
+        if r := math.Sqrt(x*x + y*y + z*z); !near(r, EarthRadius, 1e-6) {
+            t.Errorf("GeoToCartesian(%v) is %.6f km from the center, want %v", p, r, EarthRadius)
+        }
+        lat, lon := CartesianToGeo(x, y, z)
+        if d := Distance(lat, lon, p.Lat, p.Lon); d > 1e-6 {
+            t.Errorf("round trip of %v gave %.6f, %.6f (%.9f km away)", p, lat, lon, d)
+        }
+        // Seule la direction compte
+        lat, lon = CartesianToGeo(x/EarthRadius, y/EarthRadius, z/EarthRadius)
+        if d := Distance(lat, lon, p.Lat, p.Lon); d > 1e-6 {
+            t.Errorf("round trip of the unit vector of %v gave %.6f, %.6f", p, lat, lon)
+        }
+    }
+}
+
+func TestRTTToDistance(t *testing.T) {
+    tests := []struct {
+        rtt            time.Duration
+        velocityFactor float64
+        want           float64
+    }{
+        {rtt: 0, velocityFactor: DefaultVelocityFactor, want: 0},
+        {rtt: 10 * time.Millisecond, velocityFactor: 1, want: SpeedOfLight * 0.01 / 2},
+        {rtt: 10 * time.Millisecond, velocityFactor: DefaultVelocityFactor, want: FiberSpeed * 0.01 / 2},
+    }
+    for _, tt := range tests {
+        if got := RTTToDistanceAt(tt.rtt, tt.velocityFactor); !near(got, tt.want, 1e-9) {
+            t.Errorf("RTTToDistanceAt(%v, %v) = %v, want %v", tt.rtt, tt.velocityFactor, got, tt.want)
+        }
+    }
+    if got, want := RTTToDistance(10*time.Millisecond), RTTToDistanceAt(10*time.Millisecond, DefaultVelocityFactor); got != want {
+        t.Errorf("RTTToDistance = %v, want the default velocity factor's %v", got, want)
+    }
+}
+
+func TestDistanceModels(t *testing.T) {
+    rtt, baseline := 20*time.Millisecond, 2*time.Millisecond
+    tests := []struct {
+        name string
+        want float64
+    }{
+        {name: DefaultDistanceModel, want: RTTToDistance(rtt - baseline)},
+        {name: "calibrated", want: (rtt - baseline).Seconds() * SpeedOfLight * 4 / 9 / 2},
+        // Borne physique : le baseline n'est pas retiré
+        {name: "conservative", want: rtt.Seconds() * SpeedOfLight / 2},
+    }
+    for _, tt := range tests {
+        m, ok := LookupDistanceModel(tt.name)
+        if !ok {
+            t.Errorf("model %q is not registered", tt.name)
+            continue
+        }
+        if got := m.Distance(rtt, baseline); !near(got, tt.want, 1e-9) {
+            t.Errorf("%s.Distance(%v, %v) = %v, want %v", tt.name, rtt, baseline, got, tt.want)
+        }
+        if got := m.Distance(baseline/2, baseline); tt.name != "conservative" && got != 0 {
+            t.Errorf("%s: a RTT below the baseline gave %v km, want 0", tt.name, got)
+        }
+    }
+    if _, ok := LookupDistanceModel("unknown"); ok {
+        t.Error("LookupDistanceModel(unknown) found a model")
+    }
+    if got, want := LinearFiber(1).Distance(rtt, 0), RTTToDistanceAt(rtt, 1); got != want {
+        t.Errorf("LinearFiber(1) = %v, want %v", got, want)
+    }
+}
+
+func TestCentroid(t *testing.T) {
+    tests := []struct {
+        name    string
+        points  []Location
+        weights []float64
+        want    Location
+        ok      bool
+    }{
+        {name: "aucun point"},
+        {name: "un point", points: []Location{{Lat: 10, Lon: 20}}, want: Location{Lat: 10, Lon: 20}, ok: true},
+        {name: "antiméridien", points: []Location{{Lat: 0, Lon: 179}, {Lat: 0, Lon: -179}}, want: Location{Lat: 0, Lon: 180}, ok: true},
+        {name: "pondéré", points: []Location{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 90}}, weights: []float64{1, 0},
+            want: Location{Lat: 0, Lon: 0}, ok: true},
+        {name: "antipodes", points: []Location{{Lat: 0, Lon: 0}, {Lat: 0, Lon: 180}}},
+        {name: "pôles", points: []Location{{Lat: 90, Lon: 0}, {Lat: -90, Lon: 0}}},
+        {name: "poids nuls", points: []Location{{Lat: 0, Lon: 0}}, weights: []float64{0}},
+    }
+    for _, tt := range tests {
+        got, ok := Centroid(tt.points, tt.weights)
+        if ok != tt.ok {
+            t.Errorf("%s: Centroid ok = %v, want %v", tt.name, ok, tt.ok)
+            continue
+        }
+        if ok && Distance(got.Lat, got.Lon, tt.want.Lat, tt.want.Lon) > 1e-6 {
+            t.Errorf("%s: Centroid = %v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestTrilaterate(t *testing.T) {
+    a, b, c := Location{Lat: 0, Lon: 0}, Location{Lat: 0, Lon: 120}, Location{Lat: 0, Lon: -120}
+    antipode := Location{Lat: 0, Lon: 180}
+    tests := []struct {
+        name       string
+        p1, p2, p3 Location
+        d1, d2, d3 float64
+        want       Location
+    }{
+        {name: "points confondus", p1: a, p2: a, p3: a, d1: 10, d2: 20, d3: 30, want: a},
+        // Barycentre pondéré nul : repli sur le barycentre non pondéré
+        {name: "barycentre pondéré nul", p1: a, p2: antipode, p3: antipode, d1: 0, d2: 1, d3: 1, want: antipode},
+        // Les deux barycentres sont nuls : point le plus proche
+        {name: "équilatéral, distances égales", p1: a, p2: b, p3: c, d1: 5, d2: 5, d3: 5, want: a},
+        // Le poids de p2 suffit à écarter le barycentre du centre
+        {name: "équilatéral, p2 le plus proche", p1: a, p2: b, p3: c, d1: 5, d2: 1, d3: 5, want: b},
+    }
+    for _, tt := range tests {
+        got := Trilaterate(tt.p1, tt.p2, tt.p3, tt.d1, tt.d2, tt.d3)
+        if math.IsNaN(got.Lat) || math.IsNaN(got.Lon) {
+            t.Errorf("%s: Trilaterate = %v", tt.name, got)
+            continue
+        }
+        if d := Distance(got.Lat, got.Lon, tt.want.Lat, tt.want.Lon); d > 1e-6 {
+            t.Errorf("%s: Trilaterate = %v, want %v", tt.name, got, tt.want)
+        }
+    }
+
+    // Cas courant : l'estimation reste entre les points et penche vers le plus proche
+    p1, p2, p3 := Location{Lat: 48.8566, Lon: 2.3522}, Location{Lat: 51.5074, Lon: -0.1278}, Location{Lat: 50.1109, Lon: 8.6821}
+    got := Trilaterate(p1, p2, p3, 10, 300, 300)
+    if Distance(got.Lat, got.Lon, p1.Lat, p1.Lon) > Distance(got.Lat, got.Lon, p2.Lat, p2.Lon) {
+        t.Errorf("Trilaterate = %v, want closer to %v than to %v", got, p1, p2)
+    }
+}
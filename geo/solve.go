@@ -0,0 +1,380 @@
+package geo
+
+import (
+    "math"
+    "sort"
+    "sync"
+)
+
+// Anchor est un point de référence de position connue, à une distance
+// estimée (km) de la cible. Si MaxDistance est renseignée, la distance n'est
+// connue qu'à l'intervalle [MinDistance, MaxDistance] près et le résidu est
+// nul à l'intérieur de cet anneau.
+type Anchor struct {
+    Location
+    Distance    float64
+    MinDistance float64
+    MaxDistance float64
+    Weight      float64 // poids du résidu dans l'objectif ; 1 si nul
+}
+
+// Residual retourne l'écart (km) entre la distance de p à l'ancre et la
+// distance estimée, ou l'anneau [MinDistance, MaxDistance].
+func (a Anchor) Residual(p Location) float64 {
+    d := Distance(p.Lat, p.Lon, a.Lat, a.Lon)
+    if a.MaxDistance <= 0 {
+        return d - a.Distance
+    }
+    if d < a.MinDistance {
+        return d - a.MinDistance
+    }
+    if d > a.MaxDistance {
+        return d - a.MaxDistance
+    }
+    return 0
+}
+
+// Solver cherche la position minimisant la somme pondérée des carrés des
+// résidus (distance orthodromique - distance estimée), à partir de start.
+type Solver interface {
+    Solve(anchors []Anchor, start Location) Location
+}
+
+// SolverFunc permet d'utiliser une fonction comme Solver.
+type SolverFunc func(anchors []Anchor, start Location) Location
+
+func (f SolverFunc) Solve(anchors []Anchor, start Location) Location {
+    return f(anchors, start)
+}
+
+// DefaultSolver est le solveur de moindres carrés utilisé par défaut.
+const DefaultSolver = "gauss-newton"
+
+const (
+    solverMaxIterations = 200
+    solverTolerance     = 1e-7 // degrés
+    jacobianStep        = 1e-5 // degrés
+)
+
+var (
+    solversMu sync.RWMutex
+    solvers   = map[string]Solver{}
+)
+
+func init() {
+    RegisterSolver(DefaultSolver, SolverFunc(GaussNewton))
+    RegisterSolver("nelder-mead", SolverFunc(NelderMead))
+    RegisterSolver("irls", IRLS{Threshold: DefaultIRLSThreshold, Loss: LossHuber})
+}
+
+// RegisterSolver enregistre (ou remplace) un solveur sous un nom.
+func RegisterSolver(name string, s Solver) {
+    solversMu.Lock()
+    defer solversMu.Unlock()
+    solvers[name] = s
+}
+
+// LookupSolver retourne le solveur enregistré sous ce nom.
+func LookupSolver(name string) (Solver, bool) {
+    solversMu.RLock()
+    defer solversMu.RUnlock()
+    s, ok := solvers[name]
+    return s, ok
+}
+
+// Solvers retourne les noms des solveurs enregistrés, triés.
+func Solvers() []string {
+    solversMu.RLock()
+    defer solversMu.RUnlock()
+    names := make([]string, 0, len(solvers))
+    for name := range solvers {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// Residual retourne l'objectif des moindres carrés en p.
+func Residual(anchors []Anchor, p Location) float64 {
+    var sum float64
+    for _, a := range anchors {
+        r := a.Residual(p)
+        sum += anchorWeight(a) * r * r
+    }
+    return sum
+}
+
+func anchorWeight(a Anchor) float64 {
+    if a.Weight <= 0 {
+        return 1
+    }
+    return a.Weight
+}
+
+// normalize ramène une position dans les bornes lat [-90, 90], lon [-180, 180].
+func normalize(p Location) Location {
+    p.Lat = math.Max(-90, math.Min(90, p.Lat))
+    for p.Lon > 180 {
+        p.Lon -= 360
+    }
+    for p.Lon < -180 {
+        p.Lon += 360
+    }
+    return p
+}
+
+// GaussNewton résout les moindres carrés par Gauss-Newton, la jacobienne
+// étant approchée par différences finies sur lat/lon.
+func GaussNewton(anchors []Anchor, start Location) Location {
+    p := normalize(start)
+    if len(anchors) == 0 {
+        return p
+    }
+
+    for iter := 0; iter < solverMaxIterations; iter++ {
+        // Équations normales (JᵀWJ) δ = -JᵀWr, système 2x2
+        var a11, a12, a22, b1, b2 float64
+        for _, a := range anchors {
+            r := a.Residual(p)
+            jLat := (a.Residual(Location{Lat: p.Lat + jacobianStep, Lon: p.Lon}) - r) / jacobianStep
+            jLon := (a.Residual(Location{Lat: p.Lat, Lon: p.Lon + jacobianStep}) - r) / jacobianStep
+            w := anchorWeight(a)
+
+            a11 += w * jLat * jLat
+            a12 += w * jLat * jLon
+            a22 += w * jLon * jLon
+            b1 -= w * jLat * r
+            b2 -= w * jLon * r
+        }
+
+        det := a11*a22 - a12*a12
+        if math.Abs(det) < 1e-12 {
+            break
+        }
+        dLat := (b1*a22 - b2*a12) / det
+        dLon := (a11*b2 - a12*b1) / det
+
+        // Pas réduit tant que l'objectif ne diminue pas (géométrie défavorable)
+        current := Residual(anchors, p)
+        next := normalize(Location{Lat: p.Lat + dLat, Lon: p.Lon + dLon})
+        for step := 0; step < 20 && Residual(anchors, next) > current; step++ {
+            dLat, dLon = dLat/2, dLon/2
+            next = normalize(Location{Lat: p.Lat + dLat, Lon: p.Lon + dLon})
+        }
+        if Residual(anchors, next) > current {
+            break
+        }
+        p = next
+
+        if math.Hypot(dLat, dLon) < solverTolerance {
+            break
+        }
+    }
+    return p
+}
+
+// NelderMead minimise l'objectif par la méthode du simplexe (sans dérivées),
+// plus robuste que Gauss-Newton quand la géométrie est mauvaise.
+func NelderMead(anchors []Anchor, start Location) Location {
+    const (
+        reflection  = 1.0
+        expansion   = 2.0
+        contraction = 0.5
+        shrink      = 0.5
+        initialStep = 1.0 // degrés
+    )
+
+    start = normalize(start)
+    if len(anchors) == 0 {
+        return start
+    }
+
+    type vertex struct {
+        p Location
+        f float64
+    }
+    eval := func(p Location) vertex {
+        p = normalize(p)
+        return vertex{p, Residual(anchors, p)}
+    }
+    // Combinaison affine a + t(b - a)
+    along := func(a, b Location, t float64) Location {
+        return Location{Lat: a.Lat + t*(b.Lat-a.Lat), Lon: a.Lon + t*(b.Lon-a.Lon)}
+    }
+
+    simplex := []vertex{
+        eval(start),
+        eval(Location{Lat: start.Lat + initialStep, Lon: start.Lon}),
+        eval(Location{Lat: start.Lat, Lon: start.Lon + initialStep}),
+    }
+
+    for iter := 0; iter < solverMaxIterations*5; iter++ {
+        sort.Slice(simplex, func(i, j int) bool { return simplex[i].f < simplex[j].f })
+        best, worst := simplex[0], simplex[2]
+
+        size := math.Max(
+            math.Hypot(simplex[1].p.Lat-best.p.Lat, simplex[1].p.Lon-best.p.Lon),
+            math.Hypot(worst.p.Lat-best.p.Lat, worst.p.Lon-best.p.Lon))
+        if size < solverTolerance {
+            break
+        }
+
+        centroid := along(simplex[0].p, simplex[1].p, 0.5)
+        reflected := eval(along(centroid, worst.p, -reflection))
+
+        switch {
+        case reflected.f < best.f:
+            if expanded := eval(along(centroid, worst.p, -expansion)); expanded.f < reflected.f {
+                simplex[2] = expanded
+            } else {
+                simplex[2] = reflected
+            }
+        case reflected.f < simplex[1].f:
+            simplex[2] = reflected
+        default:
+            if contracted := eval(along(centroid, worst.p, contraction)); contracted.f < worst.f {
+                simplex[2] = contracted
+            } else {
+                for i := 1; i < len(simplex); i++ {
+                    simplex[i] = eval(along(best.p, simplex[i].p, shrink))
+                }
+            }
+        }
+    }
+
+    sort.Slice(simplex, func(i, j int) bool { return simplex[i].f < simplex[j].f })
+    return simplex[0].p
+}
+
+// WeightedSolver est un Solver qui expose le poids final attribué à chaque
+// point de référence (dans l'ordre des anchors).
+type WeightedSolver interface {
+    Solver
+    SolveWeighted(anchors []Anchor, start Location) (Location, []float64)
+}
+
+// Fonctions de perte robustes de l'IRLS
+const (
+    LossHuber = "huber"
+    LossTukey = "tukey"
+)
+
+// DefaultIRLSThreshold est le seuil de résidu (km) au-delà duquel l'IRLS
+// réduit le poids d'un serveur.
+const DefaultIRLSThreshold = 250.0
+
+const irlsMaxIterations = 50
+
+// IRLS résout les moindres carrés itérativement repondérés : à chaque
+// itération, les serveurs à fort résidu (anycast, route congestionnée) sont
+// dépondérés selon la perte Huber ou Tukey, puis la position est recalculée
+// par Gauss-Newton sur le même objectif.
+type IRLS struct {
+    Threshold float64 // seuil de la perte, km
+    Loss      string  // LossHuber ou LossTukey
+}
+
+func (s IRLS) Solve(anchors []Anchor, start Location) Location {
+    p, _ := s.SolveWeighted(anchors, start)
+    return p
+}
+
+func (s IRLS) SolveWeighted(anchors []Anchor, start Location) (Location, []float64) {
+    threshold := s.Threshold
+    if threshold <= 0 {
+        threshold = DefaultIRLSThreshold
+    }
+
+    robust := make([]float64, len(anchors))
+    for i := range robust {
+        robust[i] = 1
+    }
+    weighted := make([]Anchor, len(anchors))
+
+    p := normalize(start)
+    for iter := 0; iter < irlsMaxIterations; iter++ {
+        for i, a := range anchors {
+            weighted[i] = a
+            weighted[i].Weight = anchorWeight(a) * robust[i]
+        }
+        next := GaussNewton(weighted, p)
+        moved := math.Hypot(next.Lat-p.Lat, next.Lon-p.Lon)
+        p = next
+
+        active := 0
+        updated := make([]float64, len(anchors))
+        for i, a := range anchors {
+            r := math.Abs(a.Residual(p))
+            updated[i] = s.weight(r, threshold)
+            if updated[i] > 0 {
+                active++
+            }
+        }
+        // Tukey peut écarter presque tous les points : on garde alors les
+        // poids (et la position) de l'itération précédente
+        if active < 3 {
+            break
+        }
+        robust = updated
+        if moved < solverTolerance {
+            break
+        }
+    }
+    return p, robust
+}
+
+// weight retourne le poids robuste d'un résidu r (km).
+func (s IRLS) weight(r, threshold float64) float64 {
+    if s.Loss == LossTukey {
+        if r >= threshold {
+            return 0
+        }
+        u := r / threshold
+        return (1 - u*u) * (1 - u*u)
+    }
+    if r <= threshold {
+        return 1
+    }
+    return threshold / r
+}
+
+// Pas (degrés) de la grille grossière de ResidualFloor
+const floorGridStep = 10.0
+
+// RMSResidual retourne le résidu quadratique moyen pondéré (km) en p.
+func RMSResidual(anchors []Anchor, p Location) float64 {
+    var total float64
+    for _, a := range anchors {
+        total += anchorWeight(a)
+    }
+    if total == 0 {
+        return 0
+    }
+    return math.Sqrt(Residual(anchors, p) / total)
+}
+
+// ResidualFloor estime le plus petit résidu RMS atteignable, quelle que soit
+// la position : le meilleur point d'une grille grossière couvrant le globe et
+// des candidats fournis est affiné par le solveur. Un plancher élevé indique
+// qu'aucune position unique n'explique les distances mesurées.
+func ResidualFloor(s Solver, anchors []Anchor, candidates ...Location) (Location, float64) {
+    best, bestScore := Location{}, math.Inf(1)
+    try := func(p Location) {
+        if score := Residual(anchors, p); score < bestScore {
+            best, bestScore = p, score
+        }
+    }
+    for _, c := range candidates {
+        try(normalize(c))
+    }
+    for lat := -80.0; lat <= 80; lat += floorGridStep {
+        for lon := -180.0; lon < 180; lon += floorGridStep {
+            try(Location{Lat: lat, Lon: lon})
+        }
+    }
+
+    if polished := s.Solve(anchors, best); Residual(anchors, polished) < bestScore {
+        best = polished
+    }
+    return best, RMSResidual(anchors, best)
+}
@@ -0,0 +1,117 @@
+package geo
+
+import (
+    "math"
+    "testing"
+)
+
+// exactAnchors retourne des ancres aux distances exactes de target.
+func exactAnchors(target Location, points ...Location) []Anchor {
+    anchors := make([]Anchor, len(points))
+    for i, p := range points {
+        anchors[i] = Anchor{Location: p, Distance: Distance(target.Lat, target.Lon, p.Lat, p.Lon)}
+    }
+    return anchors
+}
+
+var (
+    paris     = Location{Lat: 48.8566, Lon: 2.3522}
+    london    = Location{Lat: 51.5074, Lon: -0.1278}
+    frankfurt = Location{Lat: 50.1109, Lon: 8.6821}
+    madrid    = Location{Lat: 40.4168, Lon: -3.7038}
+    milan     = Location{Lat: 45.4642, Lon: 9.19}
+    tokyo     = Location{Lat: 35.6762, Lon: 139.6503}
+    sydney    = Location{Lat: -33.8688, Lon: 151.2093}
+    auckland  = Location{Lat: -36.8485, Lon: 174.7633}
+    fiji      = Location{Lat: -17.7134, Lon: 178.065}
+    honolulu  = Location{Lat: 21.3069, Lon: -157.8583}
+)
+
+func TestSolversConverge(t *testing.T) {
+    tests := []struct {
+        name    string
+        target  Location
+        start   Location
+        anchors []Location
+    }{
+        {name: "Europe", target: Location{Lat: 47.2, Lon: 4.1}, start: paris,
+            anchors: []Location{paris, london, frankfurt, madrid, milan}},
+        {name: "départ éloigné", target: Location{Lat: 47.2, Lon: 4.1}, start: Location{Lat: 40, Lon: 10},
+            anchors: []Location{paris, london, frankfurt, madrid, milan}},
+        {name: "antiméridien", target: Location{Lat: -20, Lon: -179}, start: fiji,
+            anchors: []Location{sydney, auckland, fiji, honolulu, tokyo}},
+    }
+    for _, tt := range tests {
+        anchors := exactAnchors(tt.target, tt.anchors...)
+        gn := GaussNewton(anchors, tt.start)
+        nm := NelderMead(anchors, tt.start)
+        if d := Distance(gn.Lat, gn.Lon, tt.target.Lat, tt.target.Lon); d > 1 {
+            t.Errorf("%s: GaussNewton = %v, %.3f km from %v", tt.name, gn, d, tt.target)
+        }
+        if d := Distance(nm.Lat, nm.Lon, tt.target.Lat, tt.target.Lon); d > 1 {
+            t.Errorf("%s: NelderMead = %v, %.3f km from %v", tt.name, nm, d, tt.target)
+        }
+        if d := Distance(gn.Lat, gn.Lon, nm.Lat, nm.Lon); d > 1 {
+            t.Errorf("%s: NelderMead lands %.3f km from GaussNewton", tt.name, d)
+        }
+    }
+}
+
+func TestSolversNoisyAgree(t *testing.T) {
+    target := Location{Lat: 47.2, Lon: 4.1}
+    anchors := exactAnchors(target, paris, london, frankfurt, madrid, milan)
+    // Bruit déterministe de quelques dizaines de km
+    for i, noise := range []float64{30, -25, 40, -10, 20} {
+        anchors[i].Distance += noise
+    }
+    gn := GaussNewton(anchors, paris)
+    nm := NelderMead(anchors, paris)
+    if d := Distance(gn.Lat, gn.Lon, nm.Lat, nm.Lon); d > 5 {
+        t.Errorf("NelderMead lands %.3f km from GaussNewton on noisy distances", d)
+    }
+    if rg, rn := Residual(anchors, gn), Residual(anchors, nm); rn > rg*1.01+1 {
+        t.Errorf("NelderMead residual %.3f, want close to GaussNewton's %.3f", rn, rg)
+    }
+    if d := Distance(gn.Lat, gn.Lon, target.Lat, target.Lon); d > 100 {
+        t.Errorf("GaussNewton = %v, %.3f km from %v", gn, d, target)
+    }
+}
+
+func TestSolversWithoutAnchors(t *testing.T) {
+    start := Location{Lat: 12, Lon: 190}
+    want := Location{Lat: 12, Lon: -170}
+    for name, solve := range map[string]SolverFunc{"gauss-newton": GaussNewton, "nelder-mead": NelderMead} {
+        if got := solve([]Anchor{}, start); got != want {
+            t.Errorf("%s without anchors = %v, want the normalized start %v", name, got, want)
+        }
+    }
+}
+
+func TestLookupSolver(t *testing.T) {
+    for _, name := range []string{DefaultSolver, "nelder-mead", "irls"} {
+        if _, ok := LookupSolver(name); !ok {
+            t.Errorf("solver %q is not registered", name)
+        }
+    }
+    if _, ok := LookupSolver("unknown"); ok {
+        t.Error("LookupSolver(unknown) found a solver")
+    }
+}
+
+func TestAnchorRing(t *testing.T) {
+    a := Anchor{Location: paris, MinDistance: 100, MaxDistance: 300}
+    tests := []struct {
+        distance float64 // km au nord de Paris
+        want     float64
+    }{
+        {distance: 50, want: -50},
+        {distance: 200, want: 0},
+        {distance: 400, want: 100},
+    }
+    for _, tt := range tests {
+        p := Location{Lat: paris.Lat + tt.distance/(EarthRadius*math.Pi/180), Lon: paris.Lon}
+        if got := a.Residual(p); !near(got, tt.want, 1e-6) {
+            t.Errorf("residual at %v km = %v, want %v", tt.distance, got, tt.want)
+        }
+    }
+}
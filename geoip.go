@@ -0,0 +1,134 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "net"
+    "sort"
+
+    "triangula/geo"
+)
+
+// GeoLocator localise une IP à partir d'une source externe (base GeoIP,
+// service web). label décrit la position trouvée (ville, pays) pour
+// l'affichage. Une implémentation qui détient des ressources peut aussi
+// satisfaire io.Closer.
+type GeoLocator interface {
+    Locate(ip string) (lat, lon float64, label string, err error)
+}
+
+// errNotLocatable : l'IP est privée ou réservée, aucune source ne peut la
+// localiser
+var errNotLocatable = errors.New("private or reserved address")
+
+// Au-delà de cette distance, GeoIP et triangulation sont considérées divergentes
+const geoIPAgreementKm = 300.0
+
+// geoLocators associe un nom de --compare à la fabrique de son localisateur,
+// appelée seulement si ce nom est choisi.
+var geoLocators = map[string]func() (GeoLocator, error){}
+
+// registerGeoLocator rend un localisateur sélectionnable par --compare=name.
+func registerGeoLocator(name string, open func() (GeoLocator, error)) {
+    if _, dup := geoLocators[name]; dup {
+        panic("geo locator registered twice: " + name)
+    }
+    geoLocators[name] = open
+}
+
+// openGeoLocator ouvre le localisateur enregistré sous name.
+func openGeoLocator(name string) (GeoLocator, error) {
+    open, ok := geoLocators[name]
+    if !ok {
+        return nil, fmt.Errorf("unknown source %q", name)
+    }
+    return open()
+}
+
+// geoLocatorNames retourne les noms enregistrés, triés.
+func geoLocatorNames() []string {
+    names := make([]string, 0, len(geoLocators))
+    for name := range geoLocators {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// comparisonSource est un localisateur et le nom affiché de sa source.
+type comparisonSource struct {
+    Name    string
+    Locator GeoLocator
+}
+
+// placeLabel joint la ville et le pays connus d'une position.
+func placeLabel(city, country string) string {
+    switch {
+    case city == "":
+        return country
+    case country == "":
+        return city
+    }
+    return city + ", " + country
+}
+
+// Comparaisons omises sans échec (GeoComparison.Skipped)
+const (
+    comparisonNotLocatable = "not_locatable" // adresse privée ou réservée
+    comparisonRateLimited  = "rate_limited"  // quota du service épuisé
+)
+
+// GeoComparison est la position de la cible selon une source externe et son
+// écart (km) à chaque méthode de triangulation, dans l'ordre de
+// Estimates.Locations.
+type GeoComparison struct {
+    Source      string    `json:"source"`
+    Label       string    `json:"label,omitempty"`
+    Location    *Location `json:"location,omitempty"` // nil si la source n'a pas localisé la cible
+    DistancesKm []float64 `json:"distances_km,omitempty"`
+    Agree       bool      `json:"agree,omitempty"` // une méthode au moins à moins de geoIPAgreementKm
+    Skipped     string    `json:"skipped,omitempty"` // comparison* : omise, la source n'est pas en faute
+    Error       string    `json:"error,omitempty"`
+}
+
+// comparisonFailure retourne le message d'une comparaison sans position.
+func comparisonFailure(c GeoComparison) string {
+    switch c.Skipped {
+    case comparisonNotLocatable:
+        return tr("geoip.notLocatable")
+    case comparisonRateLimited:
+        return fmt.Sprintf(tr("geoip.rateLimited"), c.Source, c.Error)
+    }
+    return fmt.Sprintf(tr("geoip.error"), c.Source, c.Error)
+}
+
+// compareLocation localise la cible par la source et mesure l'écart aux
+// estimations. Une adresse privée ou réservée n'a pas de position
+// publique : la source n'est pas interrogée et errNotLocatable est retourné.
+// Ce cas et un quota épuisé (errRateLimited) sont notés dans Skipped.
+func compareLocation(source comparisonSource, target Target, estimates []Location) (GeoComparison, error) {
+    c := GeoComparison{Source: source.Name}
+    err := errNotLocatable
+    var lat, lon float64
+    if ip := net.ParseIP(target.IP); ip == nil || !(ip.IsPrivate() || ip.IsLoopback() || ip.IsLinkLocalUnicast()) {
+        lat, lon, c.Label, err = source.Locator.Locate(target.IP)
+    }
+    if err != nil {
+        c.Error = err.Error()
+        switch {
+        case errors.Is(err, errNotLocatable):
+            c.Skipped = comparisonNotLocatable
+        case errors.Is(err, errRateLimited):
+            c.Skipped = comparisonRateLimited
+        }
+        return c, err
+    }
+
+    c.Location = &Location{Lat: lat, Lon: lon}
+    for _, loc := range estimates {
+        d := geo.Distance(lat, lon, loc.Lat, loc.Lon)
+        c.DistancesKm = append(c.DistancesKm, d)
+        c.Agree = c.Agree || d <= geoIPAgreementKm
+    }
+    return c, nil
+}
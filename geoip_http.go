@@ -0,0 +1,131 @@
+package main
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+)
+
+// Service de géolocalisation HTTP de --compare=ip-api (gratuit, sans clé,
+// HTTP uniquement)
+const ipAPIURL = "http://ip-api.com/json/%s?fields=status,message,country,city,lat,lon"
+
+// Durée maximale d'une requête de géolocalisation HTTP
+const geoLocatorTimeout = 10 * time.Second
+
+// Attente par défaut après un refus pour dépassement de quota, quand le
+// service n'indique pas la durée restante
+const defaultRateLimitWait = time.Minute
+
+// errRateLimited : quota de requêtes du service épuisé
+var errRateLimited = errors.New("rate limit reached")
+
+func init() {
+    registerGeoLocator("ip-api", func() (GeoLocator, error) {
+        return newHTTPGeoLocator(ipAPIURL), nil
+    })
+}
+
+// httpGeoLocator interroge un service web au format d'ip-api.com : URL
+// contient un %s remplacé par l'IP, la réponse est un objet JSON portant
+// status ("success" ou "fail"), message, lat, lon, city et country. Les
+// réponses sont gardées en cache par IP pour la durée de l'exécution. Le
+// quota du service est respecté : quand ses en-têtes X-Rl (requêtes
+// restantes) et X-Ttl (secondes avant réinitialisation) l'indiquent épuisé,
+// ou après un refus 429, aucune requête n'est envoyée avant la
+// réinitialisation et errRateLimited est retourné.
+type httpGeoLocator struct {
+    URL    string
+    Client *http.Client
+
+    mu           sync.Mutex
+    cache        map[string]httpLocation
+    blockedUntil time.Time
+}
+
+type httpLocation struct {
+    Lat, Lon float64
+    Label    string
+    Err      error // errNotLocatable ou refus du service, lui aussi gardé
+}
+
+func newHTTPGeoLocator(url string) *httpGeoLocator {
+    return &httpGeoLocator{
+        URL:    url,
+        Client: &http.Client{Timeout: geoLocatorTimeout},
+        cache:  make(map[string]httpLocation),
+    }
+}
+
+func (h *httpGeoLocator) Locate(ip string) (float64, float64, string, error) {
+    h.mu.Lock()
+    defer h.mu.Unlock()
+    if loc, ok := h.cache[ip]; ok {
+        return loc.Lat, loc.Lon, loc.Label, loc.Err
+    }
+    if wait := time.Until(h.blockedUntil); wait > 0 {
+        return 0, 0, "", fmt.Errorf("%w (retry in %v)", errRateLimited, wait.Round(time.Second))
+    }
+
+    loc, err := h.fetch(ip)
+    if err != nil {
+        return 0, 0, "", err
+    }
+    h.cache[ip] = loc
+    return loc.Lat, loc.Lon, loc.Label, loc.Err
+}
+
+// fetch interroge le service. Une erreur retournée (réseau, quota) n'est
+// pas mise en cache ; un refus du service pour cette IP l'est, dans loc.Err.
+func (h *httpGeoLocator) fetch(ip string) (httpLocation, error) {
+    resp, err := h.Client.Get(fmt.Sprintf(h.URL, url.PathEscape(ip)))
+    if err != nil {
+        return httpLocation{}, err
+    }
+    defer resp.Body.Close()
+
+    remaining, errRemaining := strconv.Atoi(resp.Header.Get("X-Rl"))
+    ttl, errTTL := strconv.Atoi(resp.Header.Get("X-Ttl"))
+    reset := defaultRateLimitWait
+    if errTTL == nil {
+        reset = time.Duration(ttl) * time.Second
+    }
+    if resp.StatusCode == http.StatusTooManyRequests {
+        h.blockedUntil = time.Now().Add(reset)
+        return httpLocation{}, fmt.Errorf("%w (retry in %v)", errRateLimited, reset)
+    }
+    if errRemaining == nil && remaining == 0 {
+        h.blockedUntil = time.Now().Add(reset)
+    }
+    if resp.StatusCode != http.StatusOK {
+        return httpLocation{}, fmt.Errorf("%s", resp.Status)
+    }
+
+    var body struct {
+        Status  string  `json:"status"`
+        Message string  `json:"message"`
+        Lat     float64 `json:"lat"`
+        Lon     float64 `json:"lon"`
+        City    string  `json:"city"`
+        Country string  `json:"country"`
+    }
+    if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+        return httpLocation{}, err
+    }
+    switch {
+    case body.Status == "success":
+        return httpLocation{Lat: body.Lat, Lon: body.Lon, Label: placeLabel(body.City, body.Country)}, nil
+    case strings.Contains(body.Message, "private range"), strings.Contains(body.Message, "reserved range"):
+        return httpLocation{Err: errNotLocatable}, nil
+    case body.Message == "":
+        return httpLocation{Err: errors.New("lookup failed")}, nil
+    }
+    return httpLocation{Err: errors.New(body.Message)}, nil
+}
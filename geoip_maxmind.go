@@ -0,0 +1,39 @@
+//go:build geoip
+
+package main
+
+import (
+    "fmt"
+    "net"
+
+    "github.com/oschwald/geoip2-golang"
+)
+
+// maxmindGeoIP lit une base GeoLite2-City / GeoIP2-City (.mmdb).
+type maxmindGeoIP struct {
+    db *geoip2.Reader
+}
+
+func openGeoIP(path string) (GeoLocator, error) {
+    db, err := geoip2.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    return &maxmindGeoIP{db: db}, nil
+}
+
+func (m *maxmindGeoIP) Locate(ip string) (float64, float64, string, error) {
+    parsed := net.ParseIP(ip)
+    if parsed == nil {
+        return 0, 0, "", fmt.Errorf("invalid IP %q", ip)
+    }
+    rec, err := m.db.City(parsed)
+    if err != nil {
+        return 0, 0, "", err
+    }
+    return rec.Location.Latitude, rec.Location.Longitude, placeLabel(rec.City.Names["en"], rec.Country.Names["en"]), nil
+}
+
+func (m *maxmindGeoIP) Close() error {
+    return m.db.Close()
+}
@@ -0,0 +1,9 @@
+//go:build !geoip
+
+package main
+
+import "errors"
+
+func openGeoIP(path string) (GeoLocator, error) {
+    return nil, errors.New("GeoIP support not compiled in (rebuild with -tags geoip)")
+}
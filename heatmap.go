@@ -0,0 +1,99 @@
+package main
+
+import (
+    "bufio"
+    "fmt"
+    "log/slog"
+    "math"
+    "os"
+
+    "triangula/geo"
+)
+
+const (
+    defaultHeatmapRadiusKm = 2000.0
+    defaultHeatmapStep     = 0.5 // degrés
+)
+
+// HeatCell est une position candidate de la grille, notée par le résidu RMS
+// (km) de l'objectif des moindres carrés : plus il est bas, mieux la
+// position explique les distances mesurées.
+type HeatCell struct {
+    Lat   float64
+    Lon   float64
+    Score float64
+}
+
+// heatmapGrid évalue l'objectif du solveur sur une grille lat/lon de pas
+// step (degrés) couvrant radiusKm autour de center.
+func heatmapGrid(points []geo.Anchor, center Location, radiusKm, step float64) []HeatCell {
+    kmPerDeg := geo.EarthRadius * math.Pi / 180
+    latSpan := radiusKm / kmPerDeg
+    lonSpan := 180.0
+    if cosLat := math.Cos(center.Lat * math.Pi / 180); cosLat > 1e-6 {
+        lonSpan = math.Min(180, latSpan/cosLat)
+    }
+
+    var cells []HeatCell
+    for lat := math.Max(-90, center.Lat-latSpan); lat <= math.Min(90, center.Lat+latSpan); lat += step {
+        for dLon := -lonSpan; dLon <= lonSpan; dLon += step {
+            p := Location{Lat: lat, Lon: normalizeLon(center.Lon + dLon)}
+            cells = append(cells, HeatCell{Lat: p.Lat, Lon: p.Lon, Score: geo.RMSResidual(points, p)})
+        }
+    }
+    return cells
+}
+
+// gridExport regroupe les paramètres de la grille d'ajustement et ses
+// fichiers de sortie (--heatmap, --region).
+type gridExport struct {
+    HeatmapPath string
+    RegionPath  string
+    RadiusKm    float64
+    Step        float64
+    ThresholdKm float64
+    Vantage     *Location // ajouté à RegionPath s'il est connu
+
+    HeatmapCells int // cellules écrites dans HeatmapPath
+}
+
+// exportGrid évalue la grille autour de l'estimation par moindres carrés,
+// écrit les fichiers demandés et retourne la région probable.
+func exportGrid(est Estimates, opts Options, g *gridExport) (Region, bool) {
+    cells := heatmapGrid(anchors(est.Solved[:est.MultilatServers], opts), est.LeastSquares, g.RadiusKm, g.Step)
+
+    if g.HeatmapPath != "" {
+        if err := writeHeatmap(g.HeatmapPath, cells); err != nil {
+            slog.Error("cannot write heatmap", "path", g.HeatmapPath, "error", err)
+            g.HeatmapPath = ""
+        } else {
+            g.HeatmapCells = len(cells)
+        }
+    }
+
+    region, ok := regionFromGrid(cells, est.LeastSquares, g.ThresholdKm)
+    if ok && g.RegionPath != "" {
+        if err := writeRegionGeoJSON(g.RegionPath, region, g.Vantage); err != nil {
+            slog.Error("cannot write region", "path", g.RegionPath, "error", err)
+        }
+    }
+    return region, ok
+}
+
+// writeHeatmap écrit la grille en CSV (lat,lon,score).
+func writeHeatmap(path string, cells []HeatCell) error {
+    f, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+    w := bufio.NewWriter(f)
+    fmt.Fprintln(w, "lat,lon,score")
+    for _, c := range cells {
+        fmt.Fprintf(w, "%.4f,%.4f,%.1f\n", c.Lat, c.Lon, c.Score)
+    }
+    if err := w.Flush(); err != nil {
+        f.Close()
+        return err
+    }
+    return f.Close()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+    "database/sql"
+    "fmt"
+    "io"
+    "log/slog"
+    "time"
+
+    "triangula/geo"
+
+    _ "modernc.org/sqlite"
+)
+
+// historySchema crée les tables d'historique : une ligne par exécution dans
+// runs, une ligne par serveur mesuré dans run_servers.
+const historySchema = `
+CREATE TABLE IF NOT EXISTS runs (
+    id            INTEGER PRIMARY KEY AUTOINCREMENT,
+    timestamp     TEXT    NOT NULL,
+    target        TEXT    NOT NULL,
+    ip            TEXT    NOT NULL,
+    target_rtt_ns INTEGER NOT NULL,
+    lat           REAL,
+    lon           REAL,
+    radius_km     REAL
+);
+CREATE INDEX IF NOT EXISTS runs_target ON runs (target, timestamp);
+CREATE TABLE IF NOT EXISTS run_servers (
+    run_id    INTEGER NOT NULL REFERENCES runs (id),
+    server    TEXT    NOT NULL,
+    ip        TEXT    NOT NULL,
+    rtt_ns    INTEGER,
+    jitter_ns INTEGER,
+    error     TEXT
+);
+`
+
+// openHistory ouvre (ou crée) la base d'historique SQLite.
+func openHistory(path string) (*sql.DB, error) {
+    db, err := sql.Open("sqlite", path)
+    if err != nil {
+        return nil, err
+    }
+    if _, err := db.Exec(historySchema); err != nil {
+        db.Close()
+        return nil, fmt.Errorf("%s: %v", path, err)
+    }
+    return db, nil
+}
+
+// recordRun ajoute une exécution et ses mesures par serveur. est est nil si
+// la triangulation a échoué (position NULL).
+func recordRun(db *sql.DB, at time.Time, target Target, measurements []Measurement, est *Estimates, radiusKm float64) error {
+    tx, err := db.Begin()
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    var lat, lon, radius sql.NullFloat64
+    if est != nil {
+        lat = sql.NullFloat64{Float64: est.LeastSquares.Lat, Valid: true}
+        lon = sql.NullFloat64{Float64: est.LeastSquares.Lon, Valid: true}
+        radius = sql.NullFloat64{Float64: radiusKm, Valid: true}
+    }
+    res, err := tx.Exec(`INSERT INTO runs (timestamp, target, ip, target_rtt_ns, lat, lon, radius_km)
+        VALUES (?, ?, ?, ?, ?, ?, ?)`,
+        at.UTC().Format(time.RFC3339), target.Input, target.IP, int64(target.RTT), lat, lon, radius)
+    if err != nil {
+        return err
+    }
+    runID, err := res.LastInsertId()
+    if err != nil {
+        return err
+    }
+
+    stmt, err := tx.Prepare(`INSERT INTO run_servers (run_id, server, ip, rtt_ns, jitter_ns, error)
+        VALUES (?, ?, ?, ?, ?, ?)`)
+    if err != nil {
+        return err
+    }
+    defer stmt.Close()
+    for _, m := range measurements {
+        var rtt, jitter sql.NullInt64
+        var errText sql.NullString
+        if m.Error != "" {
+            errText = sql.NullString{String: m.Error, Valid: true}
+        } else {
+            rtt = sql.NullInt64{Int64: int64(m.RTT), Valid: true}
+            jitter = sql.NullInt64{Int64: int64(m.Jitter), Valid: true}
+        }
+        if _, err := stmt.Exec(runID, m.Server.Name, m.Server.IP, rtt, jitter, errText); err != nil {
+            return err
+        }
+    }
+    return tx.Commit()
+}
+
+// printHistory affiche l'évolution de la position estimée d'une cible
+// (saisie ou IP), avec le déplacement depuis l'exécution précédente.
+func printHistory(w io.Writer, db *sql.DB, target string) error {
+    rows, err := db.Query(`SELECT timestamp, ip, target_rtt_ns, lat, lon, radius_km FROM runs
+        WHERE target = ? OR ip = ? ORDER BY timestamp, id`, target, target)
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+
+    fmt.Fprintf(w, tr("history.title"), target)
+    var prev *Location
+    count := 0
+    for rows.Next() {
+        var at, ip string
+        var rtt int64
+        var lat, lon, radius sql.NullFloat64
+        if err := rows.Scan(&at, &ip, &rtt, &lat, &lon, &radius); err != nil {
+            return err
+        }
+        count++
+
+        if !lat.Valid || !lon.Valid {
+            fmt.Fprintf(w, tr("history.rowNone"), at, ip, time.Duration(rtt))
+            continue
+        }
+        moved := "-"
+        if prev != nil {
+            moved = formatDistance(geo.Distance(prev.Lat, prev.Lon, lat.Float64, lon.Float64))
+        }
+        fmt.Fprintf(w, tr("history.row"), at, ip, time.Duration(rtt),
+            lat.Float64, lon.Float64, formatDistance(radius.Float64), moved)
+        prev = &Location{Lat: lat.Float64, Lon: lon.Float64}
+    }
+    if err := rows.Err(); err != nil {
+        return err
+    }
+    if count == 0 {
+        fmt.Fprintln(w, tr("history.empty"))
+    }
+    return nil
+}
+
+// saveHistory enregistre l'exécution dans la base --db ; les erreurs sont
+// journalisées sans interrompre le rapport.
+func saveHistory(path string, target Target, measurements []Measurement, results []Result, est *Estimates) {
+    db, err := openHistory(path)
+    if err != nil {
+        slog.Error("cannot open history database", "path", path, "error", err)
+        return
+    }
+    defer db.Close()
+    if err := recordRun(db, time.Now(), target, measurements, est, assessCoherence(results).Precision); err != nil {
+        slog.Error("cannot record run", "path", path, "error", err)
+    }
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+    "time"
+)
+
+// Seuils de l'heuristique hébergement / accès résidentiel. Une cible en
+// centre de données répond presque aussi vite qu'un serveur de référence
+// voisin (delta minimal faible) et de façon stable (gigue faible) ; une
+// cible résidentielle ajoute la latence et la gigue du dernier kilomètre
+// (DSL, câble, Wi-Fi, mobile).
+const (
+    hostingMaxDelta      = 2 * time.Millisecond
+    residentialMinDelta  = 10 * time.Millisecond
+    hostingMaxJitter     = time.Millisecond
+    residentialMinJitter = 3 * time.Millisecond
+)
+
+// HostingGuess est le verdict de l'heuristique : Hosting si la cible
+// ressemble à un hôte en centre de données, avec la confiance du verdict
+// (similarity*) et les deux indices utilisés.
+type HostingGuess struct {
+    Hosting    bool          `json:"hosting"`
+    Confidence string        `json:"confidence"`
+    MinDelta   time.Duration `json:"min_delta_ns"`
+    Jitter     time.Duration `json:"jitter_ns"`
+}
+
+// guessHosting vote avec deux indices : le plus petit delta entre la cible
+// et un serveur de référence, et la gigue de la cible. Chacun penche vers
+// l'hébergement, l'accès résidentiel ou reste neutre entre ses seuils. Deux
+// votes concordants donnent une confiance élevée, un seul une confiance
+// moyenne ; sans vote net, la cible n'est pas dite hébergée (confiance
+// faible). false si la gigue de la cible est inconnue (session ancienne,
+// RTT repris du cache) ou sans résultat.
+func guessHosting(target Target, results []Result) (HostingGuess, bool) {
+    if len(results) == 0 || target.Jitter <= 0 {
+        return HostingGuess{}, false
+    }
+    guess := HostingGuess{MinDelta: results[0].Delta, Jitter: target.Jitter}
+    for _, r := range results {
+        if r.Delta < guess.MinDelta {
+            guess.MinDelta = r.Delta
+        }
+    }
+
+    score := vote(guess.MinDelta, hostingMaxDelta, residentialMinDelta) +
+        vote(guess.Jitter, hostingMaxJitter, residentialMinJitter)
+    guess.Hosting = score > 0
+    switch score {
+    case 2, -2:
+        guess.Confidence = similarityHigh
+    case 1, -1:
+        guess.Confidence = similarityMedium
+    default:
+        guess.Confidence = similarityLow
+    }
+    return guess, true
+}
+
+// vote retourne 1 si v <= low, -1 si v >= high, 0 entre les deux.
+func vote(v, low, high time.Duration) int {
+    switch {
+    case v <= low:
+        return 1
+    case v >= high:
+        return -1
+    }
+    return 0
+}
+
+// displayHosting affiche le verdict de l'heuristique d'hébergement.
+func displayHosting(target Target, results []Result) {
+    guess, ok := guessHosting(target, results)
+    if !ok {
+        return
+    }
+    answer := tr("hosting.no")
+    if guess.Hosting {
+        answer = tr("hosting.yes")
+    }
+
+    fmt.Println("\n" + strings.Repeat("=", 80))
+    fmt.Println(tr("hosting.title"))
+    fmt.Println(strings.Repeat("=", 80))
+    fmt.Printf(tr("hosting.verdict"), answer, tr("similarity."+guess.Confidence))
+    fmt.Printf(tr("hosting.signals"), guess.MinDelta, guess.Jitter)
+    fmt.Println(tr("hosting.caveat"))
+}
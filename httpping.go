@@ -0,0 +1,94 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "net"
+    "net/http"
+    "net/http/httptrace"
+    "strconv"
+    "sync/atomic"
+    "time"
+)
+
+const (
+    // Port de la sonde http quand la base n'en précise pas
+    defaultHTTPPort = 80
+    // Attente maximale de la réponse à une requête
+    httpPingRequestTimeout = 3 * time.Second
+)
+
+// httpMeasurer mesure le délai entre l'envoi d'une requête HEAD et le
+// premier octet de la réponse, sur une connexion réutilisée d'une requête à
+// l'autre : seule la première paie l'établissement TCP, qui n'est pas compté.
+// Tout statut HTTP vaut réponse. Le délai inclut le traitement de la requête
+// par le serveur, négligeable sur un frontal web.
+type httpMeasurer struct {
+    Port     int
+    Source   string             // adresse source ; vide : route par défaut
+    Sampling sequentialSampling // arrêt anticipé ; désactivé par défaut
+}
+
+func (m httpMeasurer) Measure(ctx context.Context, ip string, count int) (PingStats, error) {
+    dialer := net.Dialer{Timeout: tcpDialTimeout}
+    if m.Source != "" {
+        dialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(m.Source)}
+    }
+    transport := &http.Transport{DialContext: dialer.DialContext, MaxIdleConnsPerHost: 1, DisableCompression: true}
+    defer transport.CloseIdleConnections()
+    client := &http.Client{
+        Transport: transport,
+        Timeout:   httpPingRequestTimeout,
+        CheckRedirect: func(*http.Request, []*http.Request) error {
+            return http.ErrUseLastResponse
+        },
+    }
+    url := "http://" + net.JoinHostPort(ip, strconv.Itoa(m.Port)) + "/"
+
+    var samples []time.Duration
+    var lastErr error
+    sent := 0
+    for ; sent < count && !m.Sampling.done(samples); sent++ {
+        rtt, err := httpRoundTrip(ctx, client, url)
+        if ctx.Err() != nil {
+            return PingStats{}, ctx.Err()
+        }
+        if err != nil {
+            lastErr = err
+            continue
+        }
+        samples = append(samples, rtt)
+    }
+
+    if len(samples) == 0 {
+        return PingStats{}, fmt.Errorf("%s (http/%d): %v", tr("ping.noReply"), m.Port, lastErr)
+    }
+    return statsFromSamples(samples, sent), nil
+}
+
+// httpRoundTrip envoie une requête HEAD et retourne le délai entre la fin de
+// son écriture et le premier octet de la réponse. Les deux instants sont
+// notés par les goroutines d'écriture et de lecture du transport.
+func httpRoundTrip(ctx context.Context, client *http.Client, url string) (time.Duration, error) {
+    start := time.Now()
+    var wrote, first atomic.Int64
+    trace := &httptrace.ClientTrace{
+        WroteRequest:         func(httptrace.WroteRequestInfo) { wrote.Store(int64(time.Since(start))) },
+        GotFirstResponseByte: func() { first.Store(int64(time.Since(start))) },
+    }
+    req, err := http.NewRequestWithContext(httptrace.WithClientTrace(ctx, trace), http.MethodHead, url, nil)
+    if err != nil {
+        return 0, err
+    }
+    resp, err := client.Do(req)
+    if err != nil {
+        return 0, err
+    }
+    io.Copy(io.Discard, resp.Body)
+    resp.Body.Close()
+    if wrote.Load() == 0 || first.Load() < wrote.Load() {
+        return 0, fmt.Errorf("http %s: incomplete request trace", url)
+    }
+    return time.Duration(first.Load() - wrote.Load()), nil
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "os"
+    "os/signal"
+    "sync"
+    "sync/atomic"
+)
+
+// interruptContext dérive de parent un contexte annulé au premier SIGINT :
+// les mesures en cours s'arrêtent et le rapport est produit avec ce qui a
+// été collecté. Un second SIGINT force la sortie. interrupted indique si le
+// signal a été reçu ; stop rétablit le comportement par défaut du signal et
+// peut être appelée plusieurs fois.
+func interruptContext(parent context.Context) (ctx context.Context, interrupted func() bool, stop func()) {
+    ctx, cancel := context.WithCancel(parent)
+    signals := make(chan os.Signal, 2)
+    signal.Notify(signals, os.Interrupt)
+
+    var received atomic.Bool
+    done := make(chan struct{})
+    go func() {
+        select {
+        case <-signals:
+        case <-done:
+            return
+        }
+        received.Store(true)
+        slog.Warn("interrupted, finishing with the measurements collected so far")
+        fmt.Fprintln(os.Stderr, tr("interrupt.first"))
+        cancel()
+
+        select {
+        case <-signals:
+            // Sortie forcée : le programme peut être bloqué hors de run,
+            // l'erreur ne peut pas remonter jusqu'à main
+            os.Exit(exitInterrupted)
+        case <-done:
+        }
+    }()
+
+    var once sync.Once
+    stop = func() {
+        once.Do(func() {
+            signal.Stop(signals)
+            close(done)
+            cancel()
+        })
+    }
+    return ctx, received.Load, stop
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+)
+
+// Sous-commande de listing de la base (équivalent de --list)
+const listServersCommand = "list-servers"
+
+// ServerListing est une entrée de --list : le serveur tel que chargé et ses
+// indicateurs.
+type ServerListing struct {
+    Name     string  `json:"name"`
+    IP       string  `json:"ip"`
+    Country  string  `json:"country"`
+    City     string  `json:"city"`
+    Lat      float64 `json:"lat"`
+    Lon      float64 `json:"lon"`
+    Provider string  `json:"provider,omitempty"`
+    Probe    string  `json:"probe,omitempty"`
+    Anycast  bool    `json:"anycast"` // pas d'emplacement unique (voir isAnycast)
+    Global   bool    `json:"global"`  // entrée "Global" de la base
+}
+
+func newServerListing(s Server) ServerListing {
+    return ServerListing{
+        Name:     s.Name,
+        IP:       s.IP,
+        Country:  s.Country,
+        City:     s.City,
+        Lat:      s.Lat,
+        Lon:      s.Lon,
+        Provider: s.Provider,
+        Probe:    s.Probe,
+        Anycast:  isAnycast(s),
+        Global:   s.Country == "Global",
+    }
+}
+
+// listFlags résume les indicateurs d'un serveur pour les tableaux.
+func listFlags(l ServerListing) string {
+    switch {
+    case l.Global:
+        return "global"
+    case l.Anycast:
+        return "anycast"
+    }
+    return "-"
+}
+
+// listServers écrit la base de serveurs dans le format demandé (--output).
+func listServers(w io.Writer, servers []Server, format string) error {
+    listings := make([]ServerListing, len(servers))
+    for i, s := range servers {
+        listings[i] = newServerListing(s)
+    }
+
+    switch format {
+    case outputJSON:
+        enc := json.NewEncoder(w)
+        enc.SetIndent("", "  ")
+        return enc.Encode(listings)
+    case outputMarkdown:
+        fmt.Fprintln(w, tr("list.mdHeader"))
+        fmt.Fprintln(w, "|---|---|---|---|---:|---:|---|")
+        for _, l := range listings {
+            fmt.Fprintf(w, "| %s | %s | %s | %s | %.4f | %.4f | %s |\n", mdEscape(l.Name), l.IP,
+                mdEscape(l.Country), mdEscape(l.City), l.Lat, l.Lon, listFlags(l))
+        }
+        fmt.Fprintln(w)
+    default:
+        fmt.Fprintln(w, tr("list.header"))
+        for _, l := range listings {
+            fmt.Fprintf(w, "%-24s %-16s %-15s %-15s %9.4f %9.4f  %s\n",
+                l.Name, l.IP, l.Country, l.City, l.Lat, l.Lon, listFlags(l))
+        }
+    }
+    fmt.Fprintf(w, tr("list.total"), len(listings))
+    return nil
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+    "fmt"
+    "log/slog"
+    "os"
+)
+
+// setupLogger configure le logger slog par défaut pour les messages de
+// diagnostic (sur stderr). Le rapport final reste sur stdout.
+func setupLogger(level, format string) error {
+    var lvl slog.Level
+    if err := lvl.UnmarshalText([]byte(level)); err != nil {
+        return fmt.Errorf("invalid log level %q", level)
+    }
+
+    opts := &slog.HandlerOptions{Level: lvl}
+    var handler slog.Handler
+    switch format {
+    case "text":
+        handler = slog.NewTextHandler(os.Stderr, opts)
+    case "json":
+        handler = slog.NewJSONHandler(os.Stderr, opts)
+    default:
+        return fmt.Errorf("invalid log format %q (expected text or json)", format)
+    }
+
+    slog.SetDefault(slog.New(handler))
+    return nil
+}
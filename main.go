@@ -2,127 +2,142 @@ package main
 
 import (
     "bufio"
+    "context"
+    "errors"
+    "flag"
     "fmt"
+    "io"
+    "log/slog"
     "math"
+    "math/rand"
     "os"
+    "os/signal"
     "sort"
     "strings"
-    "sync"
     "time"
 
     "github.com/go-ping/ping"
+
+    "triangula/geo"
 )
 
 type Server struct {
-    Name    string
-    IP      string
-    Country string
-    City    string
-    Lat     float64
-    Lon     float64
-    AvgRTT  time.Duration
+    Name     string        `json:"name"`
+    IP       string        `json:"ip"`
+    Country  string        `json:"country"`
+    City     string        `json:"city"`
+    Lat      float64       `json:"lat"`
+    Lon      float64       `json:"lon"`
+    Provider string        `json:"provider,omitempty"` // opérateur, clé des baselines par fournisseur
+    Probe    string        `json:"probe,omitempty"`    // sonde de mesure (voir parseProbe) ; vide : ICMP
+    AvgRTT   time.Duration `json:"avg_rtt_ns"`
+    Jitter   time.Duration `json:"jitter_ns,omitempty"` // écart-type des pings
+    Loss     float64       `json:"loss,omitempty"`      // fraction des pings perdus
+
+    // MeasuredAt date la mesure d'AvgRTT, Jitter et Loss depuis ce poste ;
+    // zéro si le serveur n'a pas répondu ou n'a jamais été mesuré. Voir fresh.
+    MeasuredAt time.Time `json:"-"`
 }
 
+// Distance est l'estimation ponctuelle utilisée par les méthodes 1 et 2 :
+// modèle(|RTT serveur - RTT cible|). Ce n'est qu'une borne inférieure de la
+// distance cible-serveur (inégalité triangulaire depuis le poste de mesure),
+// dont MaxDistance est la borne supérieure (voir README, « Modèle de
+// distance »).
 type Result struct {
-    Server   Server
-    Delta    time.Duration
-    Distance float64 
+    Server        Server        `json:"server"`
+    Delta         time.Duration `json:"delta_ns"`
+    Distance      float64       `json:"distance_km"`
+    DistanceError float64       `json:"distance_error_km"` // demi-largeur de modèle(delta ± gigue)
+    MaxDistance   float64       `json:"max_distance_km"`   // modèle(RTT serveur + RTT cible)
 }
 
-type Location struct {
-    Lat float64
-    Lon float64
+// Location est une position estimée (voir le paquet geo).
+type Location = geo.Location
+
+// version est injectée à la compilation (-ldflags "-X main.version=...")
+var version = "dev"
+
+func AvgPing(ctx context.Context, ip string, count int, timeout time.Duration) (time.Duration, error) {
+    stats, err := Ping(ctx, ip, count, timeout)
+    return stats.Avg, err
 }
 
-const (
-    speedOfLight = 299792.458 
-    fiberSpeed   = speedOfLight * 0.67 
-    earthRadius  = 6371.0 
-)
+// Ping envoie count requêtes ICMP et retourne les statistiques de RTT.
+// count et timeout doivent être strictement positifs.
+func Ping(ctx context.Context, ip string, count int, timeout time.Duration) (PingStats, error) {
+    return icmpMeasurer{Timeout: timeout}.Measure(ctx, ip, count)
+}
 
-func AvgPing(ip string, count int) (time.Duration, error) {
+// Measure est Ping avec la taille et l'adresse source du Measurer.
+func (m icmpMeasurer) Measure(ctx context.Context, ip string, count int) (PingStats, error) {
+    if count <= 0 {
+        return PingStats{}, fmt.Errorf("ping %s: count must be positive, got %d", ip, count)
+    }
+    if m.Timeout <= 0 {
+        return PingStats{}, fmt.Errorf("ping %s: timeout must be positive, got %v", ip, m.Timeout)
+    }
     pinger, err := ping.NewPinger(ip)
     if err != nil {
-        return 0, err
+        return PingStats{}, err
     }
 
-    pinger.SetPrivileged(true)
+    privileged := configurePinger(pinger)
     pinger.Count = count
-    pinger.Timeout = 10 * time.Second
+    pinger.Timeout = m.Timeout
+    if m.Size > 0 {
+        pinger.Size = m.Size
+    }
+    pinger.Source = m.Source
+    var samples []time.Duration
+    pinger.OnRecv = func(pkt *ping.Packet) {
+        samples = append(samples, pkt.Rtt)
+        if m.Sampling.done(samples) {
+            pinger.Stop()
+        }
+    }
+
+    // Arrêt anticipé du pinger à l'annulation du contexte
+    done := make(chan struct{})
+    defer close(done)
+    go func() {
+        select {
+        case <-ctx.Done():
+            pinger.Stop()
+        case <-done:
+        }
+    }()
 
     err = pinger.Run()
     if err != nil {
-        return 0, err
+        return PingStats{}, icmpPermissionError(err, privileged)
+    }
+    if ctx.Err() != nil {
+        return PingStats{}, ctx.Err()
     }
 
     stats := pinger.Statistics()
     if stats.PacketsRecv == 0 {
-        return 0, fmt.Errorf("aucune réponse")
+        return PingStats{}, fmt.Errorf("%s", tr("ping.noReply"))
     }
 
-    return stats.AvgRtt, nil
-}
-
-func distance(lat1, lon1, lat2, lon2 float64) float64 {
-    dLat := (lat2 - lat1) * math.Pi / 180
-    dLon := (lon2 - lon1) * math.Pi / 180
-
-    a := math.Sin(dLat/2)*math.Sin(dLat/2) +
-        math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*
-            math.Sin(dLon/2)*math.Sin(dLon/2)
-
-    c := 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
-    return earthRadius * c
-}
-
-func rttToDistance(rtt time.Duration) float64 {
-    seconds := rtt.Seconds()
-    // Division par 2 car RTT = aller-retour
-    return (seconds * fiberSpeed) / 2
-}
-
-func geoToCartesian(lat, lon float64) (x, y, z float64) {
-    latRad := lat * math.Pi / 180
-    lonRad := lon * math.Pi / 180
-
-    x = earthRadius * math.Cos(latRad) * math.Cos(lonRad)
-    y = earthRadius * math.Cos(latRad) * math.Sin(lonRad)
-    z = earthRadius * math.Sin(latRad)
-    return
-}
-
-func cartesianToGeo(x, y, z float64) (lat, lon float64) {
-    lon = math.Atan2(y, x) * 180 / math.Pi
-    hyp := math.Sqrt(x*x + y*y)
-    lat = math.Atan2(z, hyp) * 180 / math.Pi
-    return
+    return PingStats{
+        Avg:      stats.AvgRtt,
+        StdDev:   stats.StdDevRtt,
+        Sent:     stats.PacketsSent,
+        Received: stats.PacketsRecv,
+    }, nil
 }
 
-func trilaterate(s1, s2, s3 Server, d1, d2, d3 float64) Location {
-    x1, y1, z1 := geoToCartesian(s1.Lat, s1.Lon)
-    x2, y2, z2 := geoToCartesian(s2.Lat, s2.Lon)
-    x3, y3, z3 := geoToCartesian(s3.Lat, s3.Lon)
-
-    w1 := 1.0 / (d1 + 1.0) // +1 pour éviter division par 0
-    w2 := 1.0 / (d2 + 1.0)
-    w3 := 1.0 / (d3 + 1.0)
-
-    totalWeight := w1 + w2 + w3
-
-    xEst := (x1*w1 + x2*w2 + x3*w3) / totalWeight
-    yEst := (y1*w1 + y2*w2 + y3*w3) / totalWeight
-    zEst := (z1*w1 + z2*w2 + z3*w3) / totalWeight
-
-    norm := math.Sqrt(xEst*xEst + yEst*yEst + zEst*zEst)
-    xEst = xEst / norm * earthRadius
-    yEst = yEst / norm * earthRadius
-    zEst = zEst / norm * earthRadius
-
-    lat, lon := cartesianToGeo(xEst, yEst, zEst)
-
-    return Location{Lat: lat, Lon: lon}
+// serverLocation retourne la position géographique d'un serveur.
+func serverLocation(s Server) Location {
+    return Location{Lat: s.Lat, Lon: s.Lon}
 }
 
+// multilateralTriangulation retourne le barycentre des numServers meilleurs
+// serveurs, pondéré par 1/(delta+1) (voir geo.Centroid). Si le barycentre
+// est au centre de la Terre (serveurs antipodaux), la position du meilleur
+// serveur est retournée.
 func multilateralTriangulation(results []Result, numServers int) Location {
     if len(results) < 3 {
         return Location{Lat: 0, Lon: 0}
@@ -132,537 +147,1066 @@ func multilateralTriangulation(results []Result, numServers int) Location {
         numServers = len(results)
     }
 
-    var totalLat, totalLon, totalWeight float64
-
-    for i := 0; i < numServers; i++ {
+    points := make([]Location, numServers)
+    weights := make([]float64, numServers)
+    for i := range points {
+        points[i] = serverLocation(results[i].Server)
         // Poids inversement proportionnel au delta
-        weight := 1.0 / (float64(results[i].Delta.Milliseconds()) + 1.0)
-        
-        totalLat += results[i].Server.Lat * weight
-        totalLon += results[i].Server.Lon * weight
-        totalWeight += weight
+        weights[i] = 1.0 / (float64(results[i].Delta.Milliseconds()) + 1.0)
     }
-
-    return Location{
-        Lat: totalLat / totalWeight,
-        Lon: totalLon / totalWeight,
+    if loc, ok := geo.Centroid(points, weights); ok {
+        return loc
     }
+    return serverLocation(results[0].Server)
 }
 
-func getServerDatabase() []Server {
+// embeddedServerDatabase retourne la base intégrée au binaire, remplacée
+// par celle d'update-db quand elle existe (voir getServerDatabase).
+func embeddedServerDatabase() []Server {
     return []Server{
         // === EUROPE ===
         
         // FRANCE (8 serveurs)
-        {"Cloudflare", "1.1.1.1", "France", "Paris", 48.8566, 2.3522, 0},
-        {"Google DNS", "216.58.213.195", "France", "Paris", 48.8566, 2.3522, 0},
-        {"OVH", "54.36.0.1", "France", "Paris", 48.8566, 2.3522, 0},
-        {"Scaleway", "51.15.0.1", "France", "Paris", 48.8566, 2.3522, 0},
-        {"Online", "62.210.0.1", "France", "Paris", 48.8566, 2.3522, 0},
-        {"Free", "212.27.48.10", "France", "Paris", 48.8566, 2.3522, 0},
-        {"Orange", "80.10.246.2", "France", "Paris", 48.8566, 2.3522, 0},
-        {"OVH-Strasbourg", "51.68.0.1", "France", "Strasbourg", 48.5734, 7.7521, 0},
+        {Name: "Cloudflare", IP: "1.1.1.1", Country: "France", City: "Paris", Lat: 48.8566, Lon: 2.3522, Provider: "Cloudflare", Probe: "dns"},
+        {Name: "Google DNS", IP: "216.58.213.195", Country: "France", City: "Paris", Lat: 48.8566, Lon: 2.3522, Provider: "Google"},
+        {Name: "OVH", IP: "54.36.0.1", Country: "France", City: "Paris", Lat: 48.8566, Lon: 2.3522, Provider: "OVH"},
+        {Name: "Scaleway", IP: "51.15.0.1", Country: "France", City: "Paris", Lat: 48.8566, Lon: 2.3522, Provider: "Scaleway"},
+        {Name: "Online", IP: "62.210.0.1", Country: "France", City: "Paris", Lat: 48.8566, Lon: 2.3522, Provider: "Online"},
+        {Name: "Free", IP: "212.27.48.10", Country: "France", City: "Paris", Lat: 48.8566, Lon: 2.3522, Provider: "Free"},
+        {Name: "Orange", IP: "80.10.246.2", Country: "France", City: "Paris", Lat: 48.8566, Lon: 2.3522, Provider: "Orange"},
+        {Name: "OVH-Strasbourg", IP: "51.68.0.1", Country: "France", City: "Strasbourg", Lat: 48.5734, Lon: 7.7521, Provider: "OVH"},
 
         // ROYAUME-UNI (7 serveurs)
-        {"Google-UK", "8.8.4.4", "UK", "London", 51.5074, -0.1278, 0},
-        {"Cloudflare-UK", "1.0.0.1", "UK", "London", 51.5074, -0.1278, 0},
-        {"BBC", "212.58.244.67", "UK", "London", 51.5074, -0.1278, 0},
-        {"DigitalOcean", "178.62.0.1", "UK", "London", 51.5074, -0.1278, 0},
-        {"Linode", "178.79.128.1", "UK", "London", 51.5074, -0.1278, 0},
-        {"Vodafone", "194.73.73.73", "UK", "London", 51.5074, -0.1278, 0},
-        {"BT", "194.72.9.38", "UK", "London", 51.5074, -0.1278, 0},
+        {Name: "Google-UK", IP: "8.8.4.4", Country: "UK", City: "London", Lat: 51.5074, Lon: -0.1278, Provider: "Google", Probe: "dns"},
+        {Name: "Cloudflare-UK", IP: "1.0.0.1", Country: "UK", City: "London", Lat: 51.5074, Lon: -0.1278, Provider: "Cloudflare", Probe: "dns"},
+        {Name: "BBC", IP: "212.58.244.67", Country: "UK", City: "London", Lat: 51.5074, Lon: -0.1278, Provider: "BBC"},
+        {Name: "DigitalOcean", IP: "178.62.0.1", Country: "UK", City: "London", Lat: 51.5074, Lon: -0.1278, Provider: "DigitalOcean"},
+        {Name: "Linode", IP: "178.79.128.1", Country: "UK", City: "London", Lat: 51.5074, Lon: -0.1278, Provider: "Linode"},
+        {Name: "Vodafone", IP: "194.73.73.73", Country: "UK", City: "London", Lat: 51.5074, Lon: -0.1278, Provider: "Vodafone"},
+        {Name: "BT", IP: "194.72.9.38", Country: "UK", City: "London", Lat: 51.5074, Lon: -0.1278, Provider: "BT"},
 
         // ALLEMAGNE (8 serveurs)
-        {"Hetzner", "213.133.100.1", "Germany", "Frankfurt", 50.1109, 8.6821, 0},
-        {"AWS-DE", "52.59.0.1", "Germany", "Frankfurt", 50.1109, 8.6821, 0},
-        {"Google-DE", "216.58.207.67", "Germany", "Frankfurt", 50.1109, 8.6821, 0},
-        {"Contabo", "213.136.64.1", "Germany", "Frankfurt", 50.1109, 8.6821, 0},
-        {"IONOS", "217.160.0.1", "Germany", "Frankfurt", 50.1109, 8.6821, 0},
-        {"Telekom-DE", "217.0.43.145", "Germany", "Frankfurt", 50.1109, 8.6821, 0},
-        {"Hetzner-Nuremberg", "213.239.192.1", "Germany", "Nuremberg", 49.4521, 11.0767, 0},
-        {"1&1", "217.237.148.22", "Germany", "Karlsruhe", 49.0069, 8.4037, 0},
+        {Name: "Hetzner", IP: "213.133.100.1", Country: "Germany", City: "Frankfurt", Lat: 50.1109, Lon: 8.6821, Provider: "Hetzner"},
+        {Name: "AWS-DE", IP: "52.59.0.1", Country: "Germany", City: "Frankfurt", Lat: 50.1109, Lon: 8.6821, Provider: "AWS"},
+        {Name: "Google-DE", IP: "216.58.207.67", Country: "Germany", City: "Frankfurt", Lat: 50.1109, Lon: 8.6821, Provider: "Google"},
+        {Name: "Contabo", IP: "213.136.64.1", Country: "Germany", City: "Frankfurt", Lat: 50.1109, Lon: 8.6821, Provider: "Contabo"},
+        {Name: "IONOS", IP: "217.160.0.1", Country: "Germany", City: "Frankfurt", Lat: 50.1109, Lon: 8.6821, Provider: "IONOS"},
+        {Name: "Telekom-DE", IP: "217.0.43.145", Country: "Germany", City: "Frankfurt", Lat: 50.1109, Lon: 8.6821, Provider: "Telekom"},
+        {Name: "Hetzner-Nuremberg", IP: "213.239.192.1", Country: "Germany", City: "Nuremberg", Lat: 49.4521, Lon: 11.0767, Provider: "Hetzner"},
+        {Name: "1&1", IP: "217.237.148.22", Country: "Germany", City: "Karlsruhe", Lat: 49.0069, Lon: 8.4037, Provider: "IONOS"},
 
         // PAYS-BAS (6 serveurs)
-        {"Transip", "195.8.195.8", "Netherlands", "Amsterdam", 52.3676, 4.9041, 0},
-        {"LeaseWeb", "5.79.73.204", "Netherlands", "Amsterdam", 52.3676, 4.9041, 0},
-        {"Vultr-AMS", "108.61.0.1", "Netherlands", "Amsterdam", 52.3676, 4.9041, 0},
-        {"DigitalOcean-AMS", "188.166.0.1", "Netherlands", "Amsterdam", 52.3676, 4.9041, 0},
-        {"Google-NL", "216.58.211.3", "Netherlands", "Amsterdam", 52.3676, 4.9041, 0},
-        {"KPN", "195.121.1.34", "Netherlands", "Rotterdam", 51.9225, 4.4792, 0},
+        {Name: "Transip", IP: "195.8.195.8", Country: "Netherlands", City: "Amsterdam", Lat: 52.3676, Lon: 4.9041, Provider: "Transip"},
+        {Name: "LeaseWeb", IP: "5.79.73.204", Country: "Netherlands", City: "Amsterdam", Lat: 52.3676, Lon: 4.9041, Provider: "LeaseWeb"},
+        {Name: "Vultr-AMS", IP: "108.61.0.1", Country: "Netherlands", City: "Amsterdam", Lat: 52.3676, Lon: 4.9041, Provider: "Vultr"},
+        {Name: "DigitalOcean-AMS", IP: "188.166.0.1", Country: "Netherlands", City: "Amsterdam", Lat: 52.3676, Lon: 4.9041, Provider: "DigitalOcean"},
+        {Name: "Google-NL", IP: "216.58.211.3", Country: "Netherlands", City: "Amsterdam", Lat: 52.3676, Lon: 4.9041, Provider: "Google"},
+        {Name: "KPN", IP: "195.121.1.34", Country: "Netherlands", City: "Rotterdam", Lat: 51.9225, Lon: 4.4792, Provider: "KPN"},
 
         // ESPAGNE (5 serveurs)
-        {"Telefonica", "194.179.1.100", "Spain", "Madrid", 40.4168, -3.7038, 0},
-        {"Orange-ES", "62.36.225.150", "Spain", "Madrid", 40.4168, -3.7038, 0},
-        {"Vodafone-ES", "193.110.157.151", "Spain", "Madrid", 40.4168, -3.7038, 0},
-        {"AWS-ES", "15.161.0.1", "Spain", "Madrid", 40.4168, -3.7038, 0},
-        {"Google-ES", "216.58.215.67", "Spain", "Barcelona", 41.3851, 2.1734, 0},
+        {Name: "Telefonica", IP: "194.179.1.100", Country: "Spain", City: "Madrid", Lat: 40.4168, Lon: -3.7038, Provider: "Telefonica"},
+        {Name: "Orange-ES", IP: "62.36.225.150", Country: "Spain", City: "Madrid", Lat: 40.4168, Lon: -3.7038, Provider: "Orange"},
+        {Name: "Vodafone-ES", IP: "193.110.157.151", Country: "Spain", City: "Madrid", Lat: 40.4168, Lon: -3.7038, Provider: "Vodafone"},
+        {Name: "AWS-ES", IP: "15.161.0.1", Country: "Spain", City: "Madrid", Lat: 40.4168, Lon: -3.7038, Provider: "AWS"},
+        {Name: "Google-ES", IP: "216.58.215.67", Country: "Spain", City: "Barcelona", Lat: 41.3851, Lon: 2.1734, Provider: "Google"},
 
         // ITALIE (5 serveurs)
-        {"Aruba", "62.149.128.2", "Italy", "Milan", 45.4642, 9.1900, 0},
-        {"Telecom-IT", "151.99.125.1", "Italy", "Milan", 45.4642, 9.1900, 0},
-        {"Fastweb", "195.110.124.188", "Italy", "Milan", 45.4642, 9.1900, 0},
-        {"Google-IT", "216.58.213.3", "Italy", "Milan", 45.4642, 9.1900, 0},
-        {"AWS-IT", "15.160.0.1", "Italy", "Milan", 45.4642, 9.1900, 0},
+        {Name: "Aruba", IP: "62.149.128.2", Country: "Italy", City: "Milan", Lat: 45.4642, Lon: 9.1900, Provider: "Aruba"},
+        {Name: "Telecom-IT", IP: "151.99.125.1", Country: "Italy", City: "Milan", Lat: 45.4642, Lon: 9.1900, Provider: "Telecom Italia"},
+        {Name: "Fastweb", IP: "195.110.124.188", Country: "Italy", City: "Milan", Lat: 45.4642, Lon: 9.1900, Provider: "Fastweb"},
+        {Name: "Google-IT", IP: "216.58.213.3", Country: "Italy", City: "Milan", Lat: 45.4642, Lon: 9.1900, Provider: "Google"},
+        {Name: "AWS-IT", IP: "15.160.0.1", Country: "Italy", City: "Milan", Lat: 45.4642, Lon: 9.1900, Provider: "AWS"},
 
         // SUISSE (5 serveurs)
-        {"Swisscom", "195.186.1.111", "Switzerland", "Zurich", 47.3769, 8.5417, 0},
-        {"Init7", "77.109.128.2", "Switzerland", "Zurich", 47.3769, 8.5417, 0},
-        {"Google-CH", "216.58.215.3", "Switzerland", "Zurich", 47.3769, 8.5417, 0},
-        {"Cloudflare-CH", "162.158.0.1", "Switzerland", "Geneva", 46.2044, 6.1432, 0},
-        {"Green", "80.74.140.10", "Switzerland", "Zurich", 47.3769, 8.5417, 0},
+        {Name: "Swisscom", IP: "195.186.1.111", Country: "Switzerland", City: "Zurich", Lat: 47.3769, Lon: 8.5417, Provider: "Swisscom"},
+        {Name: "Init7", IP: "77.109.128.2", Country: "Switzerland", City: "Zurich", Lat: 47.3769, Lon: 8.5417, Provider: "Init7"},
+        {Name: "Google-CH", IP: "216.58.215.3", Country: "Switzerland", City: "Zurich", Lat: 47.3769, Lon: 8.5417, Provider: "Google"},
+        {Name: "Cloudflare-CH", IP: "162.158.0.1", Country: "Switzerland", City: "Geneva", Lat: 46.2044, Lon: 6.1432, Provider: "Cloudflare", Probe: "tcp:443"},
+        {Name: "Green", IP: "80.74.140.10", Country: "Switzerland", City: "Zurich", Lat: 47.3769, Lon: 8.5417, Provider: "Green"},
 
         // SUÈDE (5 serveurs)
-        {"Telia-SE", "62.20.66.66", "Sweden", "Stockholm", 59.3293, 18.0686, 0},
-        {"Bahnhof", "195.67.199.2", "Sweden", "Stockholm", 59.3293, 18.0686, 0},
-        {"Google-SE", "216.58.211.67", "Sweden", "Stockholm", 59.3293, 18.0686, 0},
-        {"AWS-SE", "13.48.0.1", "Sweden", "Stockholm", 59.3293, 18.0686, 0},
-        {"TeliaSonera", "213.242.116.19", "Sweden", "Stockholm", 59.3293, 18.0686, 0},
+        {Name: "Telia-SE", IP: "62.20.66.66", Country: "Sweden", City: "Stockholm", Lat: 59.3293, Lon: 18.0686, Provider: "Telia"},
+        {Name: "Bahnhof", IP: "195.67.199.2", Country: "Sweden", City: "Stockholm", Lat: 59.3293, Lon: 18.0686, Provider: "Bahnhof"},
+        {Name: "Google-SE", IP: "216.58.211.67", Country: "Sweden", City: "Stockholm", Lat: 59.3293, Lon: 18.0686, Provider: "Google"},
+        {Name: "AWS-SE", IP: "13.48.0.1", Country: "Sweden", City: "Stockholm", Lat: 59.3293, Lon: 18.0686, Provider: "AWS"},
+        {Name: "TeliaSonera", IP: "213.242.116.19", Country: "Sweden", City: "Stockholm", Lat: 59.3293, Lon: 18.0686, Provider: "TeliaSonera"},
 
         // POLOGNE (5 serveurs)
-        {"OVH-PL", "91.216.107.2", "Poland", "Warsaw", 52.2297, 21.0122, 0},
-        {"Google-PL", "216.58.215.195", "Poland", "Warsaw", 52.2297, 21.0122, 0},
-        {"Orange-PL", "80.55.240.10", "Poland", "Warsaw", 52.2297, 21.0122, 0},
-        {"T-Mobile-PL", "213.180.130.10", "Poland", "Warsaw", 52.2297, 21.0122, 0},
-        {"AWS-PL", "15.236.0.1", "Poland", "Warsaw", 52.2297, 21.0122, 0},
+        {Name: "OVH-PL", IP: "91.216.107.2", Country: "Poland", City: "Warsaw", Lat: 52.2297, Lon: 21.0122, Provider: "OVH"},
+        {Name: "Google-PL", IP: "216.58.215.195", Country: "Poland", City: "Warsaw", Lat: 52.2297, Lon: 21.0122, Provider: "Google"},
+        {Name: "Orange-PL", IP: "80.55.240.10", Country: "Poland", City: "Warsaw", Lat: 52.2297, Lon: 21.0122, Provider: "Orange"},
+        {Name: "T-Mobile-PL", IP: "213.180.130.10", Country: "Poland", City: "Warsaw", Lat: 52.2297, Lon: 21.0122, Provider: "T-Mobile"},
+        {Name: "AWS-PL", IP: "15.236.0.1", Country: "Poland", City: "Warsaw", Lat: 52.2297, Lon: 21.0122, Provider: "AWS"},
 
         // USA - EST (New York) (7 serveurs)
-        {"Google-NY", "142.250.185.46", "USA", "New York", 40.7128, -74.0060, 0},
-        {"DigitalOcean-NY", "192.241.128.1", "USA", "New York", 40.7128, -74.0060, 0},
-        {"Linode-Newark", "66.228.32.1", "USA", "Newark", 40.7357, -74.1724, 0},
-        {"Verizon-NY", "208.48.0.1", "USA", "New York", 40.7128, -74.0060, 0},
-        {"GTT-NY", "89.149.128.1", "USA", "New York", 40.7128, -74.0060, 0},
-        {"AWS-NY", "54.210.0.1", "USA", "New York", 40.7128, -74.0060, 0},
-        {"Hurricane-NY", "216.66.1.2", "USA", "New York", 40.7128, -74.0060, 0},
+        {Name: "Google-NY", IP: "142.250.185.46", Country: "USA", City: "New York", Lat: 40.7128, Lon: -74.0060, Provider: "Google"},
+        {Name: "DigitalOcean-NY", IP: "192.241.128.1", Country: "USA", City: "New York", Lat: 40.7128, Lon: -74.0060, Provider: "DigitalOcean"},
+        {Name: "Linode-Newark", IP: "66.228.32.1", Country: "USA", City: "Newark", Lat: 40.7357, Lon: -74.1724, Provider: "Linode"},
+        {Name: "Verizon-NY", IP: "208.48.0.1", Country: "USA", City: "New York", Lat: 40.7128, Lon: -74.0060, Provider: "Verizon"},
+        {Name: "GTT-NY", IP: "89.149.128.1", Country: "USA", City: "New York", Lat: 40.7128, Lon: -74.0060, Provider: "GTT"},
+        {Name: "AWS-NY", IP: "54.210.0.1", Country: "USA", City: "New York", Lat: 40.7128, Lon: -74.0060, Provider: "AWS"},
+        {Name: "Hurricane-NY", IP: "216.66.1.2", Country: "USA", City: "New York", Lat: 40.7128, Lon: -74.0060, Provider: "Hurricane"},
 
         // USA - OUEST (Californie) (7 serveurs)
-        {"Google-CA", "216.58.217.206", "USA", "Los Angeles", 34.0522, -118.2437, 0},
-        {"Cloudflare-SJ", "104.16.0.1", "USA", "San Jose", 37.3382, -121.8863, 0},
-        {"AWS-CA", "52.8.0.1", "USA", "San Francisco", 37.7749, -122.4194, 0},
-        {"DigitalOcean-SF", "159.65.0.1", "USA", "San Francisco", 37.7749, -122.4194, 0},
-        {"Linode-Fremont", "50.116.0.1", "USA", "Fremont", 37.5483, -121.9886, 0},
-        {"Hurricane-LA", "216.218.186.2", "USA", "Los Angeles", 34.0522, -118.2437, 0},
-        {"Cogent-LA", "38.142.0.1", "USA", "Los Angeles", 34.0522, -118.2437, 0},
+        {Name: "Google-CA", IP: "216.58.217.206", Country: "USA", City: "Los Angeles", Lat: 34.0522, Lon: -118.2437, Provider: "Google"},
+        {Name: "Cloudflare-SJ", IP: "104.16.0.1", Country: "USA", City: "San Jose", Lat: 37.3382, Lon: -121.8863, Provider: "Cloudflare", Probe: "tcp:443"},
+        {Name: "AWS-CA", IP: "52.8.0.1", Country: "USA", City: "San Francisco", Lat: 37.7749, Lon: -122.4194, Provider: "AWS"},
+        {Name: "DigitalOcean-SF", IP: "159.65.0.1", Country: "USA", City: "San Francisco", Lat: 37.7749, Lon: -122.4194, Provider: "DigitalOcean"},
+        {Name: "Linode-Fremont", IP: "50.116.0.1", Country: "USA", City: "Fremont", Lat: 37.5483, Lon: -121.9886, Provider: "Linode"},
+        {Name: "Hurricane-LA", IP: "216.218.186.2", Country: "USA", City: "Los Angeles", Lat: 34.0522, Lon: -118.2437, Provider: "Hurricane"},
+        {Name: "Cogent-LA", IP: "38.142.0.1", Country: "USA", City: "Los Angeles", Lat: 34.0522, Lon: -118.2437, Provider: "Cogent"},
 
         // USA - CENTRE (Chicago) (5 serveurs)
-        {"Vultr-Chicago", "207.246.64.1", "USA", "Chicago", 41.8781, -87.6298, 0},
-        {"DigitalOcean-CHI", "159.89.0.1", "USA", "Chicago", 41.8781, -87.6298, 0},
-        {"Google-CHI", "216.58.193.46", "USA", "Chicago", 41.8781, -87.6298, 0},
-        {"AWS-CHI", "3.128.0.1", "USA", "Chicago", 41.8781, -87.6298, 0},
-        {"Linode-Chicago", "45.79.0.1", "USA", "Chicago", 41.8781, -87.6298, 0},
+        {Name: "Vultr-Chicago", IP: "207.246.64.1", Country: "USA", City: "Chicago", Lat: 41.8781, Lon: -87.6298, Provider: "Vultr"},
+        {Name: "DigitalOcean-CHI", IP: "159.89.0.1", Country: "USA", City: "Chicago", Lat: 41.8781, Lon: -87.6298, Provider: "DigitalOcean"},
+        {Name: "Google-CHI", IP: "216.58.193.46", Country: "USA", City: "Chicago", Lat: 41.8781, Lon: -87.6298, Provider: "Google"},
+        {Name: "AWS-CHI", IP: "3.128.0.1", Country: "USA", City: "Chicago", Lat: 41.8781, Lon: -87.6298, Provider: "AWS"},
+        {Name: "Linode-Chicago", IP: "45.79.0.1", Country: "USA", City: "Chicago", Lat: 41.8781, Lon: -87.6298, Provider: "Linode"},
 
         // USA - SUD (Texas) (5 serveurs)
-        {"Google-TX", "216.58.195.46", "USA", "Dallas", 32.7767, -96.7970, 0},
-        {"Vultr-Dallas", "108.61.224.1", "USA", "Dallas", 32.7767, -96.7970, 0},
-        {"AWS-TX", "3.16.0.1", "USA", "Dallas", 32.7767, -96.7970, 0},
-        {"DigitalOcean-TX", "159.203.0.1", "USA", "Dallas", 32.7767, -96.7970, 0},
-        {"Hurricane-TX", "64.62.128.1", "USA", "Dallas", 32.7767, -96.7970, 0},
+        {Name: "Google-TX", IP: "216.58.195.46", Country: "USA", City: "Dallas", Lat: 32.7767, Lon: -96.7970, Provider: "Google"},
+        {Name: "Vultr-Dallas", IP: "108.61.224.1", Country: "USA", City: "Dallas", Lat: 32.7767, Lon: -96.7970, Provider: "Vultr"},
+        {Name: "AWS-TX", IP: "3.16.0.1", Country: "USA", City: "Dallas", Lat: 32.7767, Lon: -96.7970, Provider: "AWS"},
+        {Name: "DigitalOcean-TX", IP: "159.203.0.1", Country: "USA", City: "Dallas", Lat: 32.7767, Lon: -96.7970, Provider: "DigitalOcean"},
+        {Name: "Hurricane-TX", IP: "64.62.128.1", Country: "USA", City: "Dallas", Lat: 32.7767, Lon: -96.7970, Provider: "Hurricane"},
 
         // CANADA (6 serveurs)
-        {"OVH-CA", "51.222.0.1", "Canada", "Montreal", 45.5017, -73.5673, 0},
-        {"Google-CA", "216.58.193.67", "Canada", "Toronto", 43.6532, -79.3832, 0},
-        {"AWS-CA", "15.223.0.1", "Canada", "Montreal", 45.5017, -73.5673, 0},
-        {"DigitalOcean-TOR", "159.203.64.1", "Canada", "Toronto", 43.6532, -79.3832, 0},
-        {"Cloudflare-TOR", "104.16.128.1", "Canada", "Toronto", 43.6532, -79.3832, 0},
-        {"Bell-CA", "64.230.160.1", "Canada", "Montreal", 45.5017, -73.5673, 0},
+        {Name: "OVH-CA", IP: "51.222.0.1", Country: "Canada", City: "Montreal", Lat: 45.5017, Lon: -73.5673, Provider: "OVH"},
+        {Name: "Google-CA", IP: "216.58.193.67", Country: "Canada", City: "Toronto", Lat: 43.6532, Lon: -79.3832, Provider: "Google"},
+        {Name: "AWS-CA", IP: "15.223.0.1", Country: "Canada", City: "Montreal", Lat: 45.5017, Lon: -73.5673, Provider: "AWS"},
+        {Name: "DigitalOcean-TOR", IP: "159.203.64.1", Country: "Canada", City: "Toronto", Lat: 43.6532, Lon: -79.3832, Provider: "DigitalOcean"},
+        {Name: "Cloudflare-TOR", IP: "104.16.128.1", Country: "Canada", City: "Toronto", Lat: 43.6532, Lon: -79.3832, Provider: "Cloudflare", Probe: "tcp:443"},
+        {Name: "Bell-CA", IP: "64.230.160.1", Country: "Canada", City: "Montreal", Lat: 45.5017, Lon: -73.5673, Provider: "Bell"},
 
         // BRÉSIL (6 serveurs)
-        {"Google-BR", "216.58.222.67", "Brazil", "São Paulo", -23.5505, -46.6333, 0},
-        {"AWS-BR", "18.231.0.1", "Brazil", "São Paulo", -23.5505, -46.6333, 0},
-        {"Cloudflare-BR", "104.16.192.1", "Brazil", "São Paulo", -23.5505, -46.6333, 0},
-        {"DigitalOcean-BR", "159.89.192.1", "Brazil", "São Paulo", -23.5505, -46.6333, 0},
-        {"Locaweb", "200.234.224.2", "Brazil", "São Paulo", -23.5505, -46.6333, 0},
-        {"Vivo-BR", "200.142.0.1", "Brazil", "Rio de Janeiro", -22.9068, -43.1729, 0},
+        {Name: "Google-BR", IP: "216.58.222.67", Country: "Brazil", City: "São Paulo", Lat: -23.5505, Lon: -46.6333, Provider: "Google"},
+        {Name: "AWS-BR", IP: "18.231.0.1", Country: "Brazil", City: "São Paulo", Lat: -23.5505, Lon: -46.6333, Provider: "AWS"},
+        {Name: "Cloudflare-BR", IP: "104.16.192.1", Country: "Brazil", City: "São Paulo", Lat: -23.5505, Lon: -46.6333, Provider: "Cloudflare", Probe: "tcp:443"},
+        {Name: "DigitalOcean-BR", IP: "159.89.192.1", Country: "Brazil", City: "São Paulo", Lat: -23.5505, Lon: -46.6333, Provider: "DigitalOcean"},
+        {Name: "Locaweb", IP: "200.234.224.2", Country: "Brazil", City: "São Paulo", Lat: -23.5505, Lon: -46.6333, Provider: "Locaweb"},
+        {Name: "Vivo-BR", IP: "200.142.0.1", Country: "Brazil", City: "Rio de Janeiro", Lat: -22.9068, Lon: -43.1729, Provider: "Vivo"},
 
         // ARGENTINE (5 serveurs)
-        {"Google-AR", "216.58.222.195", "Argentina", "Buenos Aires", -34.6037, -58.3816, 0},
-        {"Telecom-AR", "200.51.211.11", "Argentina", "Buenos Aires", -34.6037, -58.3816, 0},
-        {"Claro-AR", "200.45.191.11", "Argentina", "Buenos Aires", -34.6037, -58.3816, 0},
-        {"Arsat", "200.61.47.1", "Argentina", "Buenos Aires", -34.6037, -58.3816, 0},
-        {"Fibertel", "200.115.100.2", "Argentina", "Buenos Aires", -34.6037, -58.3816, 0},
+        {Name: "Google-AR", IP: "216.58.222.195", Country: "Argentina", City: "Buenos Aires", Lat: -34.6037, Lon: -58.3816, Provider: "Google"},
+        {Name: "Telecom-AR", IP: "200.51.211.11", Country: "Argentina", City: "Buenos Aires", Lat: -34.6037, Lon: -58.3816, Provider: "Telecom Argentina"},
+        {Name: "Claro-AR", IP: "200.45.191.11", Country: "Argentina", City: "Buenos Aires", Lat: -34.6037, Lon: -58.3816, Provider: "Claro"},
+        {Name: "Arsat", IP: "200.61.47.1", Country: "Argentina", City: "Buenos Aires", Lat: -34.6037, Lon: -58.3816, Provider: "Arsat"},
+        {Name: "Fibertel", IP: "200.115.100.2", Country: "Argentina", City: "Buenos Aires", Lat: -34.6037, Lon: -58.3816, Provider: "Fibertel"},
 
         // CHILI (5 serveurs)
-        {"Google-CL", "216.58.222.3", "Chile", "Santiago", -33.4489, -70.6693, 0},
-        {"AWS-CL", "15.220.0.1", "Chile", "Santiago", -33.4489, -70.6693, 0},
-        {"Movistar-CL", "200.28.16.68", "Chile", "Santiago", -33.4489, -70.6693, 0},
-        {"VTR", "200.104.237.131", "Chile", "Santiago", -33.4489, -70.6693, 0},
-        {"Entel-CL", "200.73.97.18", "Chile", "Santiago", -33.4489, -70.6693, 0},
+        {Name: "Google-CL", IP: "216.58.222.3", Country: "Chile", City: "Santiago", Lat: -33.4489, Lon: -70.6693, Provider: "Google"},
+        {Name: "AWS-CL", IP: "15.220.0.1", Country: "Chile", City: "Santiago", Lat: -33.4489, Lon: -70.6693, Provider: "AWS"},
+        {Name: "Movistar-CL", IP: "200.28.16.68", Country: "Chile", City: "Santiago", Lat: -33.4489, Lon: -70.6693, Provider: "Movistar"},
+        {Name: "VTR", IP: "200.104.237.131", Country: "Chile", City: "Santiago", Lat: -33.4489, Lon: -70.6693, Provider: "VTR"},
+        {Name: "Entel-CL", IP: "200.73.97.18", Country: "Chile", City: "Santiago", Lat: -33.4489, Lon: -70.6693, Provider: "Entel"},
 
         // JAPON (7 serveurs)
-        {"Google-JP", "216.58.220.195", "Japan", "Tokyo", 35.6762, 139.6503, 0},
-        {"AWS-JP", "54.178.0.1", "Japan", "Tokyo", 35.6762, 139.6503, 0},
-        {"Linode-JP", "139.162.64.1", "Japan", "Tokyo", 35.6762, 139.6503, 0},
-        {"Sakura", "153.120.0.1", "Japan", "Tokyo", 35.6762, 139.6503, 0},
-        {"GMO", "157.7.0.1", "Japan", "Tokyo", 35.6762, 139.6503, 0},
-        {"NTT-JP", "129.250.0.1", "Japan", "Tokyo", 35.6762, 139.6503, 0},
-        {"Softbank", "221.113.192.1", "Japan", "Tokyo", 35.6762, 139.6503, 0},
+        {Name: "Google-JP", IP: "216.58.220.195", Country: "Japan", City: "Tokyo", Lat: 35.6762, Lon: 139.6503, Provider: "Google"},
+        {Name: "AWS-JP", IP: "54.178.0.1", Country: "Japan", City: "Tokyo", Lat: 35.6762, Lon: 139.6503, Provider: "AWS"},
+        {Name: "Linode-JP", IP: "139.162.64.1", Country: "Japan", City: "Tokyo", Lat: 35.6762, Lon: 139.6503, Provider: "Linode"},
+        {Name: "Sakura", IP: "153.120.0.1", Country: "Japan", City: "Tokyo", Lat: 35.6762, Lon: 139.6503, Provider: "Sakura"},
+        {Name: "GMO", IP: "157.7.0.1", Country: "Japan", City: "Tokyo", Lat: 35.6762, Lon: 139.6503, Provider: "GMO"},
+        {Name: "NTT-JP", IP: "129.250.0.1", Country: "Japan", City: "Tokyo", Lat: 35.6762, Lon: 139.6503, Provider: "NTT"},
+        {Name: "Softbank", IP: "221.113.192.1", Country: "Japan", City: "Tokyo", Lat: 35.6762, Lon: 139.6503, Provider: "Softbank"},
 
         // SINGAPOUR (6 serveurs)
-        {"Google-SG", "216.58.199.67", "Singapore", "Singapore", 1.3521, 103.8198, 0},
-        {"AWS-SG", "54.254.0.1", "Singapore", "Singapore", 1.3521, 103.8198, 0},
-        {"DigitalOcean-SG", "188.166.128.1", "Singapore", "Singapore", 1.3521, 103.8198, 0},
-        {"Linode-SG", "139.162.0.1", "Singapore", "Singapore", 1.3521, 103.8198, 0},
-        {"Vultr-SG", "45.32.0.1", "Singapore", "Singapore", 1.3521, 103.8198, 0},
-        {"Singtel", "165.21.0.1", "Singapore", "Singapore", 1.3521, 103.8198, 0},
+        {Name: "Google-SG", IP: "216.58.199.67", Country: "Singapore", City: "Singapore", Lat: 1.3521, Lon: 103.8198, Provider: "Google"},
+        {Name: "AWS-SG", IP: "54.254.0.1", Country: "Singapore", City: "Singapore", Lat: 1.3521, Lon: 103.8198, Provider: "AWS"},
+        {Name: "DigitalOcean-SG", IP: "188.166.128.1", Country: "Singapore", City: "Singapore", Lat: 1.3521, Lon: 103.8198, Provider: "DigitalOcean"},
+        {Name: "Linode-SG", IP: "139.162.0.1", Country: "Singapore", City: "Singapore", Lat: 1.3521, Lon: 103.8198, Provider: "Linode"},
+        {Name: "Vultr-SG", IP: "45.32.0.1", Country: "Singapore", City: "Singapore", Lat: 1.3521, Lon: 103.8198, Provider: "Vultr"},
+        {Name: "Singtel", IP: "165.21.0.1", Country: "Singapore", City: "Singapore", Lat: 1.3521, Lon: 103.8198, Provider: "Singtel"},
 
         // CORÉE DU SUD (5 serveurs)
-        {"Google-KR", "216.58.197.67", "South Korea", "Seoul", 37.5665, 126.9780, 0},
-        {"AWS-KR", "3.36.0.1", "South Korea", "Seoul", 37.5665, 126.9780, 0},
-        {"KT", "168.126.63.1", "South Korea", "Seoul", 37.5665, 126.9780, 0},
-        {"LG-U+", "164.124.101.2", "South Korea", "Seoul", 37.5665, 126.9780, 0},
-        {"SK-Telecom", "210.220.163.82", "South Korea", "Seoul", 37.5665, 126.9780, 0},
+        {Name: "Google-KR", IP: "216.58.197.67", Country: "South Korea", City: "Seoul", Lat: 37.5665, Lon: 126.9780, Provider: "Google"},
+        {Name: "AWS-KR", IP: "3.36.0.1", Country: "South Korea", City: "Seoul", Lat: 37.5665, Lon: 126.9780, Provider: "AWS"},
+        {Name: "KT", IP: "168.126.63.1", Country: "South Korea", City: "Seoul", Lat: 37.5665, Lon: 126.9780, Provider: "KT"},
+        {Name: "LG-U+", IP: "164.124.101.2", Country: "South Korea", City: "Seoul", Lat: 37.5665, Lon: 126.9780, Provider: "LG U+"},
+        {Name: "SK-Telecom", IP: "210.220.163.82", Country: "South Korea", City: "Seoul", Lat: 37.5665, Lon: 126.9780, Provider: "SK Telecom"},
 
         // INDE (6 serveurs)
-        {"Google-IN", "216.58.196.67", "India", "Mumbai", 19.0760, 72.8777, 0},
-        {"AWS-IN", "13.233.0.1", "India", "Mumbai", 19.0760, 72.8777, 0},
-        {"DigitalOcean-IN", "159.65.144.1", "India", "Bangalore", 12.9716, 77.5946, 0},
-        {"Cloudflare-IN", "104.16.224.1", "India", "Mumbai", 19.0760, 72.8777, 0},
-        {"Bharti", "182.74.0.1", "India", "Delhi", 28.7041, 77.1025, 0},
-        {"Reliance", "49.205.0.1", "India", "Mumbai", 19.0760, 72.8777, 0},
+        {Name: "Google-IN", IP: "216.58.196.67", Country: "India", City: "Mumbai", Lat: 19.0760, Lon: 72.8777, Provider: "Google"},
+        {Name: "AWS-IN", IP: "13.233.0.1", Country: "India", City: "Mumbai", Lat: 19.0760, Lon: 72.8777, Provider: "AWS"},
+        {Name: "DigitalOcean-IN", IP: "159.65.144.1", Country: "India", City: "Bangalore", Lat: 12.9716, Lon: 77.5946, Provider: "DigitalOcean"},
+        {Name: "Cloudflare-IN", IP: "104.16.224.1", Country: "India", City: "Mumbai", Lat: 19.0760, Lon: 72.8777, Provider: "Cloudflare", Probe: "tcp:443"},
+        {Name: "Bharti", IP: "182.74.0.1", Country: "India", City: "Delhi", Lat: 28.7041, Lon: 77.1025, Provider: "Bharti"},
+        {Name: "Reliance", IP: "49.205.0.1", Country: "India", City: "Mumbai", Lat: 19.0760, Lon: 72.8777, Provider: "Reliance"},
 
         // HONG KONG (5 serveurs)
-        {"Google-HK", "216.58.197.195", "Hong Kong", "Hong Kong", 22.3193, 114.1694, 0},
-        {"AWS-HK", "18.166.0.1", "Hong Kong", "Hong Kong", 22.3193, 114.1694, 0},
-        {"DigitalOcean-HK", "159.89.224.1", "Hong Kong", "Hong Kong", 22.3193, 114.1694, 0},
-        {"Cloudflare-HK", "104.16.64.1", "Hong Kong", "Hong Kong", 22.3193, 114.1694, 0},
-        {"PCCW", "202.45.128.1", "Hong Kong", "Hong Kong", 22.3193, 114.1694, 0},
+        {Name: "Google-HK", IP: "216.58.197.195", Country: "Hong Kong", City: "Hong Kong", Lat: 22.3193, Lon: 114.1694, Provider: "Google"},
+        {Name: "AWS-HK", IP: "18.166.0.1", Country: "Hong Kong", City: "Hong Kong", Lat: 22.3193, Lon: 114.1694, Provider: "AWS"},
+        {Name: "DigitalOcean-HK", IP: "159.89.224.1", Country: "Hong Kong", City: "Hong Kong", Lat: 22.3193, Lon: 114.1694, Provider: "DigitalOcean"},
+        {Name: "Cloudflare-HK", IP: "104.16.64.1", Country: "Hong Kong", City: "Hong Kong", Lat: 22.3193, Lon: 114.1694, Provider: "Cloudflare", Probe: "tcp:443"},
+        {Name: "PCCW", IP: "202.45.128.1", Country: "Hong Kong", City: "Hong Kong", Lat: 22.3193, Lon: 114.1694, Provider: "PCCW"},
 
         // AUSTRALIE (7 serveurs)
-        {"Google-AU", "216.58.203.67", "Australia", "Sydney", -33.8688, 151.2093, 0},
-        {"AWS-AU", "54.206.0.1", "Australia", "Sydney", -33.8688, 151.2093, 0},
-        {"DigitalOcean-AU", "159.65.128.1", "Australia", "Sydney", -33.8688, 151.2093, 0},
-        {"Linode-AU", "172.105.160.1", "Australia", "Sydney", -33.8688, 151.2093, 0},
-        {"Vultr-AU", "45.76.0.1", "Australia", "Sydney", -33.8688, 151.2093, 0},
-        {"Telstra", "203.50.0.1", "Australia", "Melbourne", -37.8136, 144.9631, 0},
-        {"Optus", "211.29.132.12", "Australia", "Sydney", -33.8688, 151.2093, 0},
+        {Name: "Google-AU", IP: "216.58.203.67", Country: "Australia", City: "Sydney", Lat: -33.8688, Lon: 151.2093, Provider: "Google"},
+        {Name: "AWS-AU", IP: "54.206.0.1", Country: "Australia", City: "Sydney", Lat: -33.8688, Lon: 151.2093, Provider: "AWS"},
+        {Name: "DigitalOcean-AU", IP: "159.65.128.1", Country: "Australia", City: "Sydney", Lat: -33.8688, Lon: 151.2093, Provider: "DigitalOcean"},
+        {Name: "Linode-AU", IP: "172.105.160.1", Country: "Australia", City: "Sydney", Lat: -33.8688, Lon: 151.2093, Provider: "Linode"},
+        {Name: "Vultr-AU", IP: "45.76.0.1", Country: "Australia", City: "Sydney", Lat: -33.8688, Lon: 151.2093, Provider: "Vultr"},
+        {Name: "Telstra", IP: "203.50.0.1", Country: "Australia", City: "Melbourne", Lat: -37.8136, Lon: 144.9631, Provider: "Telstra"},
+        {Name: "Optus", IP: "211.29.132.12", Country: "Australia", City: "Sydney", Lat: -33.8688, Lon: 151.2093, Provider: "Optus"},
 
         // NOUVELLE-ZÉLANDE (5 serveurs)
-        {"Google-NZ", "216.58.199.195", "New Zealand", "Auckland", -36.8485, 174.7633, 0},
-        {"AWS-NZ", "13.239.0.1", "New Zealand", "Auckland", -36.8485, 174.7633, 0},
-        {"Spark", "203.109.129.68", "New Zealand", "Auckland", -36.8485, 174.7633, 0},
-        {"Vodafone-NZ", "202.27.184.3", "New Zealand", "Auckland", -36.8485, 174.7633, 0},
-        {"2degrees", "203.167.251.1", "New Zealand", "Auckland", -36.8485, 174.7633, 0},
+        {Name: "Google-NZ", IP: "216.58.199.195", Country: "New Zealand", City: "Auckland", Lat: -36.8485, Lon: 174.7633, Provider: "Google"},
+        {Name: "AWS-NZ", IP: "13.239.0.1", Country: "New Zealand", City: "Auckland", Lat: -36.8485, Lon: 174.7633, Provider: "AWS"},
+        {Name: "Spark", IP: "203.109.129.68", Country: "New Zealand", City: "Auckland", Lat: -36.8485, Lon: 174.7633, Provider: "Spark"},
+        {Name: "Vodafone-NZ", IP: "202.27.184.3", Country: "New Zealand", City: "Auckland", Lat: -36.8485, Lon: 174.7633, Provider: "Vodafone"},
+        {Name: "2degrees", IP: "203.167.251.1", Country: "New Zealand", City: "Auckland", Lat: -36.8485, Lon: 174.7633, Provider: "2degrees"},
 
         // AFRIQUE DU SUD (6 serveurs)
-        {"Google-ZA", "216.58.223.67", "South Africa", "Johannesburg", -26.2041, 28.0473, 0},
-        {"AWS-ZA", "13.244.0.1", "South Africa", "Cape Town", -33.9249, 18.4241, 0},
-        {"Cloudflare-ZA", "104.17.0.1", "South Africa", "Johannesburg", -26.2041, 28.0473, 0},
-        {"Telkom", "196.25.1.1", "South Africa", "Johannesburg", -26.2041, 28.0473, 0},
-        {"MTN", "41.203.0.1", "South Africa", "Johannesburg", -26.2041, 28.0473, 0},
-        {"Vodacom", "196.207.40.165", "South Africa", "Johannesburg", -26.2041, 28.0473, 0},
+        {Name: "Google-ZA", IP: "216.58.223.67", Country: "South Africa", City: "Johannesburg", Lat: -26.2041, Lon: 28.0473, Provider: "Google"},
+        {Name: "AWS-ZA", IP: "13.244.0.1", Country: "South Africa", City: "Cape Town", Lat: -33.9249, Lon: 18.4241, Provider: "AWS"},
+        {Name: "Cloudflare-ZA", IP: "104.17.0.1", Country: "South Africa", City: "Johannesburg", Lat: -26.2041, Lon: 28.0473, Provider: "Cloudflare", Probe: "tcp:443"},
+        {Name: "Telkom", IP: "196.25.1.1", Country: "South Africa", City: "Johannesburg", Lat: -26.2041, Lon: 28.0473, Provider: "Telkom"},
+        {Name: "MTN", IP: "41.203.0.1", Country: "South Africa", City: "Johannesburg", Lat: -26.2041, Lon: 28.0473, Provider: "MTN"},
+        {Name: "Vodacom", IP: "196.207.40.165", Country: "South Africa", City: "Johannesburg", Lat: -26.2041, Lon: 28.0473, Provider: "Vodacom"},
 
         // ÉGYPTE (5 serveurs)
-        {"Google-EG", "216.58.214.195", "Egypt", "Cairo", 30.0444, 31.2357, 0},
-        {"Cloudflare-EG", "104.17.64.1", "Egypt", "Cairo", 30.0444, 31.2357, 0},
-        {"TE-Data", "196.219.0.1", "Egypt", "Cairo", 30.0444, 31.2357, 0},
-        {"Orange-EG", "41.128.0.1", "Egypt", "Cairo", 30.0444, 31.2357, 0},
-        {"Vodafone-EG", "41.32.0.1", "Egypt", "Cairo", 30.0444, 31.2357, 0},
+        {Name: "Google-EG", IP: "216.58.214.195", Country: "Egypt", City: "Cairo", Lat: 30.0444, Lon: 31.2357, Provider: "Google"},
+        {Name: "Cloudflare-EG", IP: "104.17.64.1", Country: "Egypt", City: "Cairo", Lat: 30.0444, Lon: 31.2357, Provider: "Cloudflare", Probe: "tcp:443"},
+        {Name: "TE-Data", IP: "196.219.0.1", Country: "Egypt", City: "Cairo", Lat: 30.0444, Lon: 31.2357, Provider: "TE Data"},
+        {Name: "Orange-EG", IP: "41.128.0.1", Country: "Egypt", City: "Cairo", Lat: 30.0444, Lon: 31.2357, Provider: "Orange"},
+        {Name: "Vodafone-EG", IP: "41.32.0.1", Country: "Egypt", City: "Cairo", Lat: 30.0444, Lon: 31.2357, Provider: "Vodafone"},
 
         // ÉMIRATS ARABES UNIS (5 serveurs)
-        {"Google-UAE", "216.58.214.67", "UAE", "Dubai", 25.2048, 55.2708, 0},
-        {"AWS-UAE", "3.29.0.1", "UAE", "Dubai", 25.2048, 55.2708, 0},
-        {"Cloudflare-UAE", "104.17.128.1", "UAE", "Dubai", 25.2048, 55.2708, 0},
-        {"Etisalat", "213.42.20.20", "UAE", "Dubai", 25.2048, 55.2708, 0},
-        {"Du", "195.229.241.222", "UAE", "Dubai", 25.2048, 55.2708, 0},
+        {Name: "Google-UAE", IP: "216.58.214.67", Country: "UAE", City: "Dubai", Lat: 25.2048, Lon: 55.2708, Provider: "Google"},
+        {Name: "AWS-UAE", IP: "3.29.0.1", Country: "UAE", City: "Dubai", Lat: 25.2048, Lon: 55.2708, Provider: "AWS"},
+        {Name: "Cloudflare-UAE", IP: "104.17.128.1", Country: "UAE", City: "Dubai", Lat: 25.2048, Lon: 55.2708, Provider: "Cloudflare", Probe: "tcp:443"},
+        {Name: "Etisalat", IP: "213.42.20.20", Country: "UAE", City: "Dubai", Lat: 25.2048, Lon: 55.2708, Provider: "Etisalat"},
+        {Name: "Du", IP: "195.229.241.222", Country: "UAE", City: "Dubai", Lat: 25.2048, Lon: 55.2708, Provider: "Du"},
 
         // ISRAËL (5 serveurs)
-        {"Google-IL", "216.58.212.195", "Israel", "Tel Aviv", 32.0853, 34.7818, 0},
-        {"AWS-IL", "3.120.0.1", "Israel", "Tel Aviv", 32.0853, 34.7818, 0},
-        {"Bezeq", "80.178.0.1", "Israel", "Tel Aviv", 32.0853, 34.7818, 0},
-        {"Cellcom", "62.90.0.1", "Israel", "Tel Aviv", 32.0853, 34.7818, 0},
-        {"HOT", "79.178.0.1", "Israel", "Tel Aviv", 32.0853, 34.7818, 0},
+        {Name: "Google-IL", IP: "216.58.212.195", Country: "Israel", City: "Tel Aviv", Lat: 32.0853, Lon: 34.7818, Provider: "Google"},
+        {Name: "AWS-IL", IP: "3.120.0.1", Country: "Israel", City: "Tel Aviv", Lat: 32.0853, Lon: 34.7818, Provider: "AWS"},
+        {Name: "Bezeq", IP: "80.178.0.1", Country: "Israel", City: "Tel Aviv", Lat: 32.0853, Lon: 34.7818, Provider: "Bezeq"},
+        {Name: "Cellcom", IP: "62.90.0.1", Country: "Israel", City: "Tel Aviv", Lat: 32.0853, Lon: 34.7818, Provider: "Cellcom"},
+        {Name: "HOT", IP: "79.178.0.1", Country: "Israel", City: "Tel Aviv", Lat: 32.0853, Lon: 34.7818, Provider: "HOT"},
 
         // DNS PUBLICS GLOBAUX (référence)
-        {"Google-DNS-1", "8.8.8.8", "Global", "USA", 37.4056, -122.0775, 0},
-        {"Google-DNS-2", "8.8.4.4", "Global", "USA", 37.4056, -122.0775, 0},
-        {"Quad9", "9.9.9.9", "Global", "USA", 37.7749, -122.4194, 0},
-        {"OpenDNS-1", "208.67.222.222", "Global", "USA", 37.7749, -122.4194, 0},
-        {"OpenDNS-2", "208.67.220.220", "Global", "USA", 37.7749, -122.4194, 0},
+        {Name: "Google-DNS-1", IP: "8.8.8.8", Country: "Global", City: "USA", Lat: 37.4056, Lon: -122.0775, Provider: "Google", Probe: "dns"},
+        {Name: "Google-DNS-2", IP: "8.8.4.4", Country: "Global", City: "USA", Lat: 37.4056, Lon: -122.0775, Provider: "Google", Probe: "dns"},
+        {Name: "Quad9", IP: "9.9.9.9", Country: "Global", City: "USA", Lat: 37.7749, Lon: -122.4194, Provider: "Quad9", Probe: "dns"},
+        {Name: "OpenDNS-1", IP: "208.67.222.222", Country: "Global", City: "USA", Lat: 37.7749, Lon: -122.4194, Provider: "OpenDNS", Probe: "dns"},
+        {Name: "OpenDNS-2", IP: "208.67.220.220", Country: "Global", City: "USA", Lat: 37.7749, Lon: -122.4194, Provider: "OpenDNS", Probe: "dns"},
     }
 }
 
 
-func getUserInput() string {
-    reader := bufio.NewReader(os.Stdin)
-    
-    fmt.Println("\n" + strings.Repeat("=", 63))
-    fmt.Println("       SYSTEME DE TRIANGULATION IP PAR LATENCE")
-    fmt.Println(strings.Repeat("=", 63))
-    
-    fmt.Print("\nEntrez l'IP ou domaine cible : ")
-    
-    input, _ := reader.ReadString('\n')
-    input = strings.TrimSpace(input)
-    
+// getUserInput retourne la cible : arg (argument de la ligne de commande)
+// s'il est fourni, sinon la première ligne de l'entrée standard. La bannière
+// et l'invite ne sont affichées sur out (stderr quand stdout porte un
+// rapport structuré) que si l'entrée standard est un terminal : une entrée
+// redirigée est lue sans rien écrire. Une entrée vide est une erreur.
+func getUserInput(out io.Writer, arg string) (string, int, error) {
+    input := arg
     if input == "" {
-        fmt.Println("\nErreur: Aucune IP fournie")
-        os.Exit(1)
+        if isTerminal(os.Stdin) {
+            fmt.Fprintln(out, "\n"+strings.Repeat("=", 63))
+            fmt.Fprintln(out, tr("banner.title"))
+            fmt.Fprintln(out, strings.Repeat("=", 63))
+            fmt.Fprint(out, tr("input.prompt"))
+        }
+        input, _ = bufio.NewReader(os.Stdin).ReadString('\n')
     }
-    
-    return input
+    return normalizeTarget(input)
 }
 
-func displayResults(results []Result, targetIP string, targetRTT time.Duration) {
+func displayResults(results []Result, target Target, summary SweepSummary, rank string, proximity proximityScale, vantage *Location) {
     fmt.Println("\n" + strings.Repeat("=", 80))
-    fmt.Printf("RESULTATS DE L'ANALYSE - Cible: %s (RTT: %v)\n", targetIP, targetRTT)
+    fmt.Printf(tr("results.title"), target.Label(), target.RTT)
+    if target.Cached {
+        fmt.Println(tr("results.cached"))
+    }
+    if summary.Interrupted {
+        fmt.Printf(tr("results.interrupted"), summary.Measured)
+    } else if summary.Partial {
+        fmt.Printf(tr("results.partial"), summary.Measured, summary.Total)
+    }
+    if len(target.PTR) > 0 {
+        fmt.Printf(tr("results.ptr"), strings.Join(target.PTR, ", "))
+    } else {
+        fmt.Println(tr("results.noPTR"))
+    }
+    if target.ASN != nil {
+        fmt.Printf(tr("results.asn"), target.ASN)
+    }
     fmt.Println(strings.Repeat("=", 80))
 
-    fmt.Println("\nTOP 15 SERVEURS LES PLUS PROCHES (par similarité de latence)")
+    fmt.Println(tr("results.top"))
     fmt.Println(strings.Repeat("-", 80))
     
-    for i := 0; i < 15 && i < len(results); i++ {
+    for i := 0; i < reportTopServers && i < len(results); i++ {
         r := results[i]
         
-        // Indicateur de proximité
-        proximity := "[+++]"
-        if r.Delta > 50*time.Millisecond {
-            proximity = "[++ ]"
-        }
-        if r.Delta > 100*time.Millisecond {
-            proximity = "[+  ]"
-        }
-        if r.Delta > 200*time.Millisecond {
-            proximity = "[   ]"
-        }
-        
         fmt.Printf("%s %2d) %-20s | %-15s | %-12s\n",
-            proximity, i+1, r.Server.Name, r.Server.Country, r.Server.City)
-        fmt.Printf("        RTT: %6v | Delta: %6v | Distance estimée: %.0f km\n",
-            r.Server.AvgRTT, r.Delta, r.Distance)
+            proximity.indicator(r.rankScore(rank)), i+1, r.Server.Name, r.Server.Country, r.Server.City)
+        fmt.Printf(tr("results.rowStats"),
+            r.Server.AvgRTT, r.Server.Jitter, r.Delta, formatDistance(r.Distance), formatDistance(r.DistanceError))
+        if vantage != nil {
+            fmt.Printf(tr("results.fromVantage"), formatDistance(distanceFrom(*vantage, r.Server)))
+        }
         fmt.Println()
     }
+    if vantage != nil {
+        displayFarNearestServers(results, *vantage)
+    }
 }
 
-func displayTriangulation(results []Result) {
-    if len(results) < 3 {
-        fmt.Println("\nErreur: Pas assez de serveurs pour la triangulation")
+// displayTraceroute affiche les distances affinées par traceroute.
+func displayTraceroute(refinements []TracerouteRefinement) {
+    fmt.Println("\n" + strings.Repeat("=", 80))
+    fmt.Println(tr("trace.title"))
+    fmt.Println(strings.Repeat("=", 80))
+    if len(refinements) == 0 {
+        fmt.Println(tr("trace.none"))
+        return
+    }
+    for _, r := range refinements {
+        fmt.Printf(tr("trace.line"), r.Server, r.CommonHop, r.HopRTT, r.Hops,
+            formatDistance(r.OldDistance), formatDistance(r.NewDistance))
+    }
+}
+
+// displaySimilarity affiche la région estimée par similarité de latence.
+func displaySimilarity(results []Result) {
+    match, ok := matchSimilarity(results)
+    if !ok {
         return
     }
 
     fmt.Println("\n" + strings.Repeat("=", 80))
-    fmt.Println("TRIANGULATION MATHEMATIQUE")
+    fmt.Println(tr("sim.title"))
+    fmt.Println(strings.Repeat("=", 80))
+    fmt.Printf(tr("sim.servers"), strings.Join(match.Servers, ", "))
+    fmt.Printf(tr("sim.region"), match.Country, match.CountryShare*100, match.Region.Lat, match.Region.Lon)
+    fmt.Printf(tr("sim.spread"), formatDistance(match.SpreadKm))
+    fmt.Printf(tr("sim.confidence"), tr("similarity."+match.Confidence))
+}
+
+// displayTriangulation affiche les méthodes de triangulation et retourne
+// leurs estimations (false si la triangulation est impossible). Avec 1 ou 2
+// serveurs, seul un message indique que la triangulation est ignorée : le
+// tableau des résultats et la similarité de latence restent valables.
+func displayTriangulation(results []Result, servers []Server, opts Options, zones *timezoneIndex) (Estimates, bool) {
+    est, err := estimatePositions(results, opts)
+    if errors.Is(err, errNotEnoughServers) {
+        fmt.Printf(tr("tri.tooFew"), len(results))
+        fmt.Println(tr("tri.tooFewHint"))
+        return Estimates{}, false
+    }
+    if errors.Is(err, errFewLocations) {
+        fmt.Printf(tr("tri.fewLocations"), distinctLocations(results), opts.MinLocations)
+        return Estimates{}, false
+    }
+    if errors.Is(err, errLowDiversity) {
+        fmt.Printf(tr("tri.lowDiversity"), formatDistance(geometricSpread(results)), formatDistance(minGeometricSpreadKm))
+        return Estimates{}, false
+    }
+    if err != nil {
+        fmt.Println(tr("tri.notEnough"))
+        return Estimates{}, false
+    }
+
+    fmt.Println("\n" + strings.Repeat("=", 80))
+    fmt.Println(tr("tri.title"))
     fmt.Println(strings.Repeat("=", 80))
+    fmt.Println(tr("tri.caveat"))
 
-    // Méthode 1 : Trilatération simple (3 meilleurs serveurs)
-    s1, s2, s3 := results[0].Server, results[1].Server, results[2].Server
-    d1, d2, d3 := results[0].Distance, results[1].Distance, results[2].Distance
+    solved := est.Solved
+    s1, s2, s3 := solved[0].Server, solved[1].Server, solved[2].Server
+    d1, d2, d3 := solved[0].Distance, solved[1].Distance, solved[2].Distance
+    loc1, loc2, loc3 := est.Trilateration, est.Multilateration, est.LeastSquares
+    cities := knownCities(servers)
+    coherence := assessCoherence(results)
 
-    loc1 := trilaterate(s1, s2, s3, d1, d2, d3)
+    fmt.Println(tr("tri.method1"))
+    fmt.Println(strings.Repeat("-", 80))
+    fmt.Printf(tr("tri.server"), 1, s1.Name, s1.City, formatDistance(d1))
+    fmt.Printf(tr("tri.server"), 2, s2.Name, s2.City, formatDistance(d2))
+    fmt.Printf(tr("tri.server"), 3, s3.Name, s3.City, formatDistance(d3))
+    fmt.Printf(tr("tri.position"), loc1.Lat, loc1.Lon)
+    displayNearestCity(loc1, cities)
+    displayTimezone(loc1, zones)
+    displayCountry(loc1, servers)
+    displayMapLinks(loc1, coherence.Precision)
+
+    fmt.Printf(tr("tri.method2"), est.MultilatServers)
+    fmt.Println(strings.Repeat("-", 80))
+    fmt.Printf(tr("tri.position2"), loc2.Lat, loc2.Lon)
+    displayNearestCity(loc2, cities)
+    displayTimezone(loc2, zones)
+    displayCountry(loc2, servers)
+    displayMapLinks(loc2, coherence.Precision)
 
-    fmt.Println("\nMETHODE 1: Trilatération 3-points")
+    fmt.Printf(tr("tri.method3"), est.MultilatServers)
     fmt.Println(strings.Repeat("-", 80))
-    fmt.Printf("Serveur 1: %s (%s) - Distance: %.0f km\n", s1.Name, s1.City, d1)
-    fmt.Printf("Serveur 2: %s (%s) - Distance: %.0f km\n", s2.Name, s2.City, d2)
-    fmt.Printf("Serveur 3: %s (%s) - Distance: %.0f km\n", s3.Name, s3.City, d3)
-    fmt.Printf("\nPosition estimée: %.4f, %.4f\n", loc1.Lat, loc1.Lon)
-    fmt.Printf("Google Maps: https://www.google.com/maps?q=%.4f,%.4f\n", loc1.Lat, loc1.Lon)
-
-    // Méthode 2 : Multilatération (10 meilleurs serveurs)
-    numServers := 10
-    if len(results) < numServers {
-        numServers = len(results)
+    fmt.Printf(tr("tri.position2"), loc3.Lat, loc3.Lon)
+    displayNearestCity(loc3, cities)
+    displayTimezone(loc3, zones)
+    displayCountry(loc3, servers)
+    displayMapLinks(loc3, coherence.Precision)
+    if len(est.SolverWeights) > 0 {
+        fmt.Println(tr("tri.weights"))
+        for i, w := range est.SolverWeights {
+            s := solved[i].Server
+            fmt.Printf("   %-20s | %-11s | %.2f\n", s.Name, s.City, w)
+        }
     }
-    
-    loc2 := multilateralTriangulation(results, numServers)
 
-    fmt.Println("\nMETHODE 2: Multilatération pondérée (top " + fmt.Sprint(numServers) + " serveurs)")
+    fmt.Printf(tr("tri.method4"), est.CircleLocations)
     fmt.Println(strings.Repeat("-", 80))
-    fmt.Printf("Position estimée: %.4f, %.4f\n", loc2.Lat, loc2.Lon)
-    fmt.Printf("Google Maps: https://www.google.com/maps?q=%.4f,%.4f\n", loc2.Lat, loc2.Lon)
+    if est.CirclesIntersect {
+        loc4 := est.CircleIntersection
+        fmt.Printf(tr("tri.position2"), loc4.Lat, loc4.Lon)
+        displayNearestCity(loc4, cities)
+        fmt.Printf(tr("tri.circleGap"), formatDistance(geo.Distance(loc4.Lat, loc4.Lon, loc3.Lat, loc3.Lon)))
+        displayMapLinks(loc4, coherence.Precision)
+    } else {
+        fmt.Println(tr("tri.noIntersection"))
+    }
+    if est.Fallback {
+        box := est.BoundingBox
+        fmt.Printf(tr("tri.method5"), box.Servers)
+        fmt.Println(strings.Repeat("-", 80))
+        fmt.Println(tr("tri.coarse"))
+        fmt.Printf(tr("tri.position2"), box.Center.Lat, box.Center.Lon)
+        fmt.Printf(tr("tri.box"), formatDistance(box.WidthKm), formatDistance(box.HeightKm),
+            box.South, box.North, box.West, box.East)
+        if box.Skipped > 0 {
+            fmt.Printf(tr("tri.boxSkipped"), box.Skipped)
+        }
+        displayNearestCity(box.Center, cities)
+        displayMapLinks(box.Center, math.Max(box.WidthKm, box.HeightKm)/2)
+    }
 
     // Visualisation ASCII du triangle
-    fmt.Println("\nVISUALISATION DU TRIANGLE DE TRIANGULATION")
+    fmt.Println(tr("tri.visualTitle"))
     fmt.Println(strings.Repeat("-", 80))
-    fmt.Printf("\n              %s\n", s1.Name)
-    fmt.Println("                /  \\")
-    fmt.Println("               /    \\")
-    fmt.Printf("          %.0f km    %.0f km\n", d1, 
-        distance(s1.Lat, s1.Lon, loc1.Lat, loc1.Lon))
-    fmt.Println("             /        \\")
-    fmt.Println("            /   [*]    \\")
-    fmt.Println("           /   CIBLE    \\")
-    fmt.Println("          /              \\")
-    fmt.Printf("    %s ----------- %s\n", s2.Name, s3.Name)
-    fmt.Printf("               %.0f km\n", distance(s2.Lat, s2.Lon, s3.Lat, s3.Lon))
+    fmt.Println(tr("tri.visualLegend"))
+    displayTriangle(os.Stdout, [3]Result{solved[0], solved[1], solved[2]}, est.Distances, loc1)
 
     // Distances géographiques entre serveurs
-    fmt.Println("\nDISTANCES GEOGRAPHIQUES ENTRE SERVEURS")
+    fmt.Println(tr("tri.distancesTitle"))
     fmt.Println(strings.Repeat("-", 80))
-    fmt.Printf("%s <-> %s: %.0f km\n", s1.Name, s2.Name, distance(s1.Lat, s1.Lon, s2.Lat, s2.Lon))
-    fmt.Printf("%s <-> %s: %.0f km\n", s1.Name, s3.Name, distance(s1.Lat, s1.Lon, s3.Lat, s3.Lon))
-    fmt.Printf("%s <-> %s: %.0f km\n", s2.Name, s3.Name, distance(s2.Lat, s2.Lon, s3.Lat, s3.Lon))
+    fmt.Printf("%s <-> %s: %s\n", s1.Name, s2.Name, formatDistance(est.Distances.Get(0, 1)))
+    fmt.Printf("%s <-> %s: %s\n", s1.Name, s3.Name, formatDistance(est.Distances.Get(0, 2)))
+    fmt.Printf("%s <-> %s: %s\n", s2.Name, s3.Name, formatDistance(est.Distances.Get(1, 2)))
 
     // Analyse de cohérence
-    fmt.Println("\nANALYSE DE COHERENCE")
+    fmt.Println(tr("tri.coherenceTitle"))
     fmt.Println(strings.Repeat("-", 80))
     
-    avgDelta := time.Duration(0)
-    for i := 0; i < 5 && i < len(results); i++ {
-        avgDelta += results[i].Delta
+    fmt.Printf(tr("tri.coherence"), tr(coherence.Level))
+    fmt.Printf(tr("tri.avgDelta"), coherence.AvgDelta)
+    fmt.Printf(tr("tri.analyzed"), len(results))
+    fmt.Printf(tr("tri.locations"), est.DistinctLocations)
+    if est.Cities > 0 {
+        fmt.Printf(tr("tri.cities"), est.Cities)
     }
-    avgDelta /= time.Duration(5)
-    
-    coherence := "EXCELLENTE"
-    if avgDelta > 50*time.Millisecond {
-        coherence = "BONNE"
+    fmt.Printf(tr("tri.precision"), formatDistance(coherence.Precision))
+    fmt.Printf(tr("tri.residualFloor"), formatDistance(est.ResidualFloorKm))
+    if est.PossibleProxy {
+        fmt.Println(tr("tri.possibleProxy"))
     }
-    if avgDelta > 100*time.Millisecond {
-        coherence = "MOYENNE"
+    fmt.Printf(tr("tri.divergence"), formatDistance(est.DivergenceKm))
+    if est.Divergent {
+        fmt.Println("\n" + strings.Repeat("!", 80))
+        fmt.Printf(tr("tri.divergent"), formatDistance(maxMethodDivergenceKm))
+        fmt.Println(tr("tri.divergentCauses"))
+        fmt.Println(strings.Repeat("!", 80))
     }
-    if avgDelta > 200*time.Millisecond {
-        coherence = "FAIBLE"
+
+    return est, true
+}
+
+// displayUncertainty affiche l'ellipse d'incertitude Monte Carlo.
+func displayUncertainty(measurements []Measurement, target Target, opts Options, trials int) *Ellipse {
+    fmt.Println("\n" + strings.Repeat("=", 80))
+    fmt.Println(tr("mc.title"))
+    fmt.Println(strings.Repeat("=", 80))
+
+    ellipse, ok := monteCarloEllipse(measurements, target, opts, trials)
+    if !ok {
+        fmt.Println(tr("tri.notEnough"))
+        return nil
     }
-    
-    fmt.Printf("Cohérence de la triangulation: %s\n", coherence)
-    fmt.Printf("Delta moyen (top 5): %v\n", avgDelta)
-    fmt.Printf("Nombre de serveurs analysés: %d\n", len(results))
-
-    // Estimation de la précision
-    precision := 500.0 // km par défaut
-    if avgDelta < 20*time.Millisecond {
-        precision = 100.0
-    } else if avgDelta < 50*time.Millisecond {
-        precision = 200.0
-    } else if avgDelta < 100*time.Millisecond {
-        precision = 300.0
+    fmt.Printf(tr("mc.samples"), ellipse.Samples, ellipse.Confidence*100)
+    fmt.Printf(tr("mc.center"), ellipse.Center.Lat, ellipse.Center.Lon)
+    fmt.Printf(tr("mc.axes"), formatDistance(ellipse.SemiMajorKm), formatDistance(ellipse.SemiMinorKm))
+    fmt.Printf(tr("mc.orientation"), ellipse.OrientationDeg)
+    return &ellipse
+}
+
+// displayGeoIPComparison compare la position de la cible selon la source
+// aux estimations et retourne la comparaison.
+func displayGeoIPComparison(source comparisonSource, target Target, estimates []Location) GeoComparison {
+    fmt.Println("\n" + strings.Repeat("=", 80))
+    fmt.Printf(tr("geoip.title"), source.Name)
+    fmt.Println(strings.Repeat("=", 80))
+
+    c, err := compareLocation(source, target, estimates)
+    switch {
+    case errors.Is(err, errNotLocatable):
+        fmt.Println(tr("geoip.notLocatable"))
+        return c
+    case errors.Is(err, errRateLimited):
+        fmt.Printf(tr("geoip.rateLimited"), source.Name, err)
+        return c
+    case err != nil:
+        fmt.Printf(tr("geoip.error"), source.Name, err)
+        return c
     }
-    
-    fmt.Printf("Précision estimée: +/- %.0f km\n", precision)
+    fmt.Printf(tr("geoip.location"), source.Name, c.Label, c.Location.Lat, c.Location.Lon)
+
+    for i, d := range c.DistancesKm {
+        fmt.Printf(tr("geoip.distance"), i+1, source.Name, formatDistance(d))
+    }
+    if len(estimates) == 0 {
+        return c
+    }
+    if c.Agree {
+        fmt.Println(tr("geoip.agree"))
+    } else {
+        fmt.Println(tr("geoip.diverge"))
+    }
+    return c
+}
+
+func displayNearestCity(loc Location, cities []City) {
+    if city, d, ok := nearestCity(loc, cities); ok {
+        fmt.Printf(tr("tri.nearestCity"), city.Name, city.Country, formatDistance(d))
+    }
+}
+
+// displayCountry affiche le pays probable, ou l'ambiguïté si aucun pays
+// ne réunit une majorité nette des ancres proches.
+func displayCountry(loc Location, servers []Server) {
+    fmt.Print(countryLine(loc, servers))
 }
 
+// countryLine formate la ligne de displayCountry, vide sans ancre proche.
+func countryLine(loc Location, servers []Server) string {
+    shares := inferCountry(loc, servers)
+    if len(shares) == 0 {
+        return ""
+    }
+    if shares[0].Share >= countryMajority {
+        return fmt.Sprintf(tr("tri.country"), shares[0].Country, shares[0].Share*100)
+    }
+
+    var parts []string
+    for i := 0; i < 3 && i < len(shares); i++ {
+        parts = append(parts, fmt.Sprintf("%s %.0f%%", shares[i].Country, shares[i].Share*100))
+    }
+    return fmt.Sprintf(tr("tri.countryAmbiguous"), strings.Join(parts, ", "))
+}
+
+
+// displayBars affiche les limit plus grands effectifs en barres, par ordre
+// décroissant puis alphabétique à égalité, pour un affichage reproductible.
+func displayBars(counts map[string]int, limit int) {
+    type labelCount struct {
+        label string
+        count int
+    }
 
-func displayStatistics(results []Result) {
+    var bars []labelCount
+    for label, count := range counts {
+        bars = append(bars, labelCount{label, count})
+    }
+    sort.Slice(bars, func(i, j int) bool {
+        if bars[i].count != bars[j].count {
+            return bars[i].count > bars[j].count
+        }
+        return bars[i].label < bars[j].label
+    })
+
+    for i := 0; i < limit && i < len(bars); i++ {
+        bar := strings.Repeat("#", bars[i].count)
+        fmt.Printf("  %-20s %s %d\n", bars[i].label, bar, bars[i].count)
+    }
+}
+
+// countryLabel retourne le pays d'un serveur dans les statistiques : un
+// serveur sans pays (base personnalisée) compte comme inconnu plutôt que
+// comme une barre sans nom.
+func countryLabel(s Server) string {
+    if country := strings.TrimSpace(s.Country); country != "" {
+        return country
+    }
+    return tr("stats.unknownCountry")
+}
+
+func displayStatistics(measurements []Measurement, results []Result) {
     if len(results) == 0 {
         return
     }
 
     fmt.Println("\n" + strings.Repeat("=", 80))
-    fmt.Println("STATISTIQUES GLOBALES")
+    fmt.Println(tr("stats.title"))
     fmt.Println(strings.Repeat("=", 80))
 
     // Regroupement par pays
     countryStats := make(map[string]int)
+    providerStats := make(map[string]int)
     for _, r := range results {
-        countryStats[r.Server.Country]++
+        countryStats[countryLabel(r.Server)]++
+        providerStats[providerOf(r.Server)]++
     }
 
-    fmt.Println("\nRépartition par pays (top 10):")
-    
-    type countryCount struct {
-        country string
-        count   int
+    fmt.Println(tr("stats.byCountry"))
+    displayBars(countryStats, 10)
+
+    // Regroupement par fournisseur : un fournisseur dominant impose souvent
+    // un même comportement anycast ou de bordure à beaucoup de serveurs
+    fmt.Println(tr("stats.byProvider"))
+    displayBars(providerStats, 10)
+    if provider, count := dominantProvider(results[:min(reportTopServers, len(results))]); count > 0 {
+        fmt.Printf(tr("stats.providerDominant"), provider, count, min(reportTopServers, len(results)))
     }
-    
-    var countries []countryCount
-    for country, count := range countryStats {
-        countries = append(countries, countryCount{country, count})
+
+    // Couverture par continent : une région sans réponse ne contraint pas
+    // l'estimation, peu fiable si la cible s'y trouve
+    fmt.Println(tr("stats.byRegion"))
+    coverage := regionCoverage(measurements)
+    for _, c := range coverage {
+        fmt.Printf("  %-20s %3d/%-3d (%.0f%%)\n", c.Region, c.Responded, c.Attempted,
+            100*float64(c.Responded)/float64(c.Attempted))
     }
-    
-    sort.Slice(countries, func(i, j int) bool {
-        return countries[i].count > countries[j].count
-    })
-    
-    for i := 0; i < 10 && i < len(countries); i++ {
-        bar := strings.Repeat("#", countries[i].count)
-        fmt.Printf("  %-20s %s %d\n", countries[i].country, bar, countries[i].count)
+    for _, c := range coverage {
+        if c.Responded == 0 && c.Region != regionAnycast {
+            fmt.Printf(tr("stats.regionUnreachable"), c.Region)
+        }
     }
 
-    // RTT moyen
-    var totalRTT time.Duration
+    // RTT moyen et médian, sur tous les serveurs puis hors anycast et
+    // "Global" : ces derniers répondent depuis un site proche du poste de
+    // mesure et tirent les chiffres vers le bas
+    var all, unicast []time.Duration
     for _, r := range results {
-        totalRTT += r.Server.AvgRTT
+        all = append(all, r.Server.AvgRTT)
+        if !isAnycast(r.Server) {
+            unicast = append(unicast, r.Server.AvgRTT)
+        }
     }
-    avgRTT := totalRTT / time.Duration(len(results))
-    
-    fmt.Printf("\nRTT moyen de tous les serveurs: %v\n", avgRTT)
-    fmt.Printf("Nombre total de serveurs testés: %d\n", len(results))
+    avgRTT, medianRTT := rttCenter(all)
+    fmt.Printf(tr("stats.avgRTT"), avgRTT)
+    fmt.Printf(tr("stats.medianRTT"), medianRTT)
+    if len(unicast) > 0 {
+        avgRTT, medianRTT = rttCenter(unicast)
+        fmt.Printf(tr("stats.unicastRTT"), len(unicast), avgRTT, medianRTT)
+    } else {
+        fmt.Println(tr("stats.noUnicast"))
+    }
+    fmt.Printf(tr("stats.total"), len(results))
+}
+
+// rttCenter retourne la moyenne et la médiane de RTT non vides.
+func rttCenter(rtts []time.Duration) (avg, median time.Duration) {
+    var total time.Duration
+    for _, rtt := range rtts {
+        total += rtt
+    }
+    sorted := append([]time.Duration(nil), rtts...)
+    sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+    return total / time.Duration(len(rtts)), percentile(sorted, 0.5)
 }
 
 
 func main() {
-    targetIP := getUserInput()
+    if err := run(); err != nil {
+        var status exitStatus
+        if !errors.As(err, &status) {
+            fmt.Fprintf(os.Stderr, tr("flag.invalid"), err)
+            status = exitError
+        }
+        os.Exit(int(status))
+    }
+}
 
-    servers := getServerDatabase()
-    
-    targetRTT, err := AvgPing(targetIP, 5)
+// run exécute la commande demandée. Les messages d'erreur sont affichés là
+// où l'erreur survient ; le code de sortie remonte dans une exitStatus, que
+// seul main transforme en os.Exit.
+func run() error {
+    c, err := parseConfig()
     if err != nil {
-        fmt.Printf("\nErreur lors du ping de la cible: %v\n", err)
-        fmt.Println("\nVerifiez que:")
-        fmt.Println("   - L'IP/domaine est valide")
-        fmt.Println("   - Vous avez les droits root (sudo)")
-        fmt.Println("   - Le firewall autorise ICMP")
-        return
+        return err
     }
+    defer c.close()
 
-    fmt.Printf("RTT cible : %v\n\n", targetRTT)
+    if c.History != "" {
+        db, err := openHistory(c.DB)
+        if err == nil {
+            err = printHistory(os.Stdout, db, c.History)
+            db.Close()
+        }
+        if err != nil {
+            fmt.Fprintf(os.Stderr, tr("history.error"), err)
+            return exitWith(exitError)
+        }
+        return nil
+    }
 
-    // Ping parallèle des serveurs
-    fmt.Println("[+] Analyse des serveurs de référence (cela peut prendre 1-2 minutes)...")
-    fmt.Println(strings.Repeat("-", 80))
-    
-    var wg sync.WaitGroup
-    var mu sync.Mutex
-    var results []Result
-    
-    progressCount := 0
-    totalServers := len(servers)
-
-    for _, s := range servers {
-        wg.Add(1)
-        go func(server Server) {
-            defer wg.Done()
-            
-            avg, err := AvgPing(server.IP, 3)
-            if err != nil {
-                mu.Lock()
-                progressCount++
-                fmt.Printf("\r[%3d/%3d] [X] %s: erreur", progressCount, totalServers, server.Name)
-                mu.Unlock()
-                return
+    if c.Command == updateDBCommand {
+        old, updated, err := updateDatabase(c.DBURL, c.DBPubKey, c.DBChecksum)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, tr("db.error"), err)
+            return exitWith(exitError)
+        }
+        displayDatabaseDiff(os.Stdout, diffDatabases(old, updated), len(updated))
+        return nil
+    }
+
+    servers, filtered := filterServers(getServerDatabase(), c.Include, c.Exclude)
+    if filtered > 0 {
+        slog.Info("servers filtered by name", "filtered", filtered, "kept", len(servers))
+        fmt.Fprintf(c.Console, tr("filter.summary"), filtered, len(servers))
+    }
+    if c.List {
+        if err := listServers(os.Stdout, servers, c.Output); err != nil {
+            fmt.Fprintf(os.Stderr, tr("list.error"), err)
+            return exitWith(exitError)
+        }
+        return nil
+    }
+
+    // ctx borne les mesures de la cible et des serveurs, puis celles qui
+    // suivent (--traceroute, --verify) : --deadline et Ctrl+C les arrêtent
+    ctx, stopInterrupt := context.Background(), func() {}
+    var target Target
+    var measurements []Measurement
+    var wasInterrupted bool
+    var reportPath string // rapport Markdown de --out-dir
+    var replay Session
+    seed := c.Seed
+
+    if c.Replay != "" {
+        var err error
+        if replay, err = loadSession(c.Replay); err != nil {
+            fmt.Fprintf(os.Stderr, tr("session.loadError"), err)
+            return exitWith(exitError)
+        }
+        if seed == 0 {
+            seed = replay.Seed
+        }
+    }
+    if seed == 0 {
+        seed = time.Now().UnixNano()
+    }
+    if c.Sample > 0 && c.Sample < len(servers) && c.Replay == "" {
+        servers = sampleServers(servers, c.Sample, rand.New(rand.NewSource(seed)))
+        slog.Info("servers sampled", "count", len(servers), "seed", seed)
+        for _, s := range servers {
+            slog.Debug("sampled server", "server", s.Name, "ip", s.IP, "country", s.Country,
+                "continent", continentOf(s.Country))
+        }
+    }
+    opts := c.options(servers, seed)
+    slog.Debug("options", "seed", seed)
+    meta := newMetadata(c.Vantage, len(getServerDatabase()), c.ModelName, c.SolverName, flag.CommandLine)
+
+    if c.Coordinate != "" {
+        reports, paths, err := loadReports(c.Coordinate)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, tr("session.loadError"), err)
+            return exitWith(exitError)
+        }
+        vantages, combined, err := coordinate(reports, paths, opts)
+        displayCoordination(vantages, combined, err, servers)
+        if c.Strict && err != nil {
+            return exitWith(exitTooFewServers)
+        }
+        return nil
+    }
+
+    if c.Command == benchmarkCommand {
+        ctx, interrupted, stop := interruptContext(context.Background())
+        report := runBenchmark(ctx, servers, opts)
+        report.Interrupted = interrupted()
+        stop()
+        if err := writeBenchmark(os.Stdout, report, c.Output); err != nil {
+            fmt.Fprintf(os.Stderr, tr("flag.invalid"), err)
+            return exitWith(exitError)
+        }
+        if report.Responded == 0 {
+            return exitWith(exitError)
+        }
+        return nil
+    }
+
+    if c.Reachability {
+        ctx, interrupted, stop := interruptContext(context.Background())
+        report := runReachability(ctx, servers, opts)
+        report.Interrupted = interrupted()
+        stop()
+        if err := writeReachability(os.Stdout, report, c.Output); err != nil {
+            fmt.Fprintf(os.Stderr, tr("flag.invalid"), err)
+            return exitWith(exitError)
+        }
+        if report.Up == 0 {
+            return exitWith(exitError)
+        }
+        return nil
+    }
+
+    if c.Command == calibrateCommand {
+        ctx, _, stop := interruptContext(context.Background())
+        ranked, common, err := calibrate(ctx, servers, *c.Vantage, opts)
+        stop()
+        if err != nil {
+            fmt.Fprintf(os.Stderr, tr("calib.error"), err)
+            return exitWith(exitError)
+        }
+        calibration := Calibration{
+            Format:    reportFormat,
+            Version:   version,
+            Timestamp: time.Now(),
+            Vantage:   *c.Vantage,
+            Model:     c.ModelName,
+            Common:    common,
+            Skews:     make(map[string]time.Duration, len(ranked)),
+        }
+        for _, s := range ranked {
+            calibration.Skews[s.Server.IP] = s.Skew
+        }
+        if err := saveCalibration(c.Out, calibration); err != nil {
+            fmt.Fprintf(os.Stderr, tr("calib.error"), err)
+            return exitWith(exitError)
+        }
+        displaySkews(c.Console, ranked, common)
+        fmt.Fprintf(c.Console, tr("calib.written"), c.Out, len(ranked))
+        return nil
+    }
+
+    var events *eventStream
+    if c.Output == outputNDJSON {
+        events = newNDJSONStream(os.Stdout)
+        opts.Observer = events
+    }
+
+    if c.Serve != "" {
+        // Ctrl+C arrête le service proprement (voir serve)
+        ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+        defer stop()
+        if err := serve(ctx, c.Serve, servers, opts, c.ServeConcurrency, meta); err != nil {
+            fmt.Fprintf(os.Stderr, tr("serve.error"), err)
+            return exitWith(exitError)
+        }
+        return nil
+    }
+
+    if c.Replay != "" {
+        slog.Info("replaying session", "path", c.Replay, "version", replay.Version,
+            "timestamp", replay.Timestamp, "seed", seed)
+        target, measurements = replay.Target, replay.Measurements
+        fillProviders(measurements, getServerDatabase())
+        if c.OutDir != "" {
+            var err error
+            if reportPath, err = useOutDir(c.OutDir, target.Input, c.Force, nil, &c.Region, &c.Heatmap); err != nil {
+                fmt.Fprintf(os.Stderr, tr("outdir.error"), err)
+                return exitWith(exitError)
+            }
+        }
+        if meta.Vantage == nil {
+            meta.Vantage = replay.Vantage
+        }
+        if opts.Skews == nil {
+            opts.Skews = replay.Skews
+        }
+        if events != nil {
+            events.TargetMeasured(target)
+            for _, m := range measurements {
+                events.ServerMeasured(m)
+            }
+        }
+    } else if c.DryRun {
+        host, port, err := getUserInput(c.Console, c.Target)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, tr("input.invalid"), err)
+            return exitWith(exitError)
+        }
+        target = Target{Input: host, IP: host, Port: port}
+        ip, err := opts.DNSCache.ResolveIPv4(host)
+        if err == nil {
+            target.IP = ip
+        }
+        var v6 *ipv6OnlyError
+        if errors.As(err, &v6) {
+            fmt.Fprintf(os.Stderr, tr("target.ipv6Only"), err)
+            return exitWith(exitError)
+        }
+        printPlan(os.Stdout, planMeasurements(target, err == nil, servers, opts, c.Deadline, c.Traceroute))
+        if err != nil {
+            return exitWith(exitError)
+        }
+        return nil
+    } else {
+        host, port, err := getUserInput(c.Console, c.Target)
+        if err != nil {
+            fmt.Fprintf(os.Stderr, tr("input.invalid"), err)
+            return exitWith(exitError)
+        }
+        if target, err = newTarget(host, port, opts.DNSCache); err != nil {
+            if events != nil {
+                events.Error(err.Error())
+            } else {
+                fmt.Fprintf(os.Stderr, tr("target.ipv6Only"), err)
+            }
+            return exitWith(exitError)
+        }
+        if c.OutDir != "" && !c.Agent {
+            var err error
+            if reportPath, err = useOutDir(c.OutDir, target.Input, c.Force, &c.SaveSession, &c.Region, &c.Heatmap); err != nil {
+                fmt.Fprintf(os.Stderr, tr("outdir.error"), err)
+                return exitWith(exitError)
+            }
+        }
+        if c.ASN {
+            if err := target.lookupASN(cymruASN{}); err != nil {
+                slog.Warn("ASN lookup failed", "ip", target.IP, "error", err)
             }
+        }
 
-            server.AvgRTT = avg
-            delta := avg - targetRTT
-            if delta < 0 {
-                delta = -delta
+        if c.Deadline > 0 {
+            var cancel context.CancelFunc
+            ctx, cancel = context.WithTimeout(ctx, c.Deadline)
+            defer cancel()
+        }
+        // sweepServers attend la fin de tous les workers avant de rendre
+        // les mesures : le rapport partiel est construit après ce drainage
+        var interrupted func() bool
+        ctx, interrupted, stopInterrupt = interruptContext(ctx)
+        defer stopInterrupt()
+        measurements, err = runMeasurements(ctx, &target, servers, opts)
+        wasInterrupted = interrupted()
+        if err != nil && wasInterrupted {
+            if events != nil {
+                events.Error(tr("interrupt.noTarget"))
+            } else {
+                fmt.Fprintln(c.Console, tr("interrupt.noTarget"))
             }
+            return exitWith(exitInterrupted)
+        }
+        if err != nil && events != nil {
+            events.Error(err.Error())
+            return exitWith(exitError)
+        }
+        if err != nil {
+            fmt.Fprintf(c.Console, tr("target.pingError"), err)
+            fmt.Fprintln(c.Console, tr("target.checkHeader"))
+            fmt.Fprintln(c.Console, tr("target.checkValid"))
+            fmt.Fprintln(c.Console, tr("target.checkRoot"))
+            fmt.Fprintln(c.Console, tr("target.checkFirewall"))
+            if c.Strict {
+                return exitWith(exitError)
+            }
+            return nil
+        }
 
-            // Calculer la distance estimée basée sur RTT
-            estimatedDistance := rttToDistance(delta)
-
-            mu.Lock()
-            results = append(results, Result{
-                Server:   server,
-                Delta:    delta,
-                Distance: estimatedDistance,
-            })
-            progressCount++
-            fmt.Printf("\r[%3d/%3d] [OK] %s: %v", progressCount, totalServers, server.Name, avg)
-            mu.Unlock()
-        }(s)
-        
-        // délai pour éviter de surcharger(bug une fois sur deux...)
-        time.Sleep(10 * time.Millisecond)
+        session := Session{
+            Format:       reportFormat,
+            Version:      version,
+            Timestamp:    time.Now(),
+            Seed:         seed,
+            Vantage:      c.Vantage,
+            PacketSize:   c.PacketSize,
+            Source:       c.Source,
+            Target:       target,
+            Measurements: measurements,
+            Skews:        opts.Skews,
+        }
+        if c.SaveSession != "" {
+            if err := saveSession(c.SaveSession, session); err != nil {
+                slog.Error("cannot save session", "path", c.SaveSession, "error", err)
+            }
+        }
+        if c.Agent {
+            session.Agent = c.AgentName
+            if err := saveSession(c.Out, session); err != nil {
+                fmt.Fprintf(os.Stderr, tr("agent.error"), err)
+                return exitWith(exitError)
+            }
+            fmt.Fprintf(c.Console, tr("agent.written"), c.Out, summarizeSweep(measurements).Responded, len(measurements))
+            return nil
+        }
     }
 
-    wg.Wait()
-    fmt.Println("\n")
-
+    summary := summarizeSweep(measurements)
+    summary.Interrupted = wasInterrupted
+    if c.Replay == "" {
+        summary.Concurrency = opts.Concurrency.Settled()
+    }
+    measuredAt := time.Now()
+    if c.Replay != "" {
+        measuredAt = replay.Timestamp
+    }
+    inconsistent := findInconsistencies(measurements, meta.Vantage)
+    for _, f := range inconsistent {
+        slog.Warn("server location inconsistent with its RTT", "server", f.Server.Name, "ip", f.Server.IP,
+            "rtt", f.RTT, "min_rtt", f.MinRTT)
+    }
+    if !c.structured() {
+        displayInconsistencies(os.Stdout, inconsistent, c.DropInconsistent)
+    }
+    if c.DropInconsistent {
+        measurements = dropInconsistent(measurements, inconsistent)
+    }
+    results := buildResults(measurements, target.RTT, opts)
+    if len(results) == 0 && events != nil {
+        events.Error(errNoResponse.Error())
+        return exitWith(exitTooFewServers)
+    }
     if len(results) == 0 {
-        fmt.Println("\nErreur: Aucun serveur n'a répondu. Vérifiez votre connexion.")
-        return
+        fmt.Fprintln(c.Console, tr("sweep.noResponse"))
+        if c.Strict {
+            return exitWith(exitTooFewServers)
+        }
+        return nil
     }
 
-    // Tri par delta
-    sort.Slice(results, func(i, j int) bool {
-        return results[i].Delta < results[j].Delta
-    })
+    a := analysis{
+        Meta:         meta.withSweep(measuredAt, summary),
+        Target:       target,
+        Summary:      summary,
+        Measurements: measurements,
+        Results:      results,
+        Servers:      servers,
+        ReportPath:   reportPath,
+        Grid: &gridExport{
+            HeatmapPath: c.Heatmap,
+            RegionPath:  c.Region,
+            RadiusKm:    c.HeatmapRadius,
+            Step:        c.HeatmapStep,
+            ThresholdKm: c.RegionThreshold,
+            Vantage:     meta.Vantage,
+        },
+    }
+    if c.Traceroute && c.Replay == "" {
+        a.Refinements = refineWithTraceroute(ctx, target, results, opts)
+    }
 
-    // Affichage des résultats
-    displayResults(results, targetIP, targetRTT)
-    displayTriangulation(results)
-    displayStatistics(results)
+    if c.structured() {
+        if err := writeStructuredReport(ctx, c, a, opts, events); err != nil {
+            return err
+        }
+    } else {
+        estimates, triangulated := writeTextReport(ctx, c, a, opts)
+        if c.Watch > 0 && c.Replay == "" {
+            stopInterrupt()
+            filter := newPositionFilter(c.ProcessNoise, c.MeasurementNoise)
+            if triangulated {
+                filter.Update(estimates.Multilateration)
+            }
+            ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+            watch(ctx, target, measuredServers(measurements), opts, c.Watch, c.Deadline, filter)
+            stop()
+        }
+    }
 
-    fmt.Println("\n" + strings.Repeat("=", 80))
-    fmt.Println("ANALYSE TERMINEE")
-    fmt.Println(strings.Repeat("=", 80))
+    if c.Strict {
+        return exitWith(strictExitCode(results, opts.MinLocations))
+    }
+    return nil
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+    "context"
+    "strings"
+    "testing"
+    "time"
+
+    "triangula/geo"
+)
+
+func TestPingRejectsInvalidArguments(t *testing.T) {
+    tests := []struct {
+        count   int
+        timeout time.Duration
+        want    string
+    }{
+        {count: 0, timeout: time.Second, want: "count must be positive"},
+        {count: -3, timeout: time.Second, want: "count must be positive"},
+        {count: 1, timeout: 0, want: "timeout must be positive"},
+        {count: 1, timeout: -time.Second, want: "timeout must be positive"},
+    }
+    for _, tt := range tests {
+        // Rejetés avant toute socket : aucun paquet n'est envoyé
+        _, err := Ping(context.Background(), "192.0.2.1", tt.count, tt.timeout)
+        if err == nil || !strings.Contains(err.Error(), tt.want) {
+            t.Errorf("Ping(count=%d, timeout=%v) error = %v, want %q", tt.count, tt.timeout, err, tt.want)
+        }
+        if _, err := AvgPing(context.Background(), "192.0.2.1", tt.count, tt.timeout); err == nil {
+            t.Errorf("AvgPing(count=%d, timeout=%v) succeeded", tt.count, tt.timeout)
+        }
+    }
+}
+
+// resultAt retourne un résultat de serveur situé en loc, à delta de la cible.
+func resultAt(name string, loc Location, delta time.Duration) Result {
+    return Result{Server: Server{Name: name, IP: "192.0.2.1", Lat: loc.Lat, Lon: loc.Lon}, Delta: delta}
+}
+
+func TestMultilateralTriangulation(t *testing.T) {
+    tests := []struct {
+        name    string
+        results []Result
+        servers int
+        want    Location
+    }{
+        {name: "moins de trois serveurs", results: []Result{
+            resultAt("a", Location{Lat: 10, Lon: 10}, 0),
+            resultAt("b", Location{Lat: 20, Lon: 20}, 0),
+        }, servers: 10, want: Location{}},
+        // Des serveurs de part et d'autre de l'antiméridien donnent un point
+        // dans le Pacifique, et non près du méridien d'origine
+        {name: "antiméridien", results: []Result{
+            resultAt("fiji", Location{Lat: -10, Lon: 179}, 0),
+            resultAt("samoa", Location{Lat: -10, Lon: -179}, 0),
+            resultAt("tonga", Location{Lat: -20, Lon: 180}, 0),
+        }, servers: 10, want: Location{Lat: -13.34, Lon: 180}},
+        // Poids 1/(delta+1) : 1 pour le premier serveur, 1/100 pour les autres
+        {name: "poids du delta", results: []Result{
+            resultAt("proche", Location{Lat: 0, Lon: 0}, 0),
+            resultAt("loin", Location{Lat: 0, Lon: 10}, 99*time.Millisecond),
+            resultAt("plus-loin", Location{Lat: 0, Lon: 20}, 99*time.Millisecond),
+        }, servers: 10, want: Location{Lat: 0, Lon: 0.29}},
+        {name: "serveurs retenus", results: []Result{
+            resultAt("a", Location{Lat: 0, Lon: 20}, 0),
+            resultAt("b", Location{Lat: 0, Lon: 20}, 0),
+            resultAt("c", Location{Lat: 0, Lon: 20}, 0),
+            resultAt("exclu", Location{Lat: 0, Lon: -60}, 0),
+        }, servers: 3, want: Location{Lat: 0, Lon: 20}},
+        // Barycentre au centre de la Terre : position du meilleur serveur
+        {name: "antipodes", results: []Result{
+            resultAt("a", Location{Lat: 0, Lon: 0}, 0),
+            resultAt("b", Location{Lat: 0, Lon: 120}, 0),
+            resultAt("c", Location{Lat: 0, Lon: -120}, 0),
+        }, servers: 3, want: Location{Lat: 0, Lon: 0}},
+    }
+    for _, tt := range tests {
+        got := multilateralTriangulation(tt.results, tt.servers)
+        if d := geo.Distance(got.Lat, got.Lon, tt.want.Lat, tt.want.Lon); d > 5 {
+            t.Errorf("%s: multilateralTriangulation = %v, want %v (%.1f km away)", tt.name, got, tt.want, d)
+        }
+    }
+}
+
+func TestCountryLabel(t *testing.T) {
+    tests := []struct {
+        lang    string
+        country string
+        want    string
+    }{
+        {lang: "en", country: "France", want: "France"},
+        {lang: "en", country: "  Japan ", want: "Japan"},
+        {lang: "en", country: "", want: "Unknown"},
+        {lang: "en", country: "   ", want: "Unknown"},
+        {lang: "fr", country: "", want: "Inconnu"},
+    }
+    defer setLanguage(currentLang)
+    for _, tt := range tests {
+        setLanguage(tt.lang)
+        if got := countryLabel(Server{Name: "s", Country: tt.country}); got != tt.want {
+            t.Errorf("countryLabel(%q) in %s = %q, want %q", tt.country, tt.lang, got, tt.want)
+        }
+    }
+}
+
+func TestLanguageCode(t *testing.T) {
+    tests := []struct {
+        value string
+        want  string
+        ok    bool
+    }{
+        {value: "fr", want: "fr", ok: true},
+        {value: "FR", want: "fr", ok: true},
+        {value: "fr_FR.UTF-8", want: "fr", ok: true},
+        {value: "en-US", want: "en", ok: true},
+        {value: "de", want: "de"},
+        {value: ""},
+    }
+    for _, tt := range tests {
+        got, ok := languageCode(tt.value)
+        if got != tt.want || ok != tt.ok {
+            t.Errorf("languageCode(%q) = %q, %v, want %q, %v", tt.value, got, ok, tt.want, tt.ok)
+        }
+    }
+}
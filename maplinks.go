@@ -0,0 +1,103 @@
+package main
+
+import (
+    "fmt"
+    "math"
+    "strings"
+
+    "triangula/geo"
+)
+
+// Cartes vers lesquelles pointent les liens des rapports (--maps)
+const (
+    mapGoogle  = "google"
+    mapOSM     = "osm"
+    mapOSMArea = "osm-area" // cadre de la zone d'incertitude
+)
+
+// Cartes par défaut de --maps
+const defaultMaps = mapGoogle + "," + mapOSM
+
+var mapProviders = []string{mapGoogle, mapOSM}
+
+// setMapProviders sélectionne les cartes des liens (liste séparée par des
+// virgules), false si l'une d'elles est inconnue ou la liste vide.
+func setMapProviders(spec string) bool {
+    var providers []string
+    for _, p := range strings.Split(spec, ",") {
+        switch p = strings.TrimSpace(p); p {
+        case mapGoogle, mapOSM, mapOSMArea:
+            providers = append(providers, p)
+        default:
+            return false
+        }
+    }
+    mapProviders = providers
+    return true
+}
+
+// MapLink est un lien vers une carte centrée sur une position.
+type MapLink struct {
+    Label string
+    URL   string
+}
+
+// zoomForRadius retourne le niveau de zoom (2-18) d'une carte en tuiles
+// web dont la fenêtre, d'environ quatre tuiles de large, couvre deux fois
+// le diamètre de la zone d'incertitude : une incertitude de 500 km ne
+// s'ouvre pas au niveau de la rue.
+func zoomForRadius(lat, radiusKm float64) int {
+    if radiusKm <= 0 {
+        return 10
+    }
+    // Largeur (km) d'une tuile au zoom 0 à cette latitude
+    tileKm := 2 * math.Pi * geo.EarthRadius * math.Cos(lat*math.Pi/180)
+    zoom := int(math.Floor(math.Log2(tileKm / radiusKm)))
+    return max(2, min(18, zoom))
+}
+
+// mapLinks retourne les liens des cartes sélectionnées vers loc, zoomés
+// selon le rayon d'incertitude radiusKm (0 : inconnu).
+func mapLinks(loc Location, radiusKm float64) []MapLink {
+    zoom := zoomForRadius(loc.Lat, radiusKm)
+    var links []MapLink
+    for _, p := range mapProviders {
+        switch p {
+        case mapGoogle:
+            links = append(links, MapLink{"Google Maps",
+                fmt.Sprintf("https://www.google.com/maps?q=%.4f,%.4f", loc.Lat, loc.Lon)})
+        case mapOSM:
+            links = append(links, MapLink{"OpenStreetMap",
+                fmt.Sprintf("https://www.openstreetmap.org/?mlat=%.4f&mlon=%.4f#map=%d/%.4f/%.4f",
+                    loc.Lat, loc.Lon, zoom, loc.Lat, loc.Lon)})
+        case mapOSMArea:
+            if radiusKm <= 0 {
+                continue
+            }
+            kmPerDeg := geo.EarthRadius * math.Pi / 180
+            dLat := radiusKm / kmPerDeg
+            dLon := math.Min(dLat/math.Max(math.Cos(loc.Lat*math.Pi/180), 1e-9), 180)
+            links = append(links, MapLink{tr("map.osmArea"),
+                fmt.Sprintf("https://www.openstreetmap.org/?minlon=%.4f&minlat=%.4f&maxlon=%.4f&maxlat=%.4f&mlat=%.4f&mlon=%.4f",
+                    math.Max(loc.Lon-dLon, -180), math.Max(loc.Lat-dLat, -90),
+                    math.Min(loc.Lon+dLon, 180), math.Min(loc.Lat+dLat, 90), loc.Lat, loc.Lon)})
+        }
+    }
+    return links
+}
+
+// displayMapLinks affiche un lien par carte sélectionnée.
+func displayMapLinks(loc Location, radiusKm float64) {
+    for _, l := range mapLinks(loc, radiusKm) {
+        fmt.Printf("%s: %s\n", l.Label, l.URL)
+    }
+}
+
+// markdownMapLinks formate les liens en liens Markdown séparés par des virgules.
+func markdownMapLinks(loc Location, radiusKm float64) string {
+    var parts []string
+    for _, l := range mapLinks(loc, radiusKm) {
+        parts = append(parts, fmt.Sprintf("[%s](%s)", l.Label, l.URL))
+    }
+    return strings.Join(parts, ", ")
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "log/slog"
+    "math"
+    "os"
+    "strings"
+)
+
+// Formats de rapport (--output)
+const (
+    outputText     = "text"
+    outputMarkdown = "markdown"
+    outputJSON     = "json" // --list uniquement
+    outputNDJSON   = "ndjson"
+)
+
+// Nombre de serveurs listés dans les rapports
+const reportTopServers = 15
+
+// mdEscape neutralise les caractères qui casseraient une cellule de tableau.
+func mdEscape(s string) string {
+    return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// saveMarkdownReport écrit le rapport Markdown dans path (--out-dir).
+func saveMarkdownReport(path string, meta Metadata, target Target, summary SweepSummary, results []Result, est Estimates,
+    triangulated bool, region *Region, servers []Server) {
+    f, err := os.Create(path)
+    if err == nil {
+        writeMarkdownReport(f, meta, target, summary, results, est, triangulated, region, servers)
+        err = f.Close()
+    }
+    if err != nil {
+        slog.Error("cannot write report", "path", path, "error", err)
+        return
+    }
+    slog.Info("report written", "path", path)
+}
+
+// writeMarkdownReport écrit le tableau des meilleurs serveurs et la synthèse
+// de la triangulation en tableaux GitHub-flavored Markdown, avec les mêmes
+// valeurs que le mode texte. meta doit être complété par la campagne.
+func writeMarkdownReport(w io.Writer, meta Metadata, target Target, summary SweepSummary, results []Result, est Estimates,
+    triangulated bool, region *Region, servers []Server) {
+    fmt.Fprintf(w, "## %s\n\n", mdEscape(fmt.Sprintf(strings.TrimSpace(tr("md.title")), target.Label(), target.RTT)))
+    for _, line := range metadataLines(meta) {
+        fmt.Fprintf(w, "- %s\n", mdEscape(line))
+    }
+    if summary.Interrupted {
+        fmt.Fprintf(w, "- **%s**\n", mdEscape(strings.TrimSpace(fmt.Sprintf(tr("results.interrupted"), summary.Measured))))
+    }
+    if len(target.PTR) > 0 {
+        fmt.Fprintf(w, "- %s\n", mdEscape(strings.TrimSpace(fmt.Sprintf(tr("results.ptr"), strings.Join(target.PTR, ", ")))))
+    }
+    if target.ASN != nil {
+        fmt.Fprintf(w, "- %s\n", mdEscape(strings.TrimSpace(fmt.Sprintf(tr("results.asn"), target.ASN))))
+    }
+
+    fmt.Fprintf(w, "\n### %s\n\n", strings.TrimSpace(tr("results.top")))
+    fmt.Fprintln(w, tr("md.serversHeader"))
+    fmt.Fprintln(w, "|---:|---|---|---|---:|---:|---:|---:|")
+    for i := 0; i < reportTopServers && i < len(results); i++ {
+        r := results[i]
+        fmt.Fprintf(w, "| %d | %s | %s | %s | %v | %v | %v | %s ± %s |\n", i+1,
+            mdEscape(r.Server.Name), mdEscape(r.Server.Country), mdEscape(r.Server.City),
+            r.Server.AvgRTT, r.Server.Jitter, r.Delta, formatDistance(r.Distance), formatDistance(r.DistanceError))
+    }
+
+    fmt.Fprintf(w, "\n### %s\n\n", tr("tri.title"))
+    if !triangulated && len(results) < 3 {
+        fmt.Fprintln(w, strings.TrimSpace(fmt.Sprintf(tr("tri.tooFew"), len(results))))
+        return
+    }
+    if !triangulated {
+        fmt.Fprintln(w, strings.TrimSpace(tr("tri.notEnough")))
+        return
+    }
+
+    cities := knownCities(servers)
+    coherence := assessCoherence(results)
+    fmt.Fprintln(w, tr("md.methodsHeader"))
+    fmt.Fprintln(w, "|---|---:|---:|---|---|")
+    labels := []string{tr("md.method1"), tr("md.method2"), tr("md.method3")}
+    for i, loc := range est.Locations() {
+        city := ""
+        if c, d, ok := nearestCity(loc, cities); ok {
+            city = fmt.Sprintf("%s, %s (%s)", c.Name, c.Country, formatDistance(d))
+        }
+        fmt.Fprintf(w, "| %s | %.4f | %.4f | %s | %s |\n",
+            labels[i], loc.Lat, loc.Lon, mdEscape(city), markdownMapLinks(loc, coherence.Precision))
+    }
+    if est.CirclesIntersect {
+        loc := est.CircleIntersection
+        city := ""
+        if c, d, ok := nearestCity(loc, cities); ok {
+            city = fmt.Sprintf("%s, %s (%s)", c.Name, c.Country, formatDistance(d))
+        }
+        fmt.Fprintf(w, "| %s | %.4f | %.4f | %s | %s |\n",
+            tr("md.method4"), loc.Lat, loc.Lon, mdEscape(city), markdownMapLinks(loc, coherence.Precision))
+    } else {
+        fmt.Fprintf(w, "| %s | - | - | %s | |\n", tr("md.method4"), tr("md.noIntersection"))
+    }
+    if est.Fallback {
+        box := est.BoundingBox
+        fmt.Fprintf(w, "| %s | %.4f | %.4f | %s | %s |\n", tr("md.method5"),
+            box.Center.Lat, box.Center.Lon, fmt.Sprintf(tr("md.box"), formatDistance(box.WidthKm), formatDistance(box.HeightKm)),
+            markdownMapLinks(box.Center, math.Max(box.WidthKm, box.HeightKm)/2))
+    }
+
+    fmt.Fprintln(w)
+    fmt.Fprintln(w, tr("md.summaryHeader"))
+    fmt.Fprintln(w, "|---|---|")
+    fmt.Fprintf(w, "| %s | %s |\n", tr("md.coherence"), tr(coherence.Level))
+    fmt.Fprintf(w, "| %s | %v |\n", tr("md.avgDelta"), coherence.AvgDelta)
+    fmt.Fprintf(w, "| %s | %d |\n", tr("md.analyzed"), len(results))
+    fmt.Fprintf(w, "| %s | %d |\n", tr("md.locations"), est.DistinctLocations)
+    if est.Cities > 0 {
+        fmt.Fprintf(w, "| %s | %d |\n", tr("md.cities"), est.Cities)
+    }
+    fmt.Fprintf(w, "| %s | +/- %s |\n", tr("md.precision"), formatDistance(coherence.Precision))
+    fmt.Fprintf(w, "| %s | %s |\n", tr("md.residualFloor"), formatDistance(est.ResidualFloorKm))
+    fmt.Fprintf(w, "| %s | %s |\n", tr("md.divergence"), formatDistance(est.DivergenceKm))
+    if v := est.Verification; v != nil {
+        fmt.Fprintf(w, "| %s | %s |\n", fmt.Sprintf(tr("md.verification"), mdEscape(v.Server)),
+            fmt.Sprintf(tr("md.verificationGap"), formatDistance(v.GapKm), formatDistance(v.ToleranceKm)))
+    }
+    if e := est.Uncertainty; e != nil {
+        fmt.Fprintf(w, "| %s | %s |\n", fmt.Sprintf(tr("md.uncertainty"), e.Confidence*100, e.Samples),
+            fmt.Sprintf(tr("md.uncertaintyAxes"), formatDistance(e.SemiMajorKm), formatDistance(e.SemiMinorKm), e.OrientationDeg))
+    }
+    for _, c := range est.Comparisons {
+        if c.Location == nil {
+            fmt.Fprintf(w, "| %s | %s |\n", fmt.Sprintf(tr("md.comparison"), mdEscape(c.Source), "-"),
+                mdEscape(strings.TrimSpace(comparisonFailure(c))))
+            continue
+        }
+        fmt.Fprintf(w, "| %s | %s |\n", fmt.Sprintf(tr("md.comparison"), mdEscape(c.Source), mdEscape(c.Label)),
+            fmt.Sprintf(tr("md.comparisonGap"), formatDistance(c.DistancesKm[2])))
+    }
+    if ce := est.Continent; ce != nil {
+        fmt.Fprintf(w, "| %s | %s |\n", fmt.Sprintf(tr("md.continent"), mdEscape(ce.Continent), ce.Servers),
+            fmt.Sprintf(tr("md.continentGap"), ce.Local.Lat, ce.Local.Lon, formatDistance(ce.GapKm)))
+        if ce.Fused != nil {
+            fmt.Fprintf(w, "| %s | %.4f, %.4f |\n", fmt.Sprintf(tr("md.continentFused"), ce.LocalWeight*100),
+                ce.Fused.Lat, ce.Fused.Lon)
+        }
+    }
+    if region != nil {
+        fmt.Fprintf(w, "| %s | %s |\n", tr("md.region"), formatArea(region.AreaKm2))
+    }
+    if est.PossibleProxy {
+        fmt.Fprintf(w, "\n> %s\n", strings.TrimPrefix(tr("tri.possibleProxy"), "=> "))
+    }
+    if v := est.Verification; v != nil && v.Suspect {
+        fmt.Fprintf(w, "\n> **%s**\n", strings.TrimPrefix(tr("tri.verifySuspect"), "=> "))
+    }
+    if est.Divergent {
+        fmt.Fprintf(w, "\n> **%s** %s\n", strings.TrimSpace(fmt.Sprintf(tr("tri.divergent"), formatDistance(maxMethodDivergenceKm))),
+            tr("tri.divergentCauses"))
+    }
+}
@@ -0,0 +1,459 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "log/slog"
+    "math"
+    "math/rand"
+    "os"
+    "sync"
+    "time"
+
+    "triangula/geo"
+)
+
+// Valeurs par défaut des paramètres de ping (--count, --target-count, --timeout)
+const (
+    defaultServerPingCount = 3
+    defaultTargetPingCount = 5
+    defaultPingTimeout     = 10 * time.Second
+)
+
+// PingStats résume une série de pings vers une IP.
+type PingStats struct {
+    Avg      time.Duration
+    StdDev   time.Duration // gigue (écart-type des échantillons)
+    Sent     int
+    Received int
+}
+
+// Loss retourne la fraction des pings restés sans réponse.
+func (s PingStats) Loss() float64 {
+    if s.Sent <= 0 {
+        return 0
+    }
+    return 1 - float64(s.Received)/float64(s.Sent)
+}
+
+// statsFromSamples calcule moyenne et écart-type de RTT échantillonnés.
+func statsFromSamples(samples []time.Duration, sent int) PingStats {
+    stats := PingStats{Sent: sent, Received: len(samples)}
+    if len(samples) == 0 {
+        return stats
+    }
+
+    var sum time.Duration
+    for _, s := range samples {
+        sum += s
+    }
+    stats.Avg = sum / time.Duration(len(samples))
+
+    var variance float64
+    for _, s := range samples {
+        d := float64(s - stats.Avg)
+        variance += d * d
+    }
+    stats.StdDev = time.Duration(math.Sqrt(variance / float64(len(samples))))
+    return stats
+}
+
+// Measurer mesure le RTT vers une IP. Permet de remplacer le ping
+// réseau par une source déterministe (tests, rejeu). L'annulation du contexte
+// interrompt la mesure et retourne ctx.Err().
+type Measurer interface {
+    Measure(ctx context.Context, ip string, count int) (PingStats, error)
+}
+
+// Bornes de --packet-size (charge utile ICMP, en octets) : go-ping exige
+// 16 octets pour son horodatage, 65507 est le maximum d'un datagramme IPv4
+const (
+    minPacketSize = 16
+    maxPacketSize = 65507
+)
+
+// En-têtes IPv4 (20) et ICMP (8), pour comparer la taille à la MTU
+const (
+    icmpOverhead = 28
+    typicalMTU   = 1500
+)
+
+// icmpMeasurer est le Measurer par défaut, basé sur go-ping (voir Ping).
+type icmpMeasurer struct {
+    Timeout  time.Duration      // durée maximale d'une mesure
+    Size     int                // charge utile ICMP ; 0 : taille par défaut
+    Source   string             // adresse source ; vide : choisie par la table de routage
+    Sampling sequentialSampling // arrêt anticipé ; désactivé par défaut
+}
+
+// Options paramètre une analyse. Les champs nil sont remplacés par
+// leur valeur par défaut via withDefaults.
+type Options struct {
+    Measurer Measurer   // ping ICMP (avec Timeout) si nil
+    // Probes remplace Measurer pour les serveurs de référence dont la base
+    // précise la sonde, par IP résolue (voir serverProbes) ; jamais pour la cible
+    Probes map[string]Probe
+    Rand     *rand.Rand // source des étapes aléatoires ; graine fixée pour un résultat reproductible
+    Progress *os.File   // barre de progression ; nil : événements de log uniquement
+
+    // TCPFallback mesure la cible par connexion TCP si elle ignore l'ICMP.
+    // Toujours actif quand la cible précise un port (hôte:port).
+    TCPFallback bool
+
+    Count       int           // pings par serveur de référence
+    TargetCount int           // pings vers la cible
+    Timeout     time.Duration // durée maximale d'une mesure
+    PacketSize  int           // charge utile ICMP (--packet-size) ; 0 : taille par défaut
+    Source      string        // adresse source des sondes (--source, --interface) ; vide : route par défaut
+    // Sampling arrête les mesures dès que le RTT est stable (--adaptive-stddev) ;
+    // Count et TargetCount deviennent des maximums
+    Sampling sequentialSampling
+
+    Model    geo.DistanceModel // conversion RTT -> distance ; geo.DefaultDistanceModel si nil
+    Baseline time.Duration     // délai fixe retiré avant conversion
+    HopDelay time.Duration     // délai de traitement par saut retiré avec --traceroute ; 0 : ignoré
+    // ProviderBaselines remplace Baseline pour les serveurs des fournisseurs
+    // listés (clés en minuscules, voir loadProviderBaselines)
+    ProviderBaselines map[string]time.Duration
+    // Skews retire à chaque serveur (par IP) son biais mesuré par la
+    // sous-commande calibrate (--calibration) avant le calcul du delta
+    Skews map[string]time.Duration
+
+    Solver    geo.Solver // moindres carrés de la méthode 3 ; geo.DefaultSolver si nil
+    Weighting string     // pondération des résidus (weighting*) ; weightingEqual si vide
+    Geometry  string     // distances ajustées par la méthode 3 (geometry*) ; geometryDelta si vide
+    Rank      string     // classement des serveurs (rank*) ; rankDelta si vide
+
+    // DedupeLocations ne garde qu'un serveur par emplacement pour la
+    // triangulation ; l'affichage et les statistiques gardent tous les serveurs
+    DedupeLocations bool
+
+    // Collapse fusionne les serveurs d'une même ville (collapse*) pour la
+    // triangulation ; collapseNone si vide
+    Collapse string
+
+    // MinLocations est le nombre minimal de coordonnées distinctes parmi
+    // les serveurs ayant répondu ; defaultMinLocations si nul
+    MinLocations int
+
+    // Colocation compte les serveurs de la base par emplacement, pour la
+    // pondération colocation (voir colocationCounts)
+    Colocation map[Location]int
+
+    TargetCache *rttCache // RTT des cibles déjà mesurées ; nil : toujours remesurer
+    // ServerTTL réutilise la mesure d'un serveur datant de moins de ServerTTL
+    // (voir Server.fresh) ; 0 : toujours remesurer
+    ServerTTL time.Duration
+    DNSCache    *dnsCache // résolutions DNS récentes ; nil : toujours résoudre
+
+    // Concurrency borne les mesures de serveurs simultanées (--concurrency) ;
+    // nil : un worker par serveur
+    Concurrency *concurrencyLimit
+
+    Observer MeasurementObserver // notifié au fil des mesures ; nil : aucun
+
+    // OnResult reçoit le résultat de chaque serveur ayant répondu dès sa
+    // mesure (équivalent programmatique de --output=ndjson). Les appels
+    // viennent d'une seule goroutine : la fonction n'a pas à être
+    // thread-safe, mais ne doit pas bloquer, sous peine de retarder la
+    // progression et la fin de la campagne. Non appelé sans cible (benchmark).
+    OnResult func(Result)
+}
+
+// MeasurementObserver suit une campagne au fil de l'eau (--output=ndjson).
+// ServerMeasured est appelé depuis une seule goroutine à la fois.
+type MeasurementObserver interface {
+    TargetMeasured(target Target)
+    ServerMeasured(m Measurement)
+}
+
+func (o Options) withDefaults() Options {
+    if o.Count <= 0 {
+        o.Count = defaultServerPingCount
+    }
+    if o.TargetCount <= 0 {
+        o.TargetCount = defaultTargetPingCount
+    }
+    if o.Timeout <= 0 {
+        o.Timeout = defaultPingTimeout
+    }
+    if o.Measurer == nil {
+        o.Measurer = icmpMeasurer{Timeout: o.Timeout, Size: o.PacketSize, Source: o.Source, Sampling: o.Sampling}
+    }
+    if o.Model == nil {
+        o.Model, _ = geo.LookupDistanceModel(geo.DefaultDistanceModel)
+    }
+    if o.Rank == "" {
+        o.Rank = rankDelta
+    }
+    if o.Geometry == "" {
+        o.Geometry = geometryDelta
+    }
+    if o.Weighting == "" {
+        o.Weighting = weightingEqual
+    }
+    if o.Collapse == "" {
+        o.Collapse = collapseNone
+    }
+    if o.Solver == nil {
+        o.Solver, _ = geo.LookupSolver(geo.DefaultSolver)
+    }
+    if o.MinLocations <= 0 {
+        o.MinLocations = defaultMinLocations
+    }
+    if o.Rand == nil {
+        o.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+    }
+    return o
+}
+
+// Measurement est le résultat brut du ping d'un serveur de référence,
+// conservé tel quel pour pouvoir rejouer l'analyse (--save-session).
+type Measurement struct {
+    Server    Server        `json:"server"`
+    RTT       time.Duration `json:"rtt_ns"`
+    Jitter    time.Duration `json:"jitter_ns,omitempty"`
+    Error     string        `json:"error,omitempty"`
+    Cancelled bool          `json:"cancelled,omitempty"` // interrompue (deadline) avant la fin
+}
+
+// SweepSummary résume une campagne de mesures, éventuellement partielle.
+type SweepSummary struct {
+    Total     int  // serveurs prévus
+    Measured  int  // mesures menées à terme (réponse ou échec)
+    Responded int
+    Partial   bool // au moins une mesure a été interrompue
+
+    Interrupted bool // campagne arrêtée par Ctrl-C (renseigné par l'appelant)
+    Concurrency int  // workers retenus par --concurrency=auto (renseigné par l'appelant)
+}
+
+func summarizeSweep(measurements []Measurement) SweepSummary {
+    summary := SweepSummary{Total: len(measurements)}
+    for _, m := range measurements {
+        switch {
+        case m.Cancelled:
+            summary.Partial = true
+        case m.Error == "":
+            summary.Responded++
+            summary.Measured++
+        default:
+            summary.Measured++
+        }
+    }
+    return summary
+}
+
+// fresh indique si la mesure du serveur peut être réutilisée : il a répondu
+// il y a moins de ttl. Le RTT d'un serveur ne dépend que du poste de mesure,
+// pas de la cible : une mesure fraîche reste valable pour une autre cible,
+// tant que le poste, l'adresse source et les paramètres de ping sont les
+// mêmes (ce qui n'est pas vérifié). Les échecs sont toujours remesurés.
+func (s Server) fresh(ttl time.Duration) bool {
+    return ttl > 0 && !s.MeasuredAt.IsZero() && time.Since(s.MeasuredAt) <= ttl
+}
+
+// measuredServers retourne les serveurs d'une campagne complétés par leur
+// mesure (AvgRTT, Jitter, Loss, MeasuredAt), dans le même ordre : à conserver
+// et repasser à la campagne suivante pour, avec Options.ServerTTL, ne pas
+// remesurer les serveurs encore frais.
+func measuredServers(measurements []Measurement) []Server {
+    servers := make([]Server, len(measurements))
+    for i, m := range measurements {
+        servers[i] = m.Server
+    }
+    return servers
+}
+
+// completedMeasurement est une mesure terminée et la position de son
+// serveur dans la liste de la campagne.
+type completedMeasurement struct {
+    index int
+    Measurement
+}
+
+// sweepServers pinge en parallèle tous les serveurs de référence en affichant
+// la progression, au plus opts.Concurrency à la fois. Les mesures sont
+// retournées dans l'ordre des serveurs, quel que soit l'ordre de complétion ;
+// leur Server est complété par la mesure (voir measuredServers). Les serveurs
+// frais au sens de opts.ServerTTL ne sont pas repingés. À l'annulation du
+// contexte, les mesures en cours sont interrompues et marquées Cancelled.
+// targetRTT sert aux résultats passés à OnResult ; 0 : campagne sans cible.
+func sweepServers(ctx context.Context, servers []Server, targetRTT time.Duration, opts Options) []Measurement {
+    opts = opts.withDefaults()
+
+    var wg sync.WaitGroup
+    measurements := make([]Measurement, len(servers))
+
+    // Canal de complétion : un événement par serveur terminé (succès ou échec),
+    // consommé par une seule goroutine qui range la mesure et notifie la
+    // progression et l'observateur ; les workers ne partagent aucun état
+    completed := make(chan completedMeasurement, len(servers))
+    collected := make(chan struct{})
+
+    bar := newProgressBar(opts.Progress, len(servers))
+    go func() {
+        for c := range completed {
+            measurements[c.index] = c.Measurement
+            bar.Increment(c.Error == "")
+            if opts.Observer != nil {
+                opts.Observer.ServerMeasured(c.Measurement)
+            }
+            if opts.OnResult != nil && targetRTT > 0 && c.Error == "" {
+                opts.OnResult(newResult(c.Server, c.RTT, c.Jitter, targetRTT, opts))
+            }
+        }
+        bar.Finish()
+        close(collected)
+    }()
+
+    for i, s := range servers {
+        if s.fresh(opts.ServerTTL) {
+            slog.Debug("server RTT reused", "server", s.Name, "ip", s.IP, "rtt", s.AvgRTT,
+                "age", time.Since(s.MeasuredAt))
+            completed <- completedMeasurement{index: i, Measurement: Measurement{Server: s, RTT: s.AvgRTT, Jitter: s.Jitter}}
+            continue
+        }
+
+        if !opts.Concurrency.acquire(ctx) {
+            m := Measurement{Server: s, Error: ctx.Err().Error(), Cancelled: true}
+            m.Server.AvgRTT, m.Server.Jitter, m.Server.Loss, m.Server.MeasuredAt = 0, 0, 1, time.Time{}
+            completed <- completedMeasurement{index: i, Measurement: m}
+            continue
+        }
+        wg.Add(1)
+        go func(index int, server Server) {
+            defer wg.Done()
+
+            // La base peut désigner un serveur par son nom : résolu ici, via
+            // le cache, plutôt qu'à chaque ping par le Measurer
+            ip, err := opts.DNSCache.Resolve(server.IP)
+            var stats PingStats
+            if err == nil {
+                stats, err = opts.serverMeasurer().Measure(ctx, ip, opts.Count)
+            }
+            m := Measurement{Server: server}
+            if err != nil {
+                m.Server.AvgRTT, m.Server.Jitter, m.Server.Loss, m.Server.MeasuredAt = 0, 0, 1, time.Time{}
+                m.Error = err.Error()
+                m.Cancelled = errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+                slog.Debug("server unreachable", "server", server.Name, "ip", server.IP, "error", err)
+            } else {
+                m.Server.AvgRTT, m.Server.Jitter, m.Server.Loss, m.Server.MeasuredAt = stats.Avg, stats.StdDev, stats.Loss(), time.Now()
+                m.RTT, m.Jitter = stats.Avg, stats.StdDev
+                slog.Debug("server measured", "server", server.Name, "ip", server.IP,
+                    "rtt", stats.Avg, "jitter", stats.StdDev)
+            }
+
+            opts.Concurrency.release(m)
+            completed <- completedMeasurement{index: index, Measurement: m}
+        }(i, s)
+
+        // délai pour éviter de surcharger(bug une fois sur deux...)
+        select {
+        case <-time.After(10 * time.Millisecond):
+        case <-ctx.Done():
+        }
+    }
+
+    wg.Wait()
+    close(completed)
+    <-collected
+
+    summary := summarizeSweep(measurements)
+    slog.Info("sweep complete", "responded", summary.Responded, "measured", summary.Measured,
+        "total", summary.Total, "partial", summary.Partial)
+    return measurements
+}
+
+// runMeasurements mesure le RTT de la cible puis celui de tous les serveurs
+// de référence. Retourne une erreur si la cible est injoignable.
+func runMeasurements(ctx context.Context, target *Target, servers []Server, opts Options) ([]Measurement, error) {
+    opts = opts.withDefaults()
+
+    stats, age, cached := opts.TargetCache.Get(*target)
+    if cached {
+        slog.Info("target RTT from cache", "target", target.Input, "ip", target.IP, "rtt", stats.Avg, "age", age)
+    } else {
+        var err error
+        if stats, err = measureTarget(ctx, *target, opts); err != nil {
+            return nil, err
+        }
+        opts.TargetCache.Put(*target, stats)
+        slog.Info("target measured", "target", target.Input, "ip", target.IP, "rtt", stats.Avg, "jitter", stats.StdDev)
+    }
+    target.RTT, target.Jitter, target.Cached = stats.Avg, stats.StdDev, cached
+    if opts.Observer != nil {
+        opts.Observer.TargetMeasured(*target)
+    }
+
+    // Ping parallèle des serveurs
+    slog.Info("probing reference servers", "count", len(servers))
+    return sweepServers(ctx, servers, target.RTT, opts), nil
+}
+
+// measureTarget mesure le RTT de la cible, avec repli sur TCP si activé.
+// Le port de la cible n'est utilisé que par ce repli.
+func measureTarget(ctx context.Context, target Target, opts Options) (PingStats, error) {
+    stats, err := opts.Measurer.Measure(ctx, target.IP, opts.TargetCount)
+    if err == nil || ctx.Err() != nil || !(opts.TCPFallback || target.Port != 0) {
+        return stats, err
+    }
+
+    port := target.Port
+    if port == 0 {
+        port = defaultTCPPort
+    }
+    slog.Info("target ignores ICMP, falling back to TCP", "ip", target.IP, "port", port, "error", err)
+    return tcpMeasurer{Port: port, Source: opts.Source, Sampling: opts.Sampling}.Measure(ctx, target.IP, opts.TargetCount)
+}
+
+// newResult calcule l'écart de latence d'un serveur avec la cible et la
+// distance estimée correspondante selon le modèle des options. La gigue du
+// serveur, propagée par le modèle, donne l'incertitude sur cette distance ;
+// celle de la cible, commune à tous les serveurs, n'y est pas comptée.
+func newResult(server Server, rtt, jitter, targetRTT time.Duration, opts Options) Result {
+    server.AvgRTT, server.Jitter = rtt, jitter
+    // Le RTT affiché reste celui mesuré ; seuls les calculs sont corrigés
+    rtt -= opts.skewFor(server)
+    if rtt < 0 {
+        rtt = 0
+    }
+    delta := rtt - targetRTT
+    if delta < 0 {
+        delta = -delta
+    }
+
+    // Inégalité triangulaire depuis le poste de mesure M :
+    // |d(M,S) - d(M,C)| <= d(C,S) <= d(M,S) + d(M,C)
+    // Distance est la borne basse ; la borne haute cumule les délais fixes du
+    // serveur et de la cible
+    baseline := opts.baselineFor(server)
+    low := delta - jitter
+    if low < 0 {
+        low = 0
+    }
+    return Result{
+        Server:        server,
+        Delta:         delta,
+        Distance:      opts.Model.Distance(delta, baseline),
+        DistanceError: (opts.Model.Distance(delta+jitter, baseline) - opts.Model.Distance(low, baseline)) / 2,
+        MaxDistance:   opts.Model.Distance(rtt+targetRTT, baseline+opts.Baseline),
+    }
+}
+
+// buildResults convertit les mesures réussies en résultats triés par delta.
+func buildResults(measurements []Measurement, targetRTT time.Duration, opts Options) []Result {
+    opts = opts.withDefaults()
+
+    var results []Result
+    for _, m := range measurements {
+        if m.Error != "" {
+            continue
+        }
+        results = append(results, newResult(m.Server, m.RTT, m.Jitter, targetRTT, opts))
+    }
+
+    sortResults(results, opts.Rank)
+    return results
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "math/rand"
+    "testing"
+    "time"
+
+    "triangula/geo"
+)
+
+// fakeMeasurer répond avec un RTT fixe par IP, avec une gigue d'un dixième
+// du RTT ; les IP absentes sont injoignables. Sûr pour un usage concurrent.
+type fakeMeasurer map[string]time.Duration
+
+func (f fakeMeasurer) Measure(ctx context.Context, ip string, count int) (PingStats, error) {
+    if err := ctx.Err(); err != nil {
+        return PingStats{}, err
+    }
+    rtt, ok := f[ip]
+    if !ok {
+        return PingStats{Sent: count}, errors.New("no reply")
+    }
+    return PingStats{Avg: rtt, StdDev: rtt / 10, Sent: count, Received: count}, nil
+}
+
+// fakeCampaign retourne des serveurs et un fakeMeasurer dont les RTT sont
+// ceux d'un poste situé en vantage, la cible répondant en 1 ms.
+func fakeCampaign(vantage Location) ([]Server, fakeMeasurer) {
+    servers := []Server{
+        {Name: "paris", IP: "192.0.2.1", Country: "France", Lat: 48.8566, Lon: 2.3522},
+        {Name: "lyon", IP: "192.0.2.2", Country: "France", Lat: 45.764, Lon: 4.8357},
+        {Name: "frankfurt", IP: "192.0.2.3", Country: "Germany", Lat: 50.1109, Lon: 8.6821},
+        {Name: "berlin", IP: "192.0.2.4", Country: "Germany", Lat: 52.52, Lon: 13.405},
+        {Name: "madrid", IP: "192.0.2.5", Country: "Spain", Lat: 40.4168, Lon: -3.7038},
+        {Name: "london", IP: "192.0.2.6", Country: "United Kingdom", Lat: 51.5074, Lon: -0.1278},
+        {Name: "new-york", IP: "192.0.2.7", Country: "United States", Lat: 40.7128, Lon: -74.006},
+        {Name: "sao-paulo", IP: "192.0.2.8", Country: "Brazil", Lat: -23.5505, Lon: -46.6333},
+        {Name: "tokyo", IP: "192.0.2.9", Country: "Japan", Lat: 35.6762, Lon: 139.6503},
+        {Name: "sydney", IP: "192.0.2.10", Country: "Australia", Lat: -33.8688, Lon: 151.2093},
+        {Name: "muet", IP: "192.0.2.11", Country: "Italy", Lat: 41.9028, Lon: 12.4964},
+    }
+    measurer := fakeMeasurer{"198.51.100.1": time.Millisecond}
+    for _, s := range servers[:len(servers)-1] {
+        km := geo.Distance(vantage.Lat, vantage.Lon, s.Lat, s.Lon)
+        measurer[s.IP] = time.Millisecond + time.Duration(2*km/geo.FiberSpeed*float64(time.Second))
+    }
+    return servers, measurer
+}
+
+func TestSeededRunsAreReproducible(t *testing.T) {
+    servers, measurer := fakeCampaign(Location{Lat: 47.2, Lon: 4.1})
+
+    type outcome struct {
+        results []Result
+        est     Estimates
+        ellipse Ellipse
+        sample  []Server
+    }
+    run := func(seed int64) outcome {
+        opts := Options{Measurer: measurer, Rand: rand.New(rand.NewSource(seed))}.withDefaults()
+        target := Target{Input: "198.51.100.1", IP: "198.51.100.1"}
+        measurements, err := runMeasurements(context.Background(), &target, servers, opts)
+        if err != nil {
+            t.Fatalf("runMeasurements: %v", err)
+        }
+        var o outcome
+        o.results = buildResults(measurements, target.RTT, opts)
+        if o.est, err = estimatePositions(o.results, opts); err != nil {
+            t.Fatalf("estimatePositions: %v", err)
+        }
+        var ok bool
+        if o.ellipse, ok = monteCarloEllipse(measurements, target, opts, 50); !ok {
+            t.Fatal("monteCarloEllipse failed")
+        }
+        o.sample = sampleServers(servers, 5, rand.New(rand.NewSource(seed)))
+        return o
+    }
+
+    first, second := run(42), run(42)
+    if len(first.results) != len(servers)-1 {
+        t.Errorf("got %d results, want %d (one server does not reply)", len(first.results), len(servers)-1)
+    }
+    for i := range first.results {
+        if a, b := first.results[i], second.results[i]; a.Server.Name != b.Server.Name || a.Delta != b.Delta {
+            t.Errorf("result %d differs between runs: %s (%v) and %s (%v)", i, a.Server.Name, a.Delta, b.Server.Name, b.Delta)
+        }
+    }
+    if first.est.LeastSquares != second.est.LeastSquares || first.est.Multilateration != second.est.Multilateration {
+        t.Errorf("estimates differ between runs: %v / %v and %v / %v", first.est.LeastSquares, first.est.Multilateration,
+            second.est.LeastSquares, second.est.Multilateration)
+    }
+    if first.ellipse != second.ellipse {
+        t.Errorf("Monte Carlo ellipses differ with the same seed: %+v and %+v", first.ellipse, second.ellipse)
+    }
+    if len(first.sample) != 5 {
+        t.Fatalf("sampleServers returned %d servers, want 5", len(first.sample))
+    }
+    for i := range first.sample {
+        if first.sample[i].Name != second.sample[i].Name {
+            t.Errorf("sampleServers differs with the same seed at %d: %s and %s", i, first.sample[i].Name, second.sample[i].Name)
+        }
+    }
+
+    // La graine pilote bien les tirages
+    if other := run(43); other.ellipse == first.ellipse {
+        t.Errorf("Monte Carlo ellipse unchanged with another seed: %+v", other.ellipse)
+    }
+}
+
+func TestOptionsKeepInjectedSources(t *testing.T) {
+    measurer, rng := fakeMeasurer{}, rand.New(rand.NewSource(1))
+    opts := Options{Measurer: measurer, Rand: rng}.withDefaults()
+    if _, ok := opts.Measurer.(fakeMeasurer); !ok || opts.Rand != rng {
+        t.Errorf("withDefaults replaced the injected Measurer or Rand")
+    }
+    if opts := (Options{}).withDefaults(); opts.Measurer == nil || opts.Rand == nil {
+        t.Errorf("withDefaults left Measurer or Rand nil")
+    }
+}
+
+// countingObserver compte les mesures notifiées par sweepServers.
+type countingObserver struct {
+    measured []Measurement
+}
+
+func (o *countingObserver) TargetMeasured(Target) {}
+
+func (o *countingObserver) ServerMeasured(m Measurement) {
+    o.measured = append(o.measured, m)
+}
+
+func TestSweepServersConcurrently(t *testing.T) {
+    const n = 100
+    servers := make([]Server, n)
+    measurer := fakeMeasurer{}
+    for i := range servers {
+        ip := fmt.Sprintf("10.0.%d.%d", i/256, i%256)
+        servers[i] = Server{Name: fmt.Sprintf("s%03d", i), IP: ip, Lat: float64(i%90) - 45, Lon: float64(i%360) - 180}
+        // Un serveur sur dix ne répond pas
+        if i%10 != 0 {
+            measurer[ip] = time.Duration(i+1) * time.Millisecond
+        }
+    }
+
+    tests := []struct {
+        name        string
+        concurrency string
+    }{
+        {name: "un worker par serveur"},
+        {name: "limite fixe", concurrency: "16"},
+        {name: "limite automatique", concurrency: concurrencyAuto},
+    }
+    for _, tt := range tests {
+        limit, err := parseConcurrency(tt.concurrency)
+        if err != nil {
+            t.Fatal(err)
+        }
+        observer := &countingObserver{}
+        var results []Result
+        opts := Options{Measurer: measurer, Concurrency: limit, Observer: observer,
+            OnResult: func(r Result) { results = append(results, r) }}
+
+        measurements := sweepServers(context.Background(), servers, time.Millisecond, opts)
+        if len(measurements) != n {
+            t.Fatalf("%s: got %d measurements, want %d", tt.name, len(measurements), n)
+        }
+        // Rangées dans l'ordre des serveurs, quel que soit l'ordre de complétion
+        for i, m := range measurements {
+            want, ok := measurer[servers[i].IP]
+            if m.Server.Name != servers[i].Name || m.RTT != want || (m.Error == "") != ok {
+                t.Errorf("%s: measurement %d = %s, %v, %q, want %s, %v", tt.name, i, m.Server.Name, m.RTT, m.Error,
+                    servers[i].Name, want)
+            }
+        }
+        if len(observer.measured) != n {
+            t.Errorf("%s: observer notified %d times, want %d", tt.name, len(observer.measured), n)
+        }
+        if len(results) != len(measurer) {
+            t.Errorf("%s: OnResult called %d times, want %d", tt.name, len(results), len(measurer))
+        }
+        if s := summarizeSweep(measurements); s.Responded != len(measurer) || s.Measured != n || s.Partial {
+            t.Errorf("%s: summary = %+v", tt.name, s)
+        }
+    }
+}
@@ -0,0 +1,798 @@
+package main
+
+import (
+    "os"
+    "strings"
+)
+
+const defaultLang = "en"
+
+// catalogs associe chaque langue à ses messages, indexés par identifiant.
+// Les chaînes de format (verbes %) doivent être identiques d'une langue à l'autre.
+var catalogs = map[string]map[string]string{
+    "en": {
+        "banner.title":         "       LATENCY-BASED IP TRIANGULATION SYSTEM",
+        "input.prompt":         "\nEnter the target IP or domain: ",
+        "target.pingError":     "\nError while pinging the target: %v\n",
+        "target.checkHeader":   "\nCheck that:",
+        "target.checkValid":    "   - The IP/domain is valid",
+        "target.checkRoot":     "   - You have root privileges (sudo)",
+        "target.checkFirewall": "   - The firewall allows ICMP",
+        "ping.noReply":         "no reply",
+        "sweep.noResponse":     "\nError: no server responded. Check your connection.",
+        "progress.line":        "[%s] %3d/%3d %3.0f%% | errors: %d | ETA: %v",
+
+        "results.title":       "ANALYSIS RESULTS - Target: %s (RTT: %v)\n",
+        "results.top":         "\nTOP 15 CLOSEST SERVERS (by latency similarity)",
+        "results.rowStats":    "        RTT: %6v | Jitter: %6v | Delta: %6v | Estimated distance: %s ± %s\n",
+        "results.fromVantage": "        Distance from you: %s\n",
+
+        "tri.notEnough":      "\nError: not enough servers for triangulation",
+        "tri.title":          "MATHEMATICAL TRIANGULATION",
+        "tri.method1":        "\nMETHOD 1: 3-point trilateration",
+        "tri.server":         "Server %d: %s (%s) - Distance: %s\n",
+        "tri.position":       "\nEstimated position: %.4f, %.4f\n",
+        "tri.method2":        "\nMETHOD 2: Weighted multilateration (top %d servers)\n",
+        "tri.position2":      "Estimated position: %.4f, %.4f\n",
+        "tri.visualTitle":    "\nTRIANGULATION TRIANGLE VISUALIZATION",
+        "tri.target":         "TARGET",
+        "tri.distancesTitle": "\nGEOGRAPHIC DISTANCES BETWEEN SERVERS",
+        "tri.coherenceTitle": "\nCOHERENCE ANALYSIS",
+        "tri.coherence":      "Triangulation coherence: %s\n",
+        "tri.avgDelta":       "Average delta (top 5): %v\n",
+        "tri.analyzed":       "Number of servers analyzed: %d\n",
+        "tri.locations":      "Distinct server locations: %d\n",
+        "tri.precision":      "Estimated precision: +/- %s\n",
+
+        "coherence.excellent": "EXCELLENT",
+        "coherence.good":      "GOOD",
+        "coherence.average":   "AVERAGE",
+        "coherence.weak":      "WEAK",
+
+        "stats.title":     "GLOBAL STATISTICS",
+        "stats.byCountry": "\nBreakdown by country (top 10):",
+        "stats.avgRTT":    "\nAverage RTT of all servers: %v\n",
+        "stats.total":     "Total number of servers tested: %d\n",
+
+        "flag.invalidUnits": "Error: invalid unit %q (expected km or mi)\n",
+
+        "flag.invalidLang": "Error: unknown language %q (expected en or fr)\n",
+
+        "flag.invalidICMPSocket": "Error: invalid ICMP socket %q (expected auto, raw or datagram)\n",
+        "icmp.hintLinuxRaw":      "raw ICMP sockets need root or CAP_NET_RAW: run with sudo, grant it with sudo setcap cap_net_raw+ep ./triangula, or use --icmp-socket=datagram",
+        "icmp.hintLinuxDatagram": "unprivileged ICMP sockets are not allowed for this group: allow them with sudo sysctl -w net.ipv4.ping_group_range=\"0 2147483647\", run with sudo and --icmp-socket=auto or raw, or use --backend=system",
+        "icmp.hintDarwinRaw":     "raw ICMP sockets need sudo on macOS; unprivileged ones work without it: use --icmp-socket=auto",
+        "icmp.hintWindows":       "ICMP needs an Administrator prompt on Windows",
+        "icmp.hintOther":         "ICMP sockets need root on this platform: run with sudo, or use --backend=system",
+
+        "results.ptr":   "Reverse DNS: %s\n",
+        "results.noPTR": "Reverse DNS: (no PTR record)",
+
+        "results.asn": "Network: %s\n",
+
+        "tri.nearestCity": "Nearest known city: %s, %s (%s)\n",
+
+        "tri.country":          "Estimated country: %s (%.0f%% of nearby anchors)\n",
+        "tri.countryAmbiguous": "Estimated country: ambiguous (%s)\n",
+
+        "geoip.title":     "GEOIP COMPARISON - %s\n",
+        "geoip.openError": "Error: cannot open GeoIP database: %v\n",
+        "geoip.error":     "%s lookup failed: %v\n",
+        "geoip.location":  "%s position: %s (%.4f, %.4f)\n",
+        "geoip.distance":  "Triangulated (method %d) vs %s: %s apart\n",
+        "geoip.agree":     "=> GeoIP and triangulation agree: high confidence",
+        "geoip.diverge":   "=> GeoIP and triangulation diverge: check for VPN, anycast or an outdated GeoIP entry",
+
+        "geoip.notLocatable": "Private or reserved address: no public location to compare against.",
+        "geoip.rateLimited":  "%s: request quota exhausted, comparison skipped (%v)\n",
+
+        "md.comparison":    "%s position (%s)",
+        "md.comparisonGap": "%s from method 3",
+
+        "flag.invalidCompare": "Error: --compare: %v (available: %s)\n",
+
+        "session.loadError": "Error: cannot load session: %v\n",
+
+        "flag.invalid": "Error: %v\n",
+
+        "serve.error": "Error: service stopped: %v\n",
+
+        "input.empty.short": "no target provided",
+        "input.invalidURL":  "cannot extract a host from URL %q",
+        "input.invalidHost": "%q is neither an IP address nor a valid hostname",
+        "input.invalid":     "\nError: %v\n",
+
+        "input.invalidPort": "invalid port %q",
+
+        "results.partial": "(partial results: deadline reached, %d/%d servers measured)\n",
+
+        "flag.invalidCount":   "Error: --count and --target-count must be positive (got %d and %d)\n",
+        "flag.invalidTimeout": "Error: --timeout must be positive (got %v)\n",
+
+        "flag.invalidModel": "Error: unknown distance model %q (available: %s)\n",
+
+        "flag.invalidMinLocations": "Error: --min-locations must be at least 3 (got %d)\n",
+        "flag.invalidHopDelay":     "Error: --hop-delay must be positive or 0 (got %v)\n",
+
+        "flag.invalidAdaptive": "Error: --adaptive-stddev must be positive or 0 and --min-count at least 2 (got %v, %d)\n",
+
+        "flag.invalidMonteCarlo": "Error: --monte-carlo must be positive or 0 (got %d)\n",
+        "mc.title":               "UNCERTAINTY (MONTE CARLO)",
+        "mc.samples":             "Re-solves: %d (ellipse at %.0f%%)\n",
+        "mc.center":              "Mean position: %.4f, %.4f\n",
+        "mc.axes":                "Semi-major axis: %s | Semi-minor axis: %s\n",
+        "mc.orientation":         "Orientation: %.0f° from north\n",
+        "md.uncertainty":         "Uncertainty ellipse (%.0f%%, %d re-solves)",
+        "md.uncertaintyAxes":     "%s x %s, %.0f° from north",
+
+        "flag.invalidWatch": "Error: --watch and --process-noise must not be negative and --measurement-noise must be positive (got %v, %g, %g)\n",
+        "watch.header":      "\nWATCH MODE (Ctrl+C to stop) - raw vs smoothed position (method 2)",
+        "watch.line":        "#%d %s | raw: %.4f, %.4f | smoothed: %.4f, %.4f | gap: %s",
+
+        "flag.invalidSolver": "Error: unknown solver %q (available: %s)\n",
+        "tri.method3":        "\nMETHOD 3: Least-squares fit (top %d servers)\n",
+
+        "flag.invalidWeighting": "Error: unknown weighting %q (expected equal, or inverse-variance and/or colocation separated by commas)\n",
+
+        "tri.method4":        "\nMETHOD 4: 2D circle intersection (top %d distinct locations, local tangent plane)\n",
+        "tri.circleGap":      "Distance to method 3: %s\n",
+        "tri.noIntersection": "No two distance circles intersect: the distances are mutually inconsistent.",
+        "md.method4":         "4. Circle intersection (2D)",
+        "md.noIntersection":  "circles do not intersect",
+
+        "tri.method5":    "\nMETHOD 5: Bounding-box intersection (top %d servers)\n",
+        "tri.coarse":     "COARSE ESTIMATE: the circles do not intersect and the least-squares fit is unreliable; this box is a last resort.",
+        "tri.box":        "Overlap box: %s wide x %s high (lat %.2f to %.2f, lon %.2f to %.2f)\n",
+        "tri.boxSkipped": "%d server box(es) left out: they did not overlap the others.\n",
+        "md.method5":     "5. Bounding boxes (coarse)",
+        "md.box":         "box %s x %s",
+
+        "tri.tooFew":       "\nOnly %d server(s) responded: geometric triangulation needs at least 3 and is skipped.\n",
+        "tri.tooFewHint":   "The results and the latency-similarity region above still apply.",
+        "tri.fewLocations": "\nError: the responding servers occupy only %d distinct locations (at least %d needed).\nCo-located servers (e.g. several in Frankfurt) count once: widen the filters, raise --count to ride out packet loss, or check why servers elsewhere did not respond.\n",
+        "tri.lowDiversity": "\nError: insufficient geometric diversity: the responding servers span only %s (at least %s needed).\nWiden the country/continent filter, or check why servers in other regions did not respond.\n",
+
+        "flag.invalidIRLS": "Error: --irls-loss must be huber or tukey and --irls-threshold positive (got %q, %g)\n",
+        "tri.weights":      "Final server weights (1 = kept, < 1 = discounted as outlier):",
+
+        "flag.invalidGeometry": "Error: unknown geometry %q (expected delta or bounds)\n",
+
+        "sim.title":         "METHOD: Latency similarity",
+        "sim.servers":       "Servers with the closest RTT (anycast excluded): %s\n",
+        "sim.region":        "Estimated region: %s (%.0f%% of these servers), around %.4f, %.4f\n",
+        "sim.spread":        "Spread of these servers: %s\n",
+        "sim.confidence":    "Confidence: %s\n",
+        "similarity.high":   "HIGH",
+        "similarity.medium": "MEDIUM",
+        "similarity.low":    "LOW",
+        "tri.caveat":        "(distances are lower bounds derived from latency differences, see README)",
+
+        "flag.invalidBackend": "Error: unknown backend %q (expected icmp or system)\n",
+
+        "trace.title": "TRACEROUTE REFINEMENT",
+        "trace.none":  "No server shares a usable path segment with the target.",
+        "trace.line":  "%-20s | last common hop %s (%v) | %d hops after it | distance %s -> %s\n",
+
+        "tri.residualFloor": "Residual floor (best achievable RMS fit anywhere): %s\n",
+        "tri.possibleProxy": "=> Possible VPN/proxy: no single location fits the measured distances within the estimated precision",
+
+        "flag.invalidHeatmap": "Error: --heatmap-radius and --heatmap-step must be positive (got %g, %g)\n",
+        "heatmap.written":     "\nHeatmap: %d cells written to %s\n",
+        "residuals.written":   "Residuals: %d servers written to %s\n",
+
+        "region.area":        "Probable region (fit within %[2]s of the best): %[1]s\n",
+        "flag.invalidRegion": "Error: --region-threshold must not be negative (got %g)\n",
+
+        "flag.invalidOutput": "Error: unknown output format %q (expected text, markdown, ndjson or json)\n",
+        "md.title":           "Analysis results - Target: %s (RTT: %v)",
+        "md.serversHeader":   "| # | Server | Country | City | RTT | Jitter | Delta | Estimated distance |",
+        "md.methodsHeader":   "| Method | Latitude | Longitude | Nearest known city | Map |",
+        "md.method1":         "1. Trilateration",
+        "md.method2":         "2. Weighted multilateration",
+        "md.method3":         "3. Least squares",
+        "md.summaryHeader":   "| Indicator | Value |",
+        "md.coherence":       "Coherence",
+        "md.avgDelta":        "Average delta (top 5)",
+        "md.analyzed":        "Servers analyzed",
+        "md.locations":       "Distinct server locations",
+        "md.precision":       "Estimated precision",
+        "md.residualFloor":   "Residual floor",
+        "md.region":          "Probable region area",
+
+        "flag.historyNeedsDB": "Error: --history requires --db",
+        "history.error":       "Error: cannot read history: %v\n",
+        "history.title":       "Estimated location history for %s (least squares)\n",
+        "history.row":         "%s | %-15s | RTT: %8v | %.4f, %.4f +/- %s | moved: %s\n",
+        "history.rowNone":     "%s | %-15s | RTT: %8v | (not triangulated)\n",
+        "history.empty":       "No run recorded for this target.",
+
+        "flag.invalidRank": "Error: unknown ranking %q (expected delta or delta+jitter)\n",
+
+        "flag.invalidProximity": "Error: --proximity must be fixed or adaptive and --proximity-thresholds three increasing positive values in ms, e.g. 50,100,200 (got %q, %q)\n",
+
+        "results.cached": "(target RTT reused from cache, see --target-cache-ttl)",
+        "watch.cached":   " (cached target RTT)",
+
+        "results.interrupted": "(interrupted, %d servers measured)\n",
+        "interrupt.first":     "Interrupted: finishing with the servers measured so far (Ctrl-C again to quit).",
+        "interrupt.noTarget":  "Interrupted before the target was measured.",
+
+        "plan.title":      "\nMEASUREMENT PLAN (dry run, nothing was sent)",
+        "plan.target":     "  Target: %s\n",
+        "plan.unresolved": "  Target: %s (cannot be resolved)\n",
+        "plan.servers":    "  Servers: %d, measured concurrently by %d workers\n",
+        "plan.duration":   "  Estimated duration: %v (target %v, sweep %v)\n",
+        "plan.deadline":   "  Measurement phase capped by --deadline at %v\n",
+        "plan.traceroute": "  Traceroute: up to %v more\n",
+        "plan.flags":      "  Flags in effect:",
+        "plan.noFlags":    "  Flags in effect: defaults",
+
+        "list.header":        "NAME                     IP               COUNTRY         CITY                  LAT       LON  FLAGS",
+        "list.mdHeader":      "| Name | IP | Country | City | Lat | Lon | Flags |",
+        "list.total":         "%d servers\n",
+        "list.error":         "Error: %v\n",
+        "flag.jsonNeedsList": "Error: --output=json is only available with --list and benchmark",
+
+        "flag.invalidServeConcurrency": "Error: --serve-concurrency must be positive (got %d)\n",
+        "flag.invalidConcurrency":      "Error: --concurrency must be a positive number of workers or auto (got %q)\n",
+
+        "coord.title":           "MULTI-VANTAGE TRIANGULATION - %d vantage points - Target: %s\n",
+        "coord.unknownVantage":  "location unknown",
+        "coord.vantage":         "\n%s (%s) | target RTT: %v | %d servers responded\n",
+        "coord.vantageEstimate": "   Own estimate: %.4f, %.4f\n",
+        "coord.vantageNone":     "   Own estimate: not enough servers",
+        "coord.combined":        "\nCOMBINED FIT (all vantage points)",
+        "coord.anchors":         "%d anchors, RMS residual %s\n",
+
+        "flag.agentNeedsOut": "Error: --agent requires --out=report.json and cannot be combined with --replay",
+        "agent.error":        "Error: cannot write the agent report: %v\n",
+        "agent.written":      "\nAgent report written to %s (%d/%d servers responded)\n",
+
+        "outdir.error": "Error: cannot use --out-dir: %v\n",
+
+        "tz.approximate": "UTC%+d (%s, from longitude)",
+        "tz.line":        "Probable timezone: %s - local time %s\n",
+        "tz.lineNoTime":  "Probable timezone: %s\n",
+        "tz.loadError":   "Error: cannot load timezone polygons: %v\n",
+
+        "flag.invalidVelocity": "Error: --velocity-factor must be in (0,1] (got %g)\n",
+        "flag.velocityModel":   "Error: --velocity-factor only applies to the %s model (got --model=%s)\n",
+
+        "filter.summary": "%d servers filtered out by --include/--exclude, %d kept\n",
+
+        "flag.invalidSample": "Error: --sample must be 0 (all servers) or positive (got %d)\n",
+
+        "bench.title":       "NETWORK BENCHMARK - RTT by continent (ms)",
+        "bench.header":      "REGION            SRV    OK       MIN    MEDIAN       P90       MAX",
+        "bench.mdHeader":    "| Region | Servers | Responded | Min (ms) | Median (ms) | P90 (ms) | Max (ms) |",
+        "bench.total":       "%d/%d servers responded\n",
+        "bench.unreachable": "Unreachable: %s\n",
+
+        "reach.title":    "REACHABILITY - one packet per server",
+        "reach.header":   "REGION            SRV    UP",
+        "reach.mdHeader": "| Region | Servers | Up | Down |",
+        "reach.down":     "    down: %s\n",
+        "reach.total":    "%d/%d servers up (checked in %s)\n",
+
+        "flag.calibrateNeeds": "Error: calibrate requires --vantage (this machine's lat,lon) and --out",
+        "calib.error":         "Error: calibration failed: %v\n",
+        "calib.title":         "SERVER CALIBRATION",
+        "calib.common":        "Common offset: %v (median over %d located servers, not included in the skews)\n",
+        "calib.top":           "Highest-skew servers (consider dropping them with --exclude):",
+        "calib.written":       "\nCalibration written to %s (%d servers); use it with --calibration\n",
+
+        "flag.updateDBNeeds": "Error: update-db requires --db-url and --db-pubkey and/or --db-sha256",
+        "db.error":           "Error: database update failed: %v\n",
+        "db.updated":         "Server database updated: %d servers (%d added, %d removed, %d changed)\n",
+        "db.added":           "  Added: %s\n",
+        "db.removed":         "  Removed: %s\n",
+        "db.changed":         "  Changed: %s\n",
+
+        "incons.header":  "Warning: %d server(s) answer faster than light allows from their claimed location (anycast address or wrong coordinates):\n",
+        "incons.vantage": "   %-20s | %-15s | %-12s | RTT %v, but at least %v from this vantage point\n",
+        "incons.pair":    "   %-20s | %-15s | %-12s | RTT %v, but with %s (%s, %v) at least %v\n",
+        "incons.hint":    "   Use --drop-inconsistent to leave them out of the triangulation.",
+        "incons.dropped": "   Left out of the triangulation (--drop-inconsistent).",
+
+        "flag.invalidPacketSize": "Error: --packet-size %d out of range (%d-%d bytes, or 0 for the default)\n",
+
+        "flag.invalidSource": "invalid probe source: %v\n",
+
+        "meta.run":         "Run: %s, triangula %s",
+        "meta.servers":     "Servers: %d in database, %d probed, %d responded",
+        "meta.model":       "Model: %s, solver: %s",
+        "meta.vantage":     "Vantage point: %.4f, %.4f",
+        "meta.concurrency": "Concurrency: settled on %d workers (--concurrency=auto)",
+        "meta.flags":       "Options: %s",
+        "meta.noFlags":     "(defaults)",
+
+        "stats.unknownCountry": "Unknown",
+
+        "stats.medianRTT":  "Median RTT of all servers: %v\n",
+        "stats.unicastRTT": "Excluding anycast and Global servers (%d): average %v, median %v\n",
+        "stats.noUnicast":  "Excluding anycast and Global servers: none responded",
+
+        "stats.byRegion":          "\nServers reached by region (responded/measured):",
+        "stats.regionUnreachable": "Warning: no server responded in %s: estimates toward this region are unreliable.\n",
+
+        "stats.byProvider":       "\nBreakdown by provider (top 10):",
+        "stats.providerDominant": "Warning: %s operates %d of the %d closest servers: its anycast or edge routing may bias the estimate.\n",
+
+        "vantage.farServer":  "%s (%v, %s away)",
+        "vantage.farWarning": "\nWarning: the fastest servers from here are far from your location: %s, while the nearest responding server, %s, answers in %v from %s away. Their addresses are probably anycast, or their coordinates wrong.\n",
+
+        "flag.vantageConflict": "Error: --vantage=%s and --my-location=%s name different locations (--my-location is an alias of --vantage)\n",
+
+        "tri.divergence":      "Divergence between methods 1 and 2: %s\n",
+        "tri.divergent":       "WARNING: trilateration and multilateration disagree by more than %s, the result is unreliable.\n",
+        "tri.divergentCauses": "Possible causes: anycast servers among the best matches, network congestion, too few distinct server locations.",
+        "md.divergence":       "Divergence (methods 1 and 2)",
+
+        "tri.verify":         "Verification: %s is %s from the estimate; re-measured over %d pings, its RTT puts it %s from the target (gap %s, tolerance %s)\n",
+        "tri.verifyOK":       "=> The nearest server confirms the estimate",
+        "tri.verifyLoose":    "=> Loosely consistent: the gap exceeds the tolerance, but not by enough to reject the estimate",
+        "tri.verifySuspect":  "=> SUSPECT estimate: the latency of the nearest server contradicts it (anycast or misplaced server, proxy, or a bad fit)",
+        "tri.verifyFailed":   "Verification: %s did not answer (%v)\n",
+        "tri.verifyNone":     "Verification: no located server responded near the estimate",
+        "md.verification":    "Verification (%s)",
+        "md.verificationGap": "gap %s (tolerance %s)",
+
+        "tri.vertex":       "est. %s | fit %s",
+        "tri.visualLegend": "Vertices: distance estimated from the RTT (est.) and distance to the trilaterated position (fit); edges: distance between servers",
+
+        "map.title":            "WORLD MAP",
+        "map.legend":           "%c estimate (method 3): %.2f, %.2f   %c servers used (%d)\n",
+        "map.legendVantage":    "%c your location: %.2f, %.2f\n",
+        "flag.invalidASCIIMap": "Error: --ascii-map-width %d out of range (%d-%d columns)\n",
+        "flag.asciiMapText":    "Error: --ascii-map is drawn in the text report only (no --output or --template)",
+
+        "map.osmArea":      "OpenStreetMap (uncertainty area)",
+        "flag.invalidMaps": "Error: invalid --maps %q (expected a comma-separated list of google, osm, osm-area)\n",
+
+        "hosting.title":   "HOSTING OR RESIDENTIAL ACCESS",
+        "hosting.verdict": "Likely hosting: %s (confidence: %s)\n",
+        "hosting.signals": "Best delta: %v | Target jitter: %v\n",
+        "hosting.caveat":  "(heuristic from the latency signature only, see README for its limits)",
+        "hosting.yes":     "yes",
+        "hosting.no":      "no",
+
+        "flag.invalidTemplate": "Error: invalid --template: %v\n",
+        "flag.templateOutput":  "Error: --template requires --output=text",
+        "template.error":       "Error: cannot render the report template: %v\n",
+
+        "continent.title":            "CONTINENT-LOCAL SOLVE",
+        "continent.none":             "No continent matched: no located server among the closest RTTs.",
+        "continent.match":            "Best-matching continent: %s (latency similarity, confidence %s)\n",
+        "continent.tooFew":           "Not enough usable servers in %s for a local solve (%d responded).\n",
+        "continent.local":            "Continent-local estimate (%d servers): %.4f, %.4f\n",
+        "continent.global":           "Global estimate (method 3): %.4f, %.4f\n",
+        "continent.gap":              "Distance between them: %s\n",
+        "continent.fused":            "Fused estimate (%.0f%% continent, %.0f%% global): %.4f, %.4f\n",
+        "md.continent":               "Continent-local estimate, %s (%d servers)",
+        "md.continentGap":            "%.4f, %.4f, %s from method 3",
+        "md.continentFused":          "Fused estimate (%.0f%% continent)",
+        "flag.invalidContinentSolve": "Error: unknown --continent-solve %q (expected off, local or fused)\n",
+
+        "tri.cities":          "Cities used by the solve (--collapse=city): %d\n",
+        "md.cities":           "Cities used by the solve",
+        "flag.invalidCollapse": "Error: unknown --collapse %q (expected none or city)\n",
+
+        "target.ipv6Only": "Error: %v.\nThe reference servers are measured over IPv4, so the latency to an IPv6-only target is not comparable and IPv6 measurement is not supported yet. To measure it anyway, pass one of these IPv6 addresses as the target.\n",
+
+        "done": "ANALYSIS COMPLETE",
+    },
+    "fr": {
+        "banner.title":         "       SYSTEME DE TRIANGULATION IP PAR LATENCE",
+        "input.prompt":         "\nEntrez l'IP ou domaine cible : ",
+        "target.pingError":     "\nErreur lors du ping de la cible: %v\n",
+        "target.checkHeader":   "\nVerifiez que:",
+        "target.checkValid":    "   - L'IP/domaine est valide",
+        "target.checkRoot":     "   - Vous avez les droits root (sudo)",
+        "target.checkFirewall": "   - Le firewall autorise ICMP",
+        "ping.noReply":         "aucune réponse",
+        "sweep.noResponse":     "\nErreur: Aucun serveur n'a répondu. Vérifiez votre connexion.",
+        "progress.line":        "[%s] %3d/%3d %3.0f%% | erreurs: %d | ETA: %v",
+
+        "results.title":       "RESULTATS DE L'ANALYSE - Cible: %s (RTT: %v)\n",
+        "results.top":         "\nTOP 15 SERVEURS LES PLUS PROCHES (par similarité de latence)",
+        "results.rowStats":    "        RTT: %6v | Gigue: %6v | Delta: %6v | Distance estimée: %s ± %s\n",
+        "results.fromVantage": "        Distance depuis votre position: %s\n",
+
+        "tri.notEnough":      "\nErreur: Pas assez de serveurs pour la triangulation",
+        "tri.title":          "TRIANGULATION MATHEMATIQUE",
+        "tri.method1":        "\nMETHODE 1: Trilatération 3-points",
+        "tri.server":         "Serveur %d: %s (%s) - Distance: %s\n",
+        "tri.position":       "\nPosition estimée: %.4f, %.4f\n",
+        "tri.method2":        "\nMETHODE 2: Multilatération pondérée (top %d serveurs)\n",
+        "tri.position2":      "Position estimée: %.4f, %.4f\n",
+        "tri.visualTitle":    "\nVISUALISATION DU TRIANGLE DE TRIANGULATION",
+        "tri.target":         "CIBLE",
+        "tri.distancesTitle": "\nDISTANCES GEOGRAPHIQUES ENTRE SERVEURS",
+        "tri.coherenceTitle": "\nANALYSE DE COHERENCE",
+        "tri.coherence":      "Cohérence de la triangulation: %s\n",
+        "tri.avgDelta":       "Delta moyen (top 5): %v\n",
+        "tri.analyzed":       "Nombre de serveurs analysés: %d\n",
+        "tri.locations":      "Emplacements de serveurs distincts: %d\n",
+        "tri.precision":      "Précision estimée: +/- %s\n",
+
+        "coherence.excellent": "EXCELLENTE",
+        "coherence.good":      "BONNE",
+        "coherence.average":   "MOYENNE",
+        "coherence.weak":      "FAIBLE",
+
+        "stats.title":     "STATISTIQUES GLOBALES",
+        "stats.byCountry": "\nRépartition par pays (top 10):",
+        "stats.avgRTT":    "\nRTT moyen de tous les serveurs: %v\n",
+        "stats.total":     "Nombre total de serveurs testés: %d\n",
+
+        "flag.invalidUnits": "Erreur: unité %q invalide (attendu: km ou mi)\n",
+
+        "flag.invalidLang": "Erreur: langue %q inconnue (attendu: en ou fr)\n",
+
+        "flag.invalidICMPSocket": "Erreur: socket ICMP %q invalide (attendu: auto, raw ou datagram)\n",
+        "icmp.hintLinuxRaw":      "les sockets ICMP brutes exigent root ou CAP_NET_RAW : lancez avec sudo, accordez-la avec sudo setcap cap_net_raw+ep ./triangula, ou utilisez --icmp-socket=datagram",
+        "icmp.hintLinuxDatagram": "les sockets ICMP non privilégiées ne sont pas autorisées pour ce groupe : autorisez-les avec sudo sysctl -w net.ipv4.ping_group_range=\"0 2147483647\", lancez avec sudo et --icmp-socket=auto ou raw, ou utilisez --backend=system",
+        "icmp.hintDarwinRaw":     "les sockets ICMP brutes exigent sudo sous macOS ; les non privilégiées fonctionnent sans : utilisez --icmp-socket=auto",
+        "icmp.hintWindows":       "l'ICMP exige une invite de commandes Administrateur sous Windows",
+        "icmp.hintOther":         "les sockets ICMP exigent root sur cette plateforme : lancez avec sudo, ou utilisez --backend=system",
+
+        "results.ptr":   "DNS inverse: %s\n",
+        "results.noPTR": "DNS inverse: (aucun enregistrement PTR)",
+
+        "results.asn": "Réseau: %s\n",
+
+        "tri.nearestCity": "Ville connue la plus proche: %s, %s (%s)\n",
+
+        "tri.country":          "Pays estimé: %s (%.0f%% des ancres proches)\n",
+        "tri.countryAmbiguous": "Pays estimé: ambigu (%s)\n",
+
+        "geoip.title":     "COMPARAISON GEOIP - %s\n",
+        "geoip.openError": "Erreur: impossible d'ouvrir la base GeoIP: %v\n",
+        "geoip.error":     "Échec de la recherche %s: %v\n",
+        "geoip.location":  "Position %s: %s (%.4f, %.4f)\n",
+        "geoip.distance":  "Triangulation (méthode %d) / %s: %s d'écart\n",
+        "geoip.agree":     "=> GeoIP et triangulation concordent: confiance élevée",
+        "geoip.diverge":   "=> GeoIP et triangulation divergent: VPN, anycast ou entrée GeoIP obsolète possibles",
+
+        "geoip.notLocatable": "Adresse privée ou réservée: aucune position publique à comparer.",
+        "geoip.rateLimited":  "%s: quota de requêtes épuisé, comparaison ignorée (%v)\n",
+
+        "md.comparison":    "Position %s (%s)",
+        "md.comparisonGap": "%s de la méthode 3",
+
+        "flag.invalidCompare": "Erreur: --compare: %v (disponibles: %s)\n",
+
+        "session.loadError": "Erreur: impossible de charger la session: %v\n",
+
+        "flag.invalid": "Erreur: %v\n",
+
+        "serve.error": "Erreur: arrêt du service: %v\n",
+
+        "input.empty.short": "aucune cible fournie",
+        "input.invalidURL":  "impossible d'extraire un hôte de l'URL %q",
+        "input.invalidHost": "%q n'est ni une adresse IP ni un nom d'hôte valide",
+        "input.invalid":     "\nErreur: %v\n",
+
+        "input.invalidPort": "port %q invalide",
+
+        "results.partial": "(résultats partiels: délai atteint, %d/%d serveurs mesurés)\n",
+
+        "flag.invalidCount":   "Erreur: --count et --target-count doivent être positifs (reçu %d et %d)\n",
+        "flag.invalidTimeout": "Erreur: --timeout doit être positif (reçu %v)\n",
+
+        "flag.invalidModel": "Erreur: modèle de distance %q inconnu (disponibles: %s)\n",
+
+        "flag.invalidMinLocations": "Erreur: --min-locations doit valoir au moins 3 (reçu %d)\n",
+        "flag.invalidHopDelay":     "Erreur: --hop-delay doit être positif ou 0 (reçu %v)\n",
+
+        "flag.invalidAdaptive": "Erreur: --adaptive-stddev doit être positif ou 0 et --min-count au moins 2 (reçu %v, %d)\n",
+
+        "flag.invalidMonteCarlo": "Erreur: --monte-carlo doit être positif ou 0 (reçu %d)\n",
+        "mc.title":               "INCERTITUDE (MONTE CARLO)",
+        "mc.samples":             "Recalculs: %d (ellipse à %.0f%%)\n",
+        "mc.center":              "Position moyenne: %.4f, %.4f\n",
+        "mc.axes":                "Demi-grand axe: %s | Demi-petit axe: %s\n",
+        "mc.orientation":         "Orientation: %.0f° depuis le nord\n",
+        "md.uncertainty":         "Ellipse d'incertitude (%.0f%%, %d recalculs)",
+        "md.uncertaintyAxes":     "%s x %s, %.0f° depuis le nord",
+
+        "flag.invalidWatch": "Erreur: --watch et --process-noise ne doivent pas être négatifs et --measurement-noise doit être positif (reçu %v, %g, %g)\n",
+        "watch.header":      "\nMODE SURVEILLANCE (Ctrl+C pour arrêter) - position brute et lissée (méthode 2)",
+        "watch.line":        "#%d %s | brute: %.4f, %.4f | lissée: %.4f, %.4f | écart: %s",
+
+        "flag.invalidSolver": "Erreur: solveur %q inconnu (disponibles: %s)\n",
+        "tri.method3":        "\nMETHODE 3: Ajustement par moindres carrés (top %d serveurs)\n",
+
+        "flag.invalidWeighting": "Erreur: pondération %q inconnue (attendu: equal, ou inverse-variance et/ou colocation séparées par des virgules)\n",
+
+        "tri.method4":        "\nMETHODE 4: Intersection de cercles 2D (top %d emplacements distincts, plan tangent local)\n",
+        "tri.circleGap":      "Distance à la méthode 3: %s\n",
+        "tri.noIntersection": "Aucune paire de cercles de distance ne se coupe: les distances sont incompatibles entre elles.",
+        "md.method4":         "4. Intersection de cercles (2D)",
+        "md.noIntersection":  "les cercles ne se coupent pas",
+
+        "tri.method5":    "\nMETHODE 5: Intersection de boîtes (top %d serveurs)\n",
+        "tri.coarse":     "ESTIMATION GROSSIERE: les cercles ne se coupent pas et l'ajustement par moindres carrés n'est pas fiable; cette boîte est un dernier recours.",
+        "tri.box":        "Boîte commune: %s de large x %s de haut (lat %.2f à %.2f, lon %.2f à %.2f)\n",
+        "tri.boxSkipped": "%d boîte(s) de serveur écartée(s): elles ne recoupaient pas les autres.\n",
+        "md.method5":     "5. Boîtes englobantes (grossier)",
+        "md.box":         "boîte %s x %s",
+
+        "tri.tooFew":       "\nSeuls %d serveur(s) ont répondu : la triangulation géométrique en exige au moins 3 et est ignorée.\n",
+        "tri.tooFewHint":   "Les résultats et la région par similarité de latence ci-dessus restent valables.",
+        "tri.fewLocations": "\nErreur: les serveurs ayant répondu n'occupent que %d emplacements distincts (au moins %d nécessaires).\nLes serveurs colocalisés (plusieurs à Francfort par exemple) comptent une fois : élargissez les filtres, augmentez --count pour absorber les pertes de paquets, ou vérifiez pourquoi les serveurs d'ailleurs n'ont pas répondu.\n",
+        "tri.lowDiversity": "\nErreur: diversité géométrique insuffisante: les serveurs ayant répondu ne couvrent que %s (au moins %s nécessaires).\nÉlargissez le filtre pays/continent, ou vérifiez pourquoi les serveurs des autres régions n'ont pas répondu.\n",
+
+        "flag.invalidIRLS": "Erreur: --irls-loss doit valoir huber ou tukey et --irls-threshold être positif (reçu %q, %g)\n",
+        "tri.weights":      "Poids finaux des serveurs (1 = conservé, < 1 = dépondéré comme aberrant):",
+
+        "flag.invalidGeometry": "Erreur: géométrie %q inconnue (attendu: delta ou bounds)\n",
+
+        "sim.title":         "METHODE: Similarité de latence",
+        "sim.servers":       "Serveurs au RTT le plus proche (anycast exclus): %s\n",
+        "sim.region":        "Région estimée: %s (%.0f%% de ces serveurs), autour de %.4f, %.4f\n",
+        "sim.spread":        "Dispersion de ces serveurs: %s\n",
+        "sim.confidence":    "Confiance: %s\n",
+        "similarity.high":   "ÉLEVÉE",
+        "similarity.medium": "MOYENNE",
+        "similarity.low":    "FAIBLE",
+        "tri.caveat":        "(distances: bornes inférieures tirées des différences de latence, voir README)",
+
+        "flag.invalidBackend": "Erreur: backend %q inconnu (attendu: icmp ou system)\n",
+
+        "trace.title": "AFFINAGE PAR TRACEROUTE",
+        "trace.none":  "Aucun serveur ne partage de tronçon exploitable avec la cible.",
+        "trace.line":  "%-20s | dernier saut commun %s (%v) | %d sauts ensuite | distance %s -> %s\n",
+
+        "tri.residualFloor": "Résidu plancher (meilleur ajustement RMS atteignable): %s\n",
+        "tri.possibleProxy": "=> VPN/proxy possible: aucune position unique n'explique les distances mesurées à la précision estimée près",
+
+        "flag.invalidHeatmap": "Erreur: --heatmap-radius et --heatmap-step doivent être positifs (reçu %g, %g)\n",
+        "heatmap.written":     "\nCarte de chaleur: %d cellules écrites dans %s\n",
+        "residuals.written":   "Résidus: %d serveurs écrits dans %s\n",
+
+        "region.area":        "Région probable (ajustement à moins de %[2]s du meilleur): %[1]s\n",
+        "flag.invalidRegion": "Erreur: --region-threshold ne doit pas être négatif (reçu %g)\n",
+
+        "flag.invalidOutput": "Erreur: format de sortie %q inconnu (attendu: text, markdown, ndjson ou json)\n",
+        "md.title":           "Résultats de l'analyse - Cible: %s (RTT: %v)",
+        "md.serversHeader":   "| # | Serveur | Pays | Ville | RTT | Gigue | Delta | Distance estimée |",
+        "md.methodsHeader":   "| Méthode | Latitude | Longitude | Ville connue la plus proche | Carte |",
+        "md.method1":         "1. Trilatération",
+        "md.method2":         "2. Multilatération pondérée",
+        "md.method3":         "3. Moindres carrés",
+        "md.summaryHeader":   "| Indicateur | Valeur |",
+        "md.coherence":       "Cohérence",
+        "md.avgDelta":        "Delta moyen (top 5)",
+        "md.analyzed":        "Serveurs analysés",
+        "md.locations":       "Emplacements de serveurs distincts",
+        "md.precision":       "Précision estimée",
+        "md.residualFloor":   "Résidu plancher",
+        "md.region":          "Aire de la région probable",
+
+        "flag.historyNeedsDB": "Erreur: --history nécessite --db",
+        "history.error":       "Erreur: impossible de lire l'historique: %v\n",
+        "history.title":       "Historique de la position estimée de %s (moindres carrés)\n",
+        "history.row":         "%s | %-15s | RTT: %8v | %.4f, %.4f +/- %s | déplacement: %s\n",
+        "history.rowNone":     "%s | %-15s | RTT: %8v | (non triangulé)\n",
+        "history.empty":       "Aucune exécution enregistrée pour cette cible.",
+
+        "flag.invalidRank": "Erreur: classement %q inconnu (attendu: delta ou delta+jitter)\n",
+
+        "flag.invalidProximity": "Erreur: --proximity doit valoir fixed ou adaptive et --proximity-thresholds trois valeurs positives croissantes en ms, ex. 50,100,200 (reçu %q, %q)\n",
+
+        "results.cached": "(RTT de la cible repris du cache, voir --target-cache-ttl)",
+        "watch.cached":   " (RTT cible en cache)",
+
+        "results.interrupted": "(interrompu, %d serveurs mesurés)\n",
+        "interrupt.first":     "Interruption : rapport avec les serveurs déjà mesurés (Ctrl-C à nouveau pour quitter).",
+        "interrupt.noTarget":  "Interrompu avant la mesure de la cible.",
+
+        "plan.title":      "\nPLAN DE MESURE (simulation, aucun paquet envoyé)",
+        "plan.target":     "  Cible : %s\n",
+        "plan.unresolved": "  Cible : %s (résolution impossible)\n",
+        "plan.servers":    "  Serveurs : %d, mesurés en parallèle par %d workers\n",
+        "plan.duration":   "  Durée estimée : %v (cible %v, balayage %v)\n",
+        "plan.deadline":   "  Phase de mesure bornée par --deadline à %v\n",
+        "plan.traceroute": "  Traceroute : jusqu'à %v de plus\n",
+        "plan.flags":      "  Options en vigueur :",
+        "plan.noFlags":    "  Options en vigueur : valeurs par défaut",
+
+        "list.header":        "NOM                      IP               PAYS            VILLE                 LAT       LON  INDIC.",
+        "list.mdHeader":      "| Nom | IP | Pays | Ville | Lat | Lon | Indicateurs |",
+        "list.total":         "%d serveurs\n",
+        "list.error":         "Erreur: %v\n",
+        "flag.jsonNeedsList": "Erreur: --output=json n'est disponible qu'avec --list et benchmark",
+
+        "flag.invalidServeConcurrency": "Erreur: --serve-concurrency doit être strictement positif (reçu %d)\n",
+        "flag.invalidConcurrency":      "Erreur: --concurrency doit être un nombre de workers strictement positif ou auto (reçu %q)\n",
+
+        "coord.title":           "TRIANGULATION MULTI-POINTS - %d points de mesure - Cible : %s\n",
+        "coord.unknownVantage":  "position inconnue",
+        "coord.vantage":         "\n%s (%s) | RTT cible : %v | %d serveurs ont répondu\n",
+        "coord.vantageEstimate": "   Estimation propre : %.4f, %.4f\n",
+        "coord.vantageNone":     "   Estimation propre : pas assez de serveurs",
+        "coord.combined":        "\nAJUSTEMENT COMBINÉ (tous les points de mesure)",
+        "coord.anchors":         "%d points de référence, résidu RMS %s\n",
+
+        "flag.agentNeedsOut": "Erreur: --agent exige --out=rapport.json et ne se combine pas avec --replay",
+        "agent.error":        "Erreur: impossible d'écrire le rapport d'agent: %v\n",
+        "agent.written":      "\nRapport d'agent écrit dans %s (%d/%d serveurs ont répondu)\n",
+
+        "outdir.error": "Erreur: impossible d'utiliser --out-dir: %v\n",
+
+        "tz.approximate": "UTC%+d (%s, d'après la longitude)",
+        "tz.line":        "Fuseau horaire probable: %s - heure locale %s\n",
+        "tz.lineNoTime":  "Fuseau horaire probable: %s\n",
+        "tz.loadError":   "Erreur: impossible de charger les fuseaux horaires: %v\n",
+
+        "flag.invalidVelocity": "Erreur: --velocity-factor doit être dans ]0, 1] (reçu %g)\n",
+        "flag.velocityModel":   "Erreur: --velocity-factor ne s'applique qu'au modèle %s (reçu --model=%s)\n",
+
+        "filter.summary": "%d serveurs écartés par --include/--exclude, %d conservés\n",
+
+        "flag.invalidSample": "Erreur: --sample doit valoir 0 (tous les serveurs) ou être positif (reçu %d)\n",
+
+        "bench.title":       "BANC D'ESSAI RÉSEAU - RTT par continent (ms)",
+        "bench.header":      "RÉGION            SRV    OK       MIN   MÉDIANE       P90       MAX",
+        "bench.mdHeader":    "| Région | Serveurs | Réponses | Min (ms) | Médiane (ms) | P90 (ms) | Max (ms) |",
+        "bench.total":       "%d/%d serveurs ont répondu\n",
+        "bench.unreachable": "Injoignables: %s\n",
+
+        "reach.title":    "JOIGNABILITÉ - un paquet par serveur",
+        "reach.header":   "RÉGION            SRV    OK",
+        "reach.mdHeader": "| Région | Serveurs | Joignables | Injoignables |",
+        "reach.down":     "    injoignables : %s\n",
+        "reach.total":    "%d/%d serveurs joignables (vérifié en %s)\n",
+
+        "flag.calibrateNeeds": "Erreur: calibrate exige --vantage (lat,lon de ce poste) et --out",
+        "calib.error":         "Erreur: échec de la calibration: %v\n",
+        "calib.title":         "CALIBRATION DES SERVEURS",
+        "calib.common":        "Délai commun: %v (médiane sur %d serveurs localisés, non inclus dans les biais)\n",
+        "calib.top":           "Serveurs les plus biaisés (envisagez de les écarter avec --exclude):",
+        "calib.written":       "\nCalibration écrite dans %s (%d serveurs) ; à utiliser avec --calibration\n",
+
+        "flag.updateDBNeeds": "Erreur: update-db exige --db-url et --db-pubkey et/ou --db-sha256",
+        "db.error":           "Erreur: échec de la mise à jour de la base: %v\n",
+        "db.updated":         "Base de serveurs mise à jour: %d serveurs (%d ajoutés, %d retirés, %d modifiés)\n",
+        "db.added":           "  Ajoutés: %s\n",
+        "db.removed":         "  Retirés: %s\n",
+        "db.changed":         "  Modifiés: %s\n",
+
+        "incons.header":  "Attention: %d serveur(s) répondent plus vite que la lumière ne le permet depuis leur position déclarée (adresse anycast ou coordonnées erronées):\n",
+        "incons.vantage": "   %-20s | %-15s | %-12s | RTT %v, mais au moins %v depuis ce poste\n",
+        "incons.pair":    "   %-20s | %-15s | %-12s | RTT %v, mais avec %s (%s, %v) au moins %v\n",
+        "incons.hint":    "   --drop-inconsistent les écarte de la triangulation.",
+        "incons.dropped": "   Écartés de la triangulation (--drop-inconsistent).",
+
+        "flag.invalidPacketSize": "Erreur: --packet-size %d hors limites (%d-%d octets, ou 0 pour la taille par défaut)\n",
+
+        "flag.invalidSource": "source des sondes invalide : %v\n",
+
+        "meta.run":         "Exécution : %s, triangula %s",
+        "meta.servers":     "Serveurs : %d dans la base, %d mesurés, %d ont répondu",
+        "meta.model":       "Modèle : %s, solveur : %s",
+        "meta.vantage":     "Point de mesure : %.4f, %.4f",
+        "meta.concurrency": "Parallélisme : %d workers retenus (--concurrency=auto)",
+        "meta.flags":       "Options : %s",
+        "meta.noFlags":     "(par défaut)",
+
+        "stats.unknownCountry": "Inconnu",
+
+        "stats.medianRTT":  "RTT médian de tous les serveurs: %v\n",
+        "stats.unicastRTT": "Hors serveurs anycast et Global (%d): moyenne %v, médiane %v\n",
+        "stats.noUnicast":  "Hors serveurs anycast et Global: aucun n'a répondu",
+
+        "stats.byRegion":          "\nServeurs joints par région (réponses/mesurés):",
+        "stats.regionUnreachable": "Attention: aucun serveur n'a répondu en %s: les estimations vers cette région sont peu fiables.\n",
+
+        "stats.byProvider":       "\nRépartition par fournisseur (top 10):",
+        "stats.providerDominant": "Attention: %s exploite %d des %d serveurs les plus proches: son routage anycast ou de bordure peut biaiser l'estimation.\n",
+
+        "vantage.farServer":  "%s (%v, à %s)",
+        "vantage.farWarning": "\nAttention: les serveurs les plus rapides depuis ce poste sont loin de votre position : %s, alors que le serveur ayant répondu le plus proche, %s, répond en %v à %s. Leurs adresses sont probablement anycast, ou leurs coordonnées erronées.\n",
+
+        "flag.vantageConflict": "Erreur: --vantage=%s et --my-location=%s désignent deux positions différentes (--my-location est un synonyme de --vantage)\n",
+
+        "tri.divergence":      "Écart entre les méthodes 1 et 2: %s\n",
+        "tri.divergent":       "ATTENTION: trilatération et multilatération divergent de plus de %s, le résultat n'est pas fiable.\n",
+        "tri.divergentCauses": "Causes possibles: serveurs anycast parmi les meilleurs, congestion du réseau, trop peu d'emplacements de serveurs distincts.",
+        "md.divergence":       "Écart (méthodes 1 et 2)",
+
+        "tri.verify":         "Vérification: %s est à %s de l'estimation ; remesuré sur %d pings, son RTT le place à %s de la cible (écart %s, tolérance %s)\n",
+        "tri.verifyOK":       "=> Le serveur le plus proche confirme l'estimation",
+        "tri.verifyLoose":    "=> Cohérence approximative : l'écart dépasse la tolérance, mais pas assez pour rejeter l'estimation",
+        "tri.verifySuspect":  "=> Estimation SUSPECTE : la latence du serveur le plus proche la contredit (serveur anycast ou mal localisé, proxy, ou ajustement erroné)",
+        "tri.verifyFailed":   "Vérification: %s n'a pas répondu (%v)\n",
+        "tri.verifyNone":     "Vérification: aucun serveur localisé n'a répondu près de l'estimation",
+        "md.verification":    "Vérification (%s)",
+        "md.verificationGap": "écart %s (tolérance %s)",
+
+        "tri.vertex":       "est. %s | pos. %s",
+        "tri.visualLegend": "Sommets : distance estimée depuis le RTT (est.) et distance à la position trilatérée (pos.) ; côtés : distance entre serveurs",
+
+        "map.title":            "CARTE DU MONDE",
+        "map.legend":           "%c estimation (méthode 3): %.2f, %.2f   %c serveurs utilisés (%d)\n",
+        "map.legendVantage":    "%c votre position: %.2f, %.2f\n",
+        "flag.invalidASCIIMap": "Erreur: --ascii-map-width %d hors limites (%d-%d colonnes)\n",
+        "flag.asciiMapText":    "Erreur: --ascii-map n'est dessinée que dans le rapport texte (sans --output ni --template)",
+
+        "map.osmArea":      "OpenStreetMap (zone d'incertitude)",
+        "flag.invalidMaps": "Erreur: --maps %q invalide (attendu: liste de google, osm, osm-area séparés par des virgules)\n",
+
+        "hosting.title":   "HÉBERGEMENT OU ACCÈS RÉSIDENTIEL",
+        "hosting.verdict": "Hébergement probable: %s (confiance: %s)\n",
+        "hosting.signals": "Meilleur delta: %v | Gigue de la cible: %v\n",
+        "hosting.caveat":  "(heuristique tirée de la seule signature de latence, voir ses limites dans le README)",
+        "hosting.yes":     "oui",
+        "hosting.no":      "non",
+
+        "flag.invalidTemplate": "Erreur: --template invalide: %v\n",
+        "flag.templateOutput":  "Erreur: --template exige --output=text",
+        "template.error":       "Erreur: impossible d'appliquer le gabarit du rapport: %v\n",
+
+        "continent.title":            "RÉSOLUTION CONTINENTALE",
+        "continent.none":             "Aucun continent désigné: aucun serveur localisé parmi les RTT les plus proches.",
+        "continent.match":            "Continent le plus probable: %s (similarité de latence, confiance %s)\n",
+        "continent.tooFew":           "Trop peu de serveurs exploitables en %s pour une résolution locale (%d ont répondu).\n",
+        "continent.local":            "Estimation continentale (%d serveurs): %.4f, %.4f\n",
+        "continent.global":           "Estimation globale (méthode 3): %.4f, %.4f\n",
+        "continent.gap":              "Distance entre les deux: %s\n",
+        "continent.fused":            "Estimation fusionnée (%.0f%% continent, %.0f%% global): %.4f, %.4f\n",
+        "md.continent":               "Estimation continentale, %s (%d serveurs)",
+        "md.continentGap":            "%.4f, %.4f, à %s de la méthode 3",
+        "md.continentFused":          "Estimation fusionnée (%.0f%% continent)",
+        "flag.invalidContinentSolve": "Erreur: --continent-solve %q inconnu (attendu: off, local ou fused)\n",
+
+        "tri.cities":          "Villes utilisées par la résolution (--collapse=city): %d\n",
+        "md.cities":           "Villes utilisées par la résolution",
+        "flag.invalidCollapse": "Erreur: --collapse %q inconnu (attendu: none ou city)\n",
+
+        "target.ipv6Only": "Erreur: %v.\nLes serveurs de référence sont mesurés en IPv4 : la latence d'une cible joignable seulement en IPv6 ne leur est pas comparable, et la mesure en IPv6 n'est pas encore prise en charge. Pour la mesurer malgré tout, passez l'une de ces adresses IPv6 comme cible.\n",
+
+        "done": "ANALYSE TERMINEE",
+    },
+}
+
+// currentLang est la langue active, fixée au démarrage par setLanguage.
+var currentLang = defaultLang
+
+// detectLanguage choisit la langue : le flag --lang prime sur la variable
+// d'environnement LANG (ex: "fr_FR.UTF-8"), puis l'anglais par défaut.
+func detectLanguage(flagValue string) string {
+    for _, candidate := range []string{flagValue, os.Getenv("LANG")} {
+        if code, ok := languageCode(candidate); ok {
+            return code
+        }
+    }
+    return defaultLang
+}
+
+// languageCode retourne le code de langue de value (ex: "fr" pour
+// "fr_FR.UTF-8"), false s'il n'a pas de catalogue.
+func languageCode(value string) (string, bool) {
+    code := strings.ToLower(value)
+    if i := strings.IndexAny(code, "_.-"); i >= 0 {
+        code = code[:i]
+    }
+    _, ok := catalogs[code]
+    return code, ok
+}
+
+func setLanguage(lang string) {
+    if _, ok := catalogs[lang]; ok {
+        currentLang = lang
+    }
+}
+
+// tr retourne le message id dans la langue active, avec repli sur l'anglais
+// puis sur l'identifiant lui-même si le message est inconnu.
+func tr(id string) string {
+    if msg, ok := catalogs[currentLang][id]; ok {
+        return msg
+    }
+    if msg, ok := catalogs[defaultLang][id]; ok {
+        return msg
+    }
+    return id
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+    "flag"
+    "fmt"
+    "io"
+    "sort"
+    "strings"
+    "time"
+)
+
+// Metadata décrit l'exécution en tête de chaque rapport (texte, markdown,
+// ndjson, /triangulate, /ws), pour qu'un rapport archivé reste
+// interprétable et comparable à un autre des mois plus tard.
+type Metadata struct {
+    Timestamp time.Time         `json:"timestamp"` // date des mesures (celle de la session en rejeu)
+    Version   string            `json:"version"`
+    Vantage   *Location         `json:"vantage,omitempty"` // point de mesure (--vantage), nil si non configuré
+    Database  int               `json:"database"`          // serveurs de la base
+    Probed    int               `json:"probed"`            // serveurs mesurés, après filtres et échantillonnage
+    Responded int               `json:"responded"`
+    Model     string            `json:"model"`
+    Solver    string            `json:"solver"`
+    Flags     map[string]string `json:"flags"` // options passées explicitement
+    // Concurrency est le nombre de workers retenu par --concurrency=auto
+    Concurrency int `json:"concurrency,omitempty"`
+}
+
+// newMetadata prépare les métadonnées communes à toutes les analyses d'une
+// exécution ; withSweep les complète pour une campagne.
+func newMetadata(vantage *Location, database int, model, solver string, flags *flag.FlagSet) Metadata {
+    meta := Metadata{
+        Version:  version,
+        Vantage:  vantage,
+        Database: database,
+        Model:    model,
+        Solver:   solver,
+        Flags:    make(map[string]string),
+    }
+    flags.Visit(func(f *flag.Flag) {
+        meta.Flags[f.Name] = f.Value.String()
+    })
+    return meta
+}
+
+func (m Metadata) withSweep(timestamp time.Time, summary SweepSummary) Metadata {
+    m.Timestamp, m.Probed, m.Responded = timestamp, summary.Total, summary.Responded
+    m.Concurrency = summary.Concurrency
+    return m
+}
+
+// flagList retourne les options sous la forme --nom=valeur, triées.
+func (m Metadata) flagList() []string {
+    list := make([]string, 0, len(m.Flags))
+    for name, value := range m.Flags {
+        list = append(list, fmt.Sprintf("--%s=%s", name, value))
+    }
+    sort.Strings(list)
+    return list
+}
+
+// metadataLines retourne l'en-tête des rapports texte et markdown, une
+// ligne par information.
+func metadataLines(m Metadata) []string {
+    lines := []string{
+        fmt.Sprintf(tr("meta.run"), m.Timestamp.Format(time.RFC3339), m.Version),
+        fmt.Sprintf(tr("meta.servers"), m.Database, m.Probed, m.Responded),
+        fmt.Sprintf(tr("meta.model"), m.Model, m.Solver),
+    }
+    if m.Vantage != nil {
+        lines = append(lines, fmt.Sprintf(tr("meta.vantage"), m.Vantage.Lat, m.Vantage.Lon))
+    }
+    if m.Concurrency > 0 {
+        lines = append(lines, fmt.Sprintf(tr("meta.concurrency"), m.Concurrency))
+    }
+    flags := tr("meta.noFlags")
+    if len(m.Flags) > 0 {
+        flags = strings.Join(m.flagList(), " ")
+    }
+    return append(lines, fmt.Sprintf(tr("meta.flags"), flags))
+}
+
+func displayMetadata(w io.Writer, m Metadata) {
+    fmt.Fprintln(w)
+    for _, line := range metadataLines(m) {
+        fmt.Fprintln(w, line)
+    }
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+    "context"
+
+    "github.com/prometheus/client_golang/prometheus"
+)
+
+// Métriques du mode service (--serve), enregistrées une seule fois au
+// démarrage par registerMetrics.
+var (
+    triangulationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Name: "triangula_triangulations_total",
+        Help: "Number of triangulation requests, by outcome.",
+    }, []string{"status"})
+
+    serverPingsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+        Name: "triangula_server_pings_total",
+        Help: "Reference server measurements, by server and result.",
+    }, []string{"server", "result"})
+
+    measuredRTT = prometheus.NewHistogram(prometheus.HistogramOpts{
+        Name:    "triangula_measured_rtt_seconds",
+        Help:    "Measured RTT to reference servers.",
+        Buckets: prometheus.ExponentialBuckets(0.001, 2, 12),
+    })
+
+    triangulationDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+        Name:    "triangula_triangulation_duration_seconds",
+        Help:    "End-to-end triangulation latency.",
+        Buckets: prometheus.LinearBuckets(10, 20, 10),
+    })
+)
+
+func registerMetrics() {
+    prometheus.MustRegister(triangulationsTotal, serverPingsTotal, measuredRTT, triangulationDuration)
+}
+
+// instrumentedMeasurer enregistre le résultat de chaque ping vers un serveur
+// de référence. Les IP hors base (la cible) ne sont pas comptées par serveur,
+// pour ne pas créer une série par cible.
+type instrumentedMeasurer struct {
+    inner Measurer
+    names map[string]string // IP -> nom du serveur
+}
+
+func newInstrumentedMeasurer(inner Measurer, servers []Server) instrumentedMeasurer {
+    names := make(map[string]string, len(servers))
+    for _, s := range servers {
+        names[s.IP] = s.Name
+    }
+    return instrumentedMeasurer{inner: inner, names: names}
+}
+
+func (m instrumentedMeasurer) Measure(ctx context.Context, ip string, count int) (PingStats, error) {
+    stats, err := m.inner.Measure(ctx, ip, count)
+
+    name, known := m.names[ip]
+    if !known || ctx.Err() != nil {
+        return stats, err
+    }
+    if err != nil {
+        serverPingsTotal.WithLabelValues(name, "failure").Inc()
+    } else {
+        serverPingsTotal.WithLabelValues(name, "success").Inc()
+        measuredRTT.Observe(stats.Avg.Seconds())
+    }
+    return stats, err
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+    "encoding/json"
+    "io"
+    "log/slog"
+    "sync"
+    "time"
+)
+
+// Types d'événements de --output=ndjson (un objet JSON par ligne) et de
+// /ws en mode service (un message par événement). Le schéma est stable :
+// les champs existants ne changent ni de nom ni de sens.
+const (
+    eventTarget   = "target"   // cible mesurée : eventTargetMeasured
+    eventMeasured = "measured" // serveur ayant répondu : eventServer
+    eventFailed   = "failed"   // serveur sans réponse ou mesure interrompue : eventServer
+    eventResult   = "result"   // synthèse finale, toujours le dernier événement : eventResultSummary
+    eventError    = "error"    // échec de l'analyse, dernier événement : eventErrorMessage
+    eventWarning  = "warning"  // étape facultative en échec, l'analyse continue : eventWarningMessage
+)
+
+// eventTargetMeasured est l'événement eventTarget.
+type eventTargetMeasured struct {
+    Type     string  `json:"type"`
+    Input    string  `json:"input"`
+    IP       string  `json:"ip"`
+    RTTMs    float64 `json:"rtt_ms"`
+    JitterMs float64 `json:"jitter_ms"`
+    Cached   bool    `json:"cached,omitempty"`
+}
+
+// eventServer est l'événement eventMeasured ou eventFailed.
+type eventServer struct {
+    Type      string  `json:"type"`
+    Server    string  `json:"server"`
+    IP        string  `json:"ip"`
+    Country   string  `json:"country"`
+    City      string  `json:"city"`
+    RTTMs     float64 `json:"rtt_ms,omitempty"`
+    JitterMs  float64 `json:"jitter_ms,omitempty"`
+    Error     string  `json:"error,omitempty"`
+    Cancelled bool    `json:"cancelled,omitempty"`
+}
+
+// eventResultSummary est l'événement eventResult. Les distances (champs
+// *_km) sont toujours en km ; Units l'indique aux consommateurs.
+type eventResultSummary struct {
+    Type        string           `json:"type"`
+    Units       string           `json:"units"`
+    Metadata    Metadata         `json:"metadata"`
+    Target      Target           `json:"target"`
+    Total       int              `json:"total"`
+    Measured    int              `json:"measured"`
+    Responded   int              `json:"responded"`
+    Interrupted bool             `json:"interrupted,omitempty"`
+    PacketSize  int              `json:"packet_size,omitempty"` // 0 : taille par défaut
+    Results     []Result         `json:"results"`                // reportTopServers meilleurs
+    Estimates   *Estimates       `json:"estimates,omitempty"`
+    Region      *Region          `json:"region,omitempty"`
+    Similarity  *SimilarityMatch `json:"similarity,omitempty"`
+}
+
+// eventErrorMessage est l'événement eventError.
+type eventErrorMessage struct {
+    Type  string `json:"type"`
+    Error string `json:"error"`
+}
+
+// eventWarningMessage est l'événement eventWarning.
+type eventWarningMessage struct {
+    Type    string `json:"type"`
+    Message string `json:"message"`
+}
+
+// eventStream sérialise les événements vers une sortie (NDJSON, WebSocket) ;
+// il implémente MeasurementObserver.
+type eventStream struct {
+    mu    sync.Mutex
+    write func(event any) error
+}
+
+// newNDJSONStream écrit un objet JSON par ligne sur w.
+func newNDJSONStream(w io.Writer) *eventStream {
+    return &eventStream{write: json.NewEncoder(w).Encode}
+}
+
+func (w *eventStream) emit(event any) {
+    w.mu.Lock()
+    defer w.mu.Unlock()
+    if err := w.write(event); err != nil {
+        slog.Debug("cannot write event", "error", err)
+    }
+}
+
+func milliseconds(d time.Duration) float64 {
+    return float64(d) / float64(time.Millisecond)
+}
+
+func (w *eventStream) TargetMeasured(target Target) {
+    w.emit(eventTargetMeasured{
+        Type:     eventTarget,
+        Input:    target.Input,
+        IP:       target.IP,
+        RTTMs:    milliseconds(target.RTT),
+        JitterMs: milliseconds(target.Jitter),
+        Cached:   target.Cached,
+    })
+}
+
+func (w *eventStream) ServerMeasured(m Measurement) {
+    event := eventServer{
+        Type:    eventMeasured,
+        Server:  m.Server.Name,
+        IP:      m.Server.IP,
+        Country: m.Server.Country,
+        City:    m.Server.City,
+    }
+    if m.Error != "" {
+        event.Type, event.Error, event.Cancelled = eventFailed, m.Error, m.Cancelled
+    } else {
+        event.RTTMs, event.JitterMs = milliseconds(m.RTT), milliseconds(m.Jitter)
+    }
+    w.emit(event)
+}
+
+// Result écrit la synthèse finale ; meta doit être complété par la campagne.
+func (w *eventStream) Result(meta Metadata, target Target, summary SweepSummary, results []Result, est *Estimates, region *Region,
+    packetSize int) {
+    event := eventResultSummary{
+        Type:        eventResult,
+        Units:       unitKm,
+        Metadata:    meta,
+        Target:      target,
+        Total:       summary.Total,
+        Measured:    summary.Measured,
+        Responded:   summary.Responded,
+        Interrupted: summary.Interrupted,
+        PacketSize:  packetSize,
+        Results:     results,
+        Estimates:   est,
+        Region:      region,
+    }
+    if len(event.Results) > reportTopServers {
+        event.Results = event.Results[:reportTopServers]
+    }
+    if match, ok := matchSimilarity(results); ok {
+        event.Similarity = &match
+    }
+    w.emit(event)
+}
+
+// Error écrit l'échec de l'analyse.
+func (w *eventStream) Error(message string) {
+    w.emit(eventErrorMessage{Type: eventError, Error: message})
+}
+
+// Warning signale l'échec d'une étape facultative (--verify...) ; le
+// résultat suit.
+func (w *eventStream) Warning(message string) {
+    w.emit(eventWarningMessage{Type: eventWarning, Message: message})
+}
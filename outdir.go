@@ -0,0 +1,52 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// safeFilename réduit une cible (nom d'hôte, IPv4, IPv6) à un nom de fichier
+// sûr : tout caractère hors [A-Za-z0-9._-] devient _, et un nom qui serait
+// caché ou vide est préfixé.
+func safeFilename(target string) string {
+    name := strings.Map(func(r rune) rune {
+        switch {
+        case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+            return r
+        }
+        return '_'
+    }, target)
+    if name == "" || strings.HasPrefix(name, ".") {
+        name = "target" + name
+    }
+    return name
+}
+
+// useOutDir fixe, dans dir, les chemins des artefacts de la cible que les
+// options n'ont pas déjà fixés : session (.json), région (.geojson), grille
+// (.csv) et rapport Markdown (.md, retourné). Un pointeur nil (session
+// rejouée) est ignoré. Crée dir si besoin et refuse d'écraser un fichier
+// existant sans force.
+func useOutDir(dir, target string, force bool, session, region, heatmap *string) (string, error) {
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        return "", err
+    }
+    base := filepath.Join(dir, safeFilename(target))
+    var report string
+    for _, artifact := range []struct {
+        path *string
+        ext  string
+    }{{session, ".json"}, {region, ".geojson"}, {heatmap, ".csv"}, {&report, ".md"}} {
+        if artifact.path == nil || *artifact.path != "" {
+            continue
+        }
+        path := base + artifact.ext
+        if _, err := os.Stat(path); err == nil && !force {
+            return "", fmt.Errorf("%s already exists (use --force to overwrite)", path)
+        }
+        *artifact.path = path
+    }
+    return report, nil
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "os"
+    "strings"
+)
+
+// analysis regroupe une campagne mesurée ou rejouée, prête à être rendue
+// dans le format demandé.
+type analysis struct {
+    Meta         Metadata // complété par la campagne (Metadata.withSweep)
+    Target       Target
+    Summary      SweepSummary
+    Measurements []Measurement
+    Results      []Result
+    Refinements  []TracerouteRefinement // --traceroute
+    Servers      []Server
+    Grid         *gridExport
+    ReportPath   string // rapport Markdown de --out-dir ; vide sans --out-dir
+}
+
+// proximity retourne l'échelle des indicateurs de proximité : celle de
+// --proximity-thresholds, ou les quartiles observés avec --proximity=adaptive.
+func (c *config) proximity(results []Result, rank string) proximityScale {
+    if c.Proximity == proximityAdaptive {
+        return adaptiveProximity(results, rank)
+    }
+    return c.ProximityScale
+}
+
+// structuredEstimates triangule les résultats et y joint les analyses
+// facultatives demandées (--verify, --monte-carlo, comparaisons,
+// --continent-solve), que les formats structurés portent dans Estimates.
+func structuredEstimates(ctx context.Context, c *config, a analysis, opts Options, events *eventStream) (Estimates, bool) {
+    est, err := estimatePositions(a.Results, opts)
+    if err != nil {
+        return est, false
+    }
+    if c.Verify && c.Replay == "" {
+        if v, err := verifyEstimate(ctx, a.Target, a.Results, est, opts); err == nil {
+            est.Verification = &v
+        } else {
+            slog.Warn("estimate verification failed", "server", v.Server, "error", err)
+            if events != nil {
+                events.Warning(fmt.Sprintf("verification against %q failed: %v", v.Server, err))
+            }
+        }
+    }
+    if c.MonteCarlo > 0 {
+        if e, ok := monteCarloEllipse(a.Measurements, a.Target, opts, c.MonteCarlo); ok {
+            est.Uncertainty = &e
+        }
+    }
+    for _, source := range c.Comparisons {
+        cmp, err := compareLocation(source, a.Target, est.Locations())
+        if cmp.Skipped != "" {
+            slog.Info("geolocation comparison skipped", "source", source.Name, "reason", err)
+        } else if err != nil {
+            slog.Warn("geolocation comparison failed", "source", source.Name, "error", err)
+        }
+        est.Comparisons = append(est.Comparisons, cmp)
+    }
+    if c.ContinentSolve != continentSolveOff {
+        if ce, err := continentSolve(a.Results, est, opts, c.ContinentSolve == continentSolveFused); err == nil {
+            est.Continent = &ce
+        } else {
+            slog.Info("continent-local solve skipped", "continent", ce.Continent, "servers", ce.Servers, "reason", err)
+        }
+    }
+    return est, true
+}
+
+// writeStructuredReport écrit sur stdout le rapport markdown, ndjson ou
+// rendu par --template, après l'historique (--db) et les fichiers de la
+// grille et des résidus.
+func writeStructuredReport(ctx context.Context, c *config, a analysis, opts Options, events *eventStream) error {
+    est, triangulated := structuredEstimates(ctx, c, a, opts, events)
+    if c.DB != "" && c.Replay == "" {
+        var recorded *Estimates
+        if triangulated {
+            recorded = &est
+        }
+        saveHistory(c.DB, a.Target, a.Measurements, a.Results, recorded)
+    }
+    var region *Region
+    if triangulated {
+        if r, ok := exportGrid(est, opts, a.Grid); ok {
+            region = &r
+        }
+        if c.ResidualsCSV != "" {
+            exportResiduals(c.ResidualsCSV, est, opts)
+        }
+    }
+
+    switch {
+    case events != nil:
+        writeNDJSONReport(events, a, est, triangulated, region, opts)
+    case c.Template != nil:
+        if err := writeTemplateReport(c, a, est, triangulated, region, opts); err != nil {
+            return err
+        }
+    default:
+        writeMarkdownReport(os.Stdout, a.Meta, a.Target, a.Summary, a.Results, est, triangulated, region, a.Servers)
+    }
+    if a.ReportPath != "" {
+        saveMarkdownReport(a.ReportPath, a.Meta, a.Target, a.Summary, a.Results, est, triangulated, region, a.Servers)
+    }
+    return nil
+}
+
+// writeNDJSONReport émet l'événement final de --output=ndjson.
+func writeNDJSONReport(events *eventStream, a analysis, est Estimates, triangulated bool, region *Region, opts Options) {
+    var estimates *Estimates
+    if triangulated {
+        estimates = &est
+    }
+    events.Result(a.Meta, a.Target, a.Summary, a.Results, estimates, region, opts.PacketSize)
+}
+
+// writeTemplateReport rend le gabarit de --template sur stdout.
+func writeTemplateReport(c *config, a analysis, est Estimates, triangulated bool, region *Region, opts Options) error {
+    report := newReport(a.Meta, a.Target, a.Summary, a.Results, est, triangulated, region,
+        a.Servers, opts.Rank, c.proximity(a.Results, opts.Rank))
+    if err := renderReport(os.Stdout, c.Template, report); err != nil {
+        fmt.Fprintf(os.Stderr, tr("template.error"), err)
+        return exitWith(exitError)
+    }
+    return nil
+}
+
+// writeTextReport affiche le rapport texte section par section, en menant
+// les analyses facultatives au fil de l'affichage, et retourne l'estimation
+// (--watch en repart).
+func writeTextReport(ctx context.Context, c *config, a analysis, opts Options) (Estimates, bool) {
+    displayMetadata(os.Stdout, a.Meta)
+    displayResults(a.Results, a.Target, a.Summary, opts.Rank, c.proximity(a.Results, opts.Rank), a.Meta.Vantage)
+    if c.Traceroute {
+        displayTraceroute(a.Refinements)
+    }
+    displaySimilarity(a.Results)
+    displayHosting(a.Target, a.Results)
+    estimates, triangulated := displayTriangulation(a.Results, a.Servers, opts, c.Zones)
+    if triangulated && c.Verify && c.Replay == "" {
+        estimates.Verification = displayVerification(ctx, a.Target, a.Results, estimates, opts)
+    }
+    if c.DB != "" && c.Replay == "" {
+        var recorded *Estimates
+        if triangulated {
+            recorded = &estimates
+        }
+        saveHistory(c.DB, a.Target, a.Measurements, a.Results, recorded)
+    }
+    if triangulated && c.ContinentSolve != continentSolveOff {
+        estimates.Continent = displayContinentSolve(a.Results, estimates, opts, c.ContinentSolve == continentSolveFused, a.Servers)
+    }
+    if triangulated && c.ASCIIMap {
+        fmt.Println("\n" + strings.Repeat("=", 80))
+        fmt.Println(tr("map.title"))
+        fmt.Println(strings.Repeat("=", 80))
+        renderASCIIMap(os.Stdout, estimates.LeastSquares, estimates.Solved[:estimates.MultilatServers], c.ASCIIMapWidth,
+            a.Meta.Vantage)
+    }
+    var region *Region
+    if triangulated {
+        if r, ok := exportGrid(estimates, opts, a.Grid); ok {
+            region = &r
+            fmt.Printf(tr("region.area"), formatArea(region.AreaKm2), formatDistance(region.ThresholdKm))
+        }
+        if a.Grid.HeatmapPath != "" {
+            fmt.Printf(tr("heatmap.written"), a.Grid.HeatmapCells, a.Grid.HeatmapPath)
+        }
+        if c.ResidualsCSV != "" {
+            if n := exportResiduals(c.ResidualsCSV, estimates, opts); n > 0 {
+                fmt.Printf(tr("residuals.written"), n, c.ResidualsCSV)
+            }
+        }
+    }
+    // Sans triangulation, la raison est déjà affichée : les tirages
+    // Monte Carlo échoueraient tous pour la même raison
+    if c.MonteCarlo > 0 && triangulated {
+        estimates.Uncertainty = displayUncertainty(a.Measurements, a.Target, opts, c.MonteCarlo)
+    }
+    for _, source := range c.Comparisons {
+        var locations []Location
+        if triangulated {
+            locations = estimates.Locations()
+        }
+        cmp := displayGeoIPComparison(source, a.Target, locations)
+        if triangulated {
+            estimates.Comparisons = append(estimates.Comparisons, cmp)
+        }
+    }
+    displayStatistics(a.Measurements, a.Results)
+    if a.ReportPath != "" {
+        saveMarkdownReport(a.ReportPath, a.Meta, a.Target, a.Summary, a.Results, estimates, triangulated, region, a.Servers)
+    }
+
+    fmt.Println("\n" + strings.Repeat("=", 80))
+    fmt.Println(tr("done"))
+    fmt.Println(strings.Repeat("=", 80))
+    return estimates, triangulated
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "runtime"
+
+    "github.com/go-ping/ping"
+)
+
+// Sockets ICMP de go-ping (--icmp-socket)
+const (
+    icmpSocketAuto     = "auto"     // selon la plateforme et les droits (voir privilegedSocket)
+    icmpSocketRaw      = "raw"      // socket brute : root ou CAP_NET_RAW sous Linux, administrateur sous Windows
+    icmpSocketDatagram = "datagram" // socket ICMP non privilégiée : macOS, Linux avec net.ipv4.ping_group_range
+)
+
+var icmpSocket = icmpSocketAuto
+
+// setICMPSocket sélectionne le type de socket ICMP, false s'il est inconnu.
+func setICMPSocket(mode string) bool {
+    switch mode {
+    case icmpSocketAuto, icmpSocketRaw, icmpSocketDatagram:
+        icmpSocket = mode
+        return true
+    }
+    return false
+}
+
+// privilegedSocket décide si go-ping doit ouvrir une socket brute
+// (SetPrivileged(true)) sur la plateforme goos. En auto : toujours sous
+// Windows, qui ne connaît pas les sockets ICMP datagramme ; jamais sous
+// macOS, où elles sont ouvertes à tous ; sous Linux selon que le processus
+// est root, faute de pouvoir lire net.ipv4.ping_group_range sans /proc ;
+// toujours ailleurs. Un mode explicite l'emporte, sauf sous Windows.
+func privilegedSocket(goos, mode string, root bool) bool {
+    if goos == "windows" {
+        return true
+    }
+    switch mode {
+    case icmpSocketRaw:
+        return true
+    case icmpSocketDatagram:
+        return false
+    }
+    switch goos {
+    case "darwin":
+        return false
+    case "linux":
+        return root
+    default:
+        return true
+    }
+}
+
+// configurePinger applique le type de socket choisi au pinger et le
+// retourne (true : socket brute).
+func configurePinger(pinger *ping.Pinger) bool {
+    privileged := privilegedSocket(runtime.GOOS, icmpSocket, os.Geteuid() == 0)
+    pinger.SetPrivileged(privileged)
+    return privileged
+}
+
+// permissionHint retourne la marche à suivre quand l'ouverture de la socket
+// ICMP est refusée, selon la plateforme et le type de socket.
+func permissionHint(goos string, privileged bool) string {
+    switch {
+    case goos == "windows":
+        return tr("icmp.hintWindows")
+    case goos == "linux" && privileged:
+        return tr("icmp.hintLinuxRaw")
+    case goos == "linux":
+        return tr("icmp.hintLinuxDatagram")
+    case goos == "darwin" && privileged:
+        return tr("icmp.hintDarwinRaw")
+    default:
+        return tr("icmp.hintOther")
+    }
+}
+
+// icmpPermissionError complète un refus d'ouverture de socket par la
+// marche à suivre ; les autres erreurs sont retournées telles quelles.
+func icmpPermissionError(err error, privileged bool) error {
+    if !errors.Is(err, os.ErrPermission) {
+        return err
+    }
+    return fmt.Errorf("%w (%s)", err, permissionHint(runtime.GOOS, privileged))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+    "errors"
+    "io"
+    "os"
+    "runtime"
+    "strings"
+    "testing"
+)
+
+func TestPrivilegedSocket(t *testing.T) {
+    tests := []struct {
+        goos string
+        mode string
+        root bool
+        want bool
+    }{
+        // Windows n'a pas de socket ICMP datagramme, quel que soit le mode
+        {goos: "windows", mode: icmpSocketAuto, want: true},
+        {goos: "windows", mode: icmpSocketDatagram, want: true},
+        {goos: "darwin", mode: icmpSocketAuto, want: false},
+        {goos: "darwin", mode: icmpSocketAuto, root: true, want: false},
+        {goos: "darwin", mode: icmpSocketRaw, want: true},
+        {goos: "linux", mode: icmpSocketAuto, root: true, want: true},
+        {goos: "linux", mode: icmpSocketAuto, root: false, want: false},
+        {goos: "linux", mode: icmpSocketRaw, want: true},
+        {goos: "linux", mode: icmpSocketDatagram, root: true, want: false},
+        {goos: "freebsd", mode: icmpSocketAuto, want: true},
+        {goos: "freebsd", mode: icmpSocketDatagram, want: false},
+    }
+    for _, tt := range tests {
+        if got := privilegedSocket(tt.goos, tt.mode, tt.root); got != tt.want {
+            t.Errorf("privilegedSocket(%s, %s, root=%v) = %v, want %v", tt.goos, tt.mode, tt.root, got, tt.want)
+        }
+    }
+}
+
+func TestPermissionHint(t *testing.T) {
+    tests := []struct {
+        goos       string
+        privileged bool
+        want       string
+    }{
+        {goos: "windows", privileged: true, want: "icmp.hintWindows"},
+        {goos: "linux", privileged: true, want: "icmp.hintLinuxRaw"},
+        {goos: "linux", privileged: false, want: "icmp.hintLinuxDatagram"},
+        {goos: "darwin", privileged: true, want: "icmp.hintDarwinRaw"},
+        {goos: "darwin", privileged: false, want: "icmp.hintOther"},
+        {goos: "freebsd", privileged: true, want: "icmp.hintOther"},
+    }
+    for _, tt := range tests {
+        if got := permissionHint(tt.goos, tt.privileged); got != tr(tt.want) {
+            t.Errorf("permissionHint(%s, %v) = %q, want the %s message", tt.goos, tt.privileged, got, tt.want)
+        }
+    }
+}
+
+func TestICMPPermissionError(t *testing.T) {
+    denied := &os.SyscallError{Syscall: "socket", Err: os.ErrPermission}
+    err := icmpPermissionError(denied, true)
+    if !errors.Is(err, os.ErrPermission) || !strings.Contains(err.Error(), permissionHint(runtime.GOOS, true)) {
+        t.Errorf("icmpPermissionError(permission denied) = %v, want the wrapped error and a hint", err)
+    }
+    if err := icmpPermissionError(io.EOF, true); err != io.EOF {
+        t.Errorf("icmpPermissionError(EOF) = %v, want EOF unchanged", err)
+    }
+}
+
+func TestSetICMPSocket(t *testing.T) {
+    defer setICMPSocket(icmpSocket)
+    for _, mode := range []string{icmpSocketAuto, icmpSocketRaw, icmpSocketDatagram} {
+        if !setICMPSocket(mode) || icmpSocket != mode {
+            t.Errorf("setICMPSocket(%s) rejected or not applied", mode)
+        }
+    }
+    if setICMPSocket("stream") {
+        t.Error("setICMPSocket(stream) accepted")
+    }
+}
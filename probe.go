@@ -0,0 +1,128 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Sondes de mesure d'un serveur (champ Probe de la base)
+const (
+    probeICMP = "icmp" // backend choisi par --backend ; défaut d'un champ vide
+    probeTCP  = "tcp"  // établissement de connexion, "tcp" ou "tcp:PORT" (défaut defaultTCPPort)
+    probeHTTP = "http" // requête HEAD, "http" ou "http:PORT" (défaut defaultHTTPPort)
+    probeDNS  = "dns"  // requête DNS sur le port 53 (voir DNSPing)
+)
+
+// Probe est une sonde décodée par parseProbe.
+type Probe struct {
+    Kind string
+    Port int // tcp et http seulement
+}
+
+// parseProbe décode le champ Probe d'un serveur ; vide vaut probeICMP.
+func parseProbe(s string) (Probe, error) {
+    kind, port, hasPort := strings.Cut(strings.TrimSpace(s), ":")
+    p := Probe{Kind: kind}
+    switch kind {
+    case "", probeICMP, probeDNS:
+        if hasPort {
+            return Probe{}, fmt.Errorf("probe %q: no port expected", s)
+        }
+        if kind == "" {
+            p.Kind = probeICMP
+        }
+        return p, nil
+    case probeTCP:
+        p.Port = defaultTCPPort
+    case probeHTTP:
+        p.Port = defaultHTTPPort
+    default:
+        return Probe{}, fmt.Errorf("unknown probe %q (want %s, %s[:port], %s[:port] or %s)",
+            s, probeICMP, probeTCP, probeHTTP, probeDNS)
+    }
+    if hasPort {
+        n, err := strconv.Atoi(port)
+        if err != nil || n < 1 || n > 65535 {
+            return Probe{}, fmt.Errorf("probe %q: invalid port", s)
+        }
+        p.Port = n
+    }
+    return p, nil
+}
+
+// serverProbes retourne les sondes autres qu'ICMP des serveurs, par IP
+// résolue comme dans sweepServers (les serveurs désignés par un nom sont
+// résolus ici, via le cache). Une sonde invalide ou un nom irrésoluble est
+// signalé et le serveur mesuré par le backend ; nil si aucun serveur n'a de
+// sonde.
+func serverProbes(servers []Server, dns *dnsCache) map[string]Probe {
+    var probes map[string]Probe
+    for _, s := range servers {
+        p, err := parseProbe(s.Probe)
+        if err != nil {
+            slog.Warn("ignoring server probe, measuring with the backend", "server", s.Name, "error", err)
+            continue
+        }
+        if p.Kind == probeICMP {
+            continue
+        }
+        ip, err := dns.Resolve(s.IP)
+        if err != nil {
+            slog.Debug("cannot resolve probed server", "server", s.Name, "ip", s.IP, "error", err)
+            continue
+        }
+        if probes == nil {
+            probes = make(map[string]Probe)
+        }
+        probes[ip] = p
+    }
+    return probes
+}
+
+// serverMeasurer retourne le Measurer des serveurs de référence : Measurer,
+// précédé des sondes de Probes s'il y en a.
+func (o Options) serverMeasurer() Measurer {
+    if len(o.Probes) == 0 {
+        return o.Measurer
+    }
+    return probeMeasurer{Base: o.Measurer, Probes: o.Probes, Timeout: o.Timeout, Source: o.Source, Sampling: o.Sampling}
+}
+
+// probeMeasurer mesure chaque serveur avec la sonde que lui attribue la
+// base (voir serverProbes), les autres adresses par Base. Si la sonde ne
+// reçoit aucune réponse, la mesure retombe sur Base.
+type probeMeasurer struct {
+    Base     Measurer
+    Probes   map[string]Probe // IP -> sonde
+    Timeout  time.Duration    // durée maximale d'une mesure DNS
+    Source   string
+    Sampling sequentialSampling
+}
+
+func (m probeMeasurer) Measure(ctx context.Context, ip string, count int) (PingStats, error) {
+    probe, ok := m.Probes[ip]
+    if !ok {
+        return m.Base.Measure(ctx, ip, count)
+    }
+    var measurer Measurer
+    switch probe.Kind {
+    case probeTCP:
+        measurer = tcpMeasurer{Port: probe.Port, Source: m.Source, Sampling: m.Sampling}
+    case probeHTTP:
+        measurer = httpMeasurer{Port: probe.Port, Source: m.Source, Sampling: m.Sampling}
+    case probeDNS:
+        measurer = dnsMeasurer{Timeout: m.Timeout, Source: m.Source, Sampling: m.Sampling}
+    default:
+        return m.Base.Measure(ctx, ip, count)
+    }
+    stats, err := measurer.Measure(ctx, ip, count)
+    if err == nil || ctx.Err() != nil {
+        return stats, err
+    }
+    slog.Debug("server probe failed, falling back", "ip", ip, "probe", probe.Kind, "error", err)
+    return m.Base.Measure(ctx, ip, count)
+}
@@ -0,0 +1,72 @@
+package main
+
+import "testing"
+
+func TestParseProbe(t *testing.T) {
+    tests := []struct {
+        probe string
+        want  Probe
+        fails bool
+    }{
+        {probe: "", want: Probe{Kind: probeICMP}},
+        {probe: "  ", want: Probe{Kind: probeICMP}},
+        {probe: "icmp", want: Probe{Kind: probeICMP}},
+        {probe: "dns", want: Probe{Kind: probeDNS}},
+        {probe: "tcp", want: Probe{Kind: probeTCP, Port: defaultTCPPort}},
+        {probe: " tcp:8443 ", want: Probe{Kind: probeTCP, Port: 8443}},
+        {probe: "http", want: Probe{Kind: probeHTTP, Port: defaultHTTPPort}},
+        {probe: "http:8080", want: Probe{Kind: probeHTTP, Port: 8080}},
+        {probe: "tcp:1", want: Probe{Kind: probeTCP, Port: 1}},
+        {probe: "tcp:65535", want: Probe{Kind: probeTCP, Port: 65535}},
+        {probe: "icmp:7", fails: true},
+        {probe: "dns:53", fails: true},
+        {probe: "tcp:0", fails: true},
+        {probe: "tcp:65536", fails: true},
+        {probe: "tcp:", fails: true},
+        {probe: "http:x", fails: true},
+        {probe: "udp", fails: true},
+        {probe: "TCP", fails: true},
+    }
+    for _, tt := range tests {
+        got, err := parseProbe(tt.probe)
+        if tt.fails {
+            if err == nil {
+                t.Errorf("parseProbe(%q) = %v, want an error", tt.probe, got)
+            }
+            continue
+        }
+        if err != nil || got != tt.want {
+            t.Errorf("parseProbe(%q) = %v, %v, want %v", tt.probe, got, err, tt.want)
+        }
+    }
+}
+
+func TestServerProbes(t *testing.T) {
+    cache := fakeDNSCache(map[string][]string{
+        "probed.example": {"192.0.2.10"},
+    })
+    servers := []Server{
+        {Name: "icmp", IP: "192.0.2.1"},
+        {Name: "tcp", IP: "192.0.2.2", Probe: "tcp:8443"},
+        {Name: "http", IP: "probed.example", Probe: "http"},
+        {Name: "invalide", IP: "192.0.2.3", Probe: "udp"},
+        {Name: "irrésoluble", IP: "missing.example", Probe: "dns"},
+    }
+    want := map[string]Probe{
+        "192.0.2.2":  {Kind: probeTCP, Port: 8443},
+        "192.0.2.10": {Kind: probeHTTP, Port: defaultHTTPPort},
+    }
+    got := serverProbes(servers, cache)
+    if len(got) != len(want) {
+        t.Fatalf("serverProbes = %v, want %v", got, want)
+    }
+    for ip, p := range want {
+        if got[ip] != p {
+            t.Errorf("serverProbes[%s] = %v, want %v", ip, got[ip], p)
+        }
+    }
+    // Sans sonde, le backend mesure tous les serveurs
+    if got := serverProbes(servers[:1], cache); got != nil {
+        t.Errorf("serverProbes without probes = %v, want nil", got)
+    }
+}
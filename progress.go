@@ -0,0 +1,102 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "log/slog"
+    "os"
+    "strings"
+    "time"
+)
+
+const (
+    progressBarWidth = 30
+    // Hors terminal : un événement tous les 10% ou toutes les 5 secondes
+    progressLineStep     = 0.10
+    progressLineInterval = 5 * time.Second
+)
+
+// progressBar affiche l'avancement des mesures : barre redessinée sur place
+// dans un terminal, événements de log périodiques sinon (service, redirection).
+type progressBar struct {
+    out      io.Writer
+    tty      bool
+    total    int
+    done     int
+    failed   int
+    start    time.Time
+    lastLine time.Time
+    lastFrac float64
+}
+
+func newProgressBar(out *os.File, total int) *progressBar {
+    return &progressBar{
+        out:   out,
+        tty:   out != nil && isTerminal(out),
+        total: total,
+        start: time.Now(),
+    }
+}
+
+// isTerminal indique si f est un terminal (périphérique caractère).
+func isTerminal(f *os.File) bool {
+    fi, err := f.Stat()
+    if err != nil {
+        return false
+    }
+    return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Increment enregistre la fin de la mesure d'un serveur.
+func (p *progressBar) Increment(ok bool) {
+    p.done++
+    if !ok {
+        p.failed++
+    }
+
+    if p.tty {
+        fmt.Fprintf(p.out, "\r%s", p.render())
+        return
+    }
+
+    frac := p.fraction()
+    if p.done == p.total || frac-p.lastFrac >= progressLineStep || time.Since(p.lastLine) >= progressLineInterval {
+        slog.Info("progress", "done", p.done, "total", p.total, "failed", p.failed,
+            "eta", p.eta().Round(time.Second))
+        p.lastFrac = frac
+        p.lastLine = time.Now()
+    }
+}
+
+// Finish termine l'affichage de la barre.
+func (p *progressBar) Finish() {
+    if p.tty {
+        fmt.Fprintln(p.out)
+        fmt.Fprintln(p.out)
+    }
+}
+
+func (p *progressBar) fraction() float64 {
+    if p.total == 0 {
+        return 1
+    }
+    return float64(p.done) / float64(p.total)
+}
+
+// eta estime le temps restant à partir du temps moyen par serveur mesuré.
+func (p *progressBar) eta() time.Duration {
+    if p.done == 0 {
+        return 0
+    }
+    perServer := time.Since(p.start) / time.Duration(p.done)
+    return perServer * time.Duration(p.total-p.done)
+}
+
+func (p *progressBar) render() string {
+    frac := p.fraction()
+    filled := int(frac * progressBarWidth)
+    bar := strings.Repeat("#", filled) + strings.Repeat("-", progressBarWidth-filled)
+
+    return fmt.Sprintf(tr("progress.line"),
+        bar, p.done, p.total, frac*100, p.failed, p.eta().Round(time.Second))
+}
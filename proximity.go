@@ -0,0 +1,77 @@
+package main
+
+import (
+    "fmt"
+    "sort"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Modes de l'indicateur de proximité (--proximity)
+const (
+    proximityFixed    = "fixed"
+    proximityAdaptive = "adaptive"
+)
+
+// Seuils par défaut de l'indicateur de proximité, en ms (--proximity-thresholds)
+const defaultProximityThresholds = "50,100,200"
+
+// Indicateurs de proximité, du plus proche au plus lointain
+var proximityLevels = [...]string{"[+++]", "[++ ]", "[+  ]", "[   ]"}
+
+// proximityScale classe le score d'un serveur (voir rankScore) dans les
+// niveaux de proximityLevels : Thresholds sont les bornes hautes des trois
+// premiers niveaux, croissantes.
+type proximityScale struct {
+    Thresholds [3]time.Duration
+}
+
+func (p proximityScale) indicator(score time.Duration) string {
+    for i, t := range p.Thresholds {
+        if score <= t {
+            return proximityLevels[i]
+        }
+    }
+    return proximityLevels[len(proximityLevels)-1]
+}
+
+// parseProximityThresholds lit trois seuils croissants en ms, séparés par des
+// virgules (ex. "50,100,200").
+func parseProximityThresholds(spec string) (proximityScale, error) {
+    var scale proximityScale
+    fields := strings.Split(spec, ",")
+    if len(fields) != len(scale.Thresholds) {
+        return scale, fmt.Errorf("expected %d thresholds, got %d", len(scale.Thresholds), len(fields))
+    }
+    for i, f := range fields {
+        ms, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+        if err != nil || ms <= 0 {
+            return scale, fmt.Errorf("invalid threshold %q", f)
+        }
+        scale.Thresholds[i] = time.Duration(ms * float64(time.Millisecond))
+        if i > 0 && scale.Thresholds[i] <= scale.Thresholds[i-1] {
+            return scale, fmt.Errorf("thresholds must increase (%q)", spec)
+        }
+    }
+    return scale, nil
+}
+
+// adaptiveProximity déduit les seuils des quartiles des scores observés :
+// chaque niveau réunit environ un quart des serveurs, quelle que soit la
+// latence de base du poste (satellite, réseau mobile).
+func adaptiveProximity(results []Result, rank string) proximityScale {
+    var scale proximityScale
+    if len(results) == 0 {
+        return scale
+    }
+    scores := make([]time.Duration, len(results))
+    for i, r := range results {
+        scores[i] = r.rankScore(rank)
+    }
+    sort.Slice(scores, func(i, j int) bool { return scores[i] < scores[j] })
+    for i := range scale.Thresholds {
+        scale.Thresholds[i] = percentile(scores, float64(i+1)/4)
+    }
+    return scale
+}
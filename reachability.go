@@ -0,0 +1,133 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "io"
+    "sort"
+    "strings"
+    "time"
+)
+
+// Sous-commande de vérification de joignabilité (équivalent de --reachability)
+const reachabilityCommand = "reachability"
+
+// Délai d'un ping de joignabilité, sauf --timeout explicite : un serveur
+// qui met plus longtemps à répondre est inutilisable pour la triangulation
+const reachabilityTimeout = 2 * time.Second
+
+// ServerReachability est l'état d'un serveur lors d'une vérification.
+type ServerReachability struct {
+    Name  string  `json:"name"`
+    IP    string  `json:"ip"`
+    Up    bool    `json:"up"`
+    RTTMs float64 `json:"rtt_ms,omitempty"`
+    Error string  `json:"error,omitempty"`
+}
+
+// RegionReachability regroupe les serveurs d'un continent (ou regionAnycast).
+type RegionReachability struct {
+    Region  string               `json:"region"`
+    Up      int                  `json:"up"`
+    Servers []ServerReachability `json:"servers"`
+}
+
+// Down retourne les noms des serveurs injoignables de la région.
+func (r RegionReachability) Down() []string {
+    var down []string
+    for _, s := range r.Servers {
+        if !s.Up {
+            down = append(down, s.Name)
+        }
+    }
+    return down
+}
+
+// ReachabilityReport est le résultat de --reachability.
+type ReachabilityReport struct {
+    Timestamp   time.Time            `json:"timestamp"`
+    Duration    time.Duration        `json:"duration_ns"`
+    Servers     int                  `json:"servers"`
+    Up          int                  `json:"up"`
+    Interrupted bool                 `json:"interrupted,omitempty"`
+    Regions     []RegionReachability `json:"regions"`
+}
+
+// runReachability pinge chaque serveur de la base une fois (opts.Count
+// réglé par l'appelant) et note seulement s'il a répondu, par continent.
+// Les mesures interrompues ne sont pas comptées.
+func runReachability(ctx context.Context, servers []Server, opts Options) ReachabilityReport {
+    start := time.Now()
+    measurements := sweepServers(ctx, servers, 0, opts)
+    report := ReachabilityReport{Timestamp: start, Duration: time.Since(start)}
+
+    regions := make(map[string]*RegionReachability)
+    for _, m := range measurements {
+        if m.Cancelled {
+            continue
+        }
+        region := continentOf(m.Server.Country)
+        if region == "" {
+            region = regionAnycast
+        }
+        r, ok := regions[region]
+        if !ok {
+            r = &RegionReachability{Region: region}
+            regions[region] = r
+        }
+        s := ServerReachability{Name: m.Server.Name, IP: m.Server.IP, Up: m.Error == "", Error: m.Error}
+        if s.Up {
+            s.RTTMs = milliseconds(m.RTT)
+            r.Up++
+            report.Up++
+        }
+        r.Servers = append(r.Servers, s)
+        report.Servers++
+    }
+
+    for _, r := range regions {
+        report.Regions = append(report.Regions, *r)
+    }
+    sort.Slice(report.Regions, func(i, j int) bool { return report.Regions[i].Region < report.Regions[j].Region })
+    return report
+}
+
+// writeReachability écrit le rapport en texte, Markdown ou JSON (--output) :
+// une ligne par région, suivie des serveurs injoignables.
+func writeReachability(w io.Writer, report ReachabilityReport, format string) error {
+    if format == outputJSON {
+        enc := json.NewEncoder(w)
+        enc.SetIndent("", "  ")
+        return enc.Encode(report)
+    }
+
+    if format == outputMarkdown {
+        fmt.Fprintf(w, "## %s\n\n", strings.TrimSpace(tr("reach.title")))
+        fmt.Fprintln(w, tr("reach.mdHeader"))
+        fmt.Fprintln(w, "|---|---:|---:|---|")
+    } else {
+        fmt.Fprintln(w, "\n"+strings.Repeat("=", 80))
+        fmt.Fprintln(w, tr("reach.title"))
+        fmt.Fprintln(w, strings.Repeat("=", 80))
+        fmt.Fprintln(w, tr("reach.header"))
+    }
+    for _, r := range report.Regions {
+        down := strings.Join(r.Down(), ", ")
+        if format == outputMarkdown {
+            fmt.Fprintf(w, "| %s | %d | %d | %s |\n", r.Region, len(r.Servers), r.Up, down)
+            continue
+        }
+        fmt.Fprintf(w, "%-15s %5d %5d\n", r.Region, len(r.Servers), r.Up)
+        if down != "" {
+            fmt.Fprintf(w, tr("reach.down"), down)
+        }
+    }
+
+    fmt.Fprintln(w)
+    if report.Interrupted {
+        fmt.Fprintf(w, tr("results.interrupted"), report.Servers)
+    }
+    fmt.Fprintf(w, tr("reach.total"), report.Up, report.Servers, report.Duration.Round(100*time.Millisecond))
+    return nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+    "encoding/json"
+    "math"
+    "os"
+    "sort"
+
+    "triangula/geo"
+)
+
+// Écart de résidu RMS (km) au meilleur point en deçà duquel une cellule de
+// la grille appartient à la région probable
+const defaultRegionThresholdKm = 100.0
+
+// Region est l'enveloppe convexe des positions candidates dont l'ajustement
+// est proche du meilleur : « la cible est quelque part là-dedans ».
+type Region struct {
+    Polygon     []Location `json:"polygon"` // sommets, premier et dernier identiques
+    AreaKm2     float64    `json:"area_km2"`
+    BestScoreKm float64    `json:"best_score_km"`
+    ThresholdKm float64    `json:"threshold_km"`
+    Cells       int        `json:"cells"`
+}
+
+// planePoint est une position projetée sur le plan tangent (km).
+type planePoint struct{ x, y float64 }
+
+// regionFromGrid retient les cellules dont le score est à moins de
+// thresholdKm du meilleur et calcule leur enveloppe convexe, projetée sur le
+// plan tangent autour de center pour l'aire et le passage de l'antiméridien.
+func regionFromGrid(cells []HeatCell, center Location, thresholdKm float64) (Region, bool) {
+    if len(cells) == 0 {
+        return Region{}, false
+    }
+    best := math.Inf(1)
+    for _, c := range cells {
+        best = math.Min(best, c.Score)
+    }
+
+    kmPerDeg := geo.EarthRadius * math.Pi / 180
+    cosLat := math.Max(math.Cos(center.Lat*math.Pi/180), 1e-6)
+
+    var points []planePoint
+    for _, c := range cells {
+        if c.Score-best > thresholdKm {
+            continue
+        }
+        points = append(points, planePoint{
+            x: normalizeLon(c.Lon-center.Lon) * kmPerDeg * cosLat,
+            y: (c.Lat - center.Lat) * kmPerDeg,
+        })
+    }
+
+    hull := convexHull(points)
+    region := Region{BestScoreKm: best, ThresholdKm: thresholdKm, Cells: len(points)}
+    for _, p := range hull {
+        region.Polygon = append(region.Polygon, Location{
+            Lat: center.Lat + p.y/kmPerDeg,
+            Lon: normalizeLon(center.Lon + p.x/(kmPerDeg*cosLat)),
+        })
+    }
+    if len(region.Polygon) > 0 {
+        region.Polygon = append(region.Polygon, region.Polygon[0])
+    }
+
+    // Aire (formule du lacet)
+    for i := range hull {
+        j := (i + 1) % len(hull)
+        region.AreaKm2 += hull[i].x*hull[j].y - hull[j].x*hull[i].y
+    }
+    region.AreaKm2 = math.Abs(region.AreaKm2) / 2
+    return region, true
+}
+
+// convexHull retourne l'enveloppe convexe dans le sens trigonométrique
+// (chaîne monotone d'Andrew).
+func convexHull(points []planePoint) []planePoint {
+    if len(points) < 3 {
+        return points
+    }
+    sorted := append([]planePoint(nil), points...)
+    sort.Slice(sorted, func(i, j int) bool {
+        if sorted[i].x != sorted[j].x {
+            return sorted[i].x < sorted[j].x
+        }
+        return sorted[i].y < sorted[j].y
+    })
+
+    cross := func(o, a, b planePoint) float64 {
+        return (a.x-o.x)*(b.y-o.y) - (a.y-o.y)*(b.x-o.x)
+    }
+    var hull []planePoint
+    for pass := 0; pass < 2; pass++ {
+        start := len(hull)
+        for _, p := range sorted {
+            for len(hull) >= start+2 && cross(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+                hull = hull[:len(hull)-1]
+            }
+            hull = append(hull, p)
+        }
+        // Le dernier point de chaque demi-enveloppe est le premier de l'autre
+        hull = hull[:len(hull)-1]
+        for i, j := 0, len(sorted)-1; i < j; i, j = i+1, j-1 {
+            sorted[i], sorted[j] = sorted[j], sorted[i]
+        }
+    }
+    return hull
+}
+
+// writeRegionGeoJSON écrit la région comme une FeatureCollection GeoJSON
+// (coordonnées [lon, lat]), suivie du poste de mesure s'il est connu.
+func writeRegionGeoJSON(path string, region Region, vantage *Location) error {
+    ring := make([][2]float64, len(region.Polygon))
+    for i, p := range region.Polygon {
+        ring[i] = [2]float64{p.Lon, p.Lat}
+    }
+    features := []any{map[string]any{
+        "type": "Feature",
+        "geometry": map[string]any{
+            "type":        "Polygon",
+            "coordinates": [][][2]float64{ring},
+        },
+        "properties": map[string]any{
+            "area_km2":      region.AreaKm2,
+            "best_score_km": region.BestScoreKm,
+            "threshold_km":  region.ThresholdKm,
+            "cells":         region.Cells,
+        },
+    }}
+    if vantage != nil {
+        features = append(features, map[string]any{
+            "type": "Feature",
+            "geometry": map[string]any{
+                "type":        "Point",
+                "coordinates": [2]float64{vantage.Lon, vantage.Lat},
+            },
+            "properties": map[string]any{"role": "vantage"},
+        })
+    }
+    doc := map[string]any{
+        "type":     "FeatureCollection",
+        "features": features,
+    }
+    data, err := json.MarshalIndent(doc, "", "  ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(path, data, 0644)
+}
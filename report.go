@@ -0,0 +1,158 @@
+package main
+
+import (
+    "bytes"
+    _ "embed"
+    "fmt"
+    "io"
+    "math"
+    "os"
+    "strings"
+    "text/template"
+
+    "triangula/geo"
+)
+
+// Nom de --template désignant le gabarit intégré
+const defaultTemplateName = "default"
+
+// defaultReportTemplate reprend la présentation du rapport texte : en-tête,
+// meilleurs serveurs, similarité de latence, méthodes de triangulation et
+// analyse de cohérence. Point de départ des gabarits personnalisés.
+//
+//go:embed report.tmpl
+var defaultReportTemplate string
+
+// Report regroupe les données d'un rapport, passées aux gabarits de
+// --template (text/template).
+type Report struct {
+    Metadata     Metadata
+    Target       Target
+    Summary      SweepSummary
+    Results      []Result // tous les serveurs, dans l'ordre du classement
+    Top          []Result // les reportTopServers premiers
+    Triangulated bool     // false : Estimates et Coherence sont vides
+    Estimates    Estimates
+    Coherence    Coherence
+    Similarity   *SimilarityMatch // nil sans serveur mono-localisé
+    Hosting      *HostingGuess    // nil si la gigue de la cible est inconnue
+    Region       *Region          // nil sans --region ni --out-dir
+
+    rank      string
+    proximity proximityScale
+    servers   []Server
+    cities    []City
+}
+
+// ReportPlace décrit une position estimée pour les gabarits : lignes
+// formatées de la ville connue la plus proche et du pays probable (vides si
+// inconnus), liens cartographiques.
+type ReportPlace struct {
+    Location
+    NearestCity string
+    Country     string
+    Maps        []MapLink
+}
+
+func newReport(meta Metadata, target Target, summary SweepSummary, results []Result, est Estimates, triangulated bool,
+    region *Region, servers []Server, rank string, proximity proximityScale) Report {
+    report := Report{
+        Metadata:     meta,
+        Target:       target,
+        Summary:      summary,
+        Results:      results,
+        Top:          results[:min(reportTopServers, len(results))],
+        Triangulated: triangulated,
+        Region:       region,
+        rank:         rank,
+        proximity:    proximity,
+        servers:      servers,
+        cities:       knownCities(servers),
+    }
+    if triangulated {
+        report.Estimates, report.Coherence = est, assessCoherence(results)
+    }
+    if match, ok := matchSimilarity(results); ok {
+        report.Similarity = &match
+    }
+    if guess, ok := guessHosting(target, results); ok {
+        report.Hosting = &guess
+    }
+    return report
+}
+
+// ComparisonFailure retourne le message d'une comparaison sans position
+// (source injoignable, adresse privée, quota épuisé), terminé par un saut
+// de ligne.
+func (r Report) ComparisonFailure(c GeoComparison) string {
+    return strings.TrimSuffix(comparisonFailure(c), "\n") + "\n"
+}
+
+// MetadataLines retourne les lignes de métadonnées de l'exécution.
+func (r Report) MetadataLines() []string {
+    return metadataLines(r.Metadata)
+}
+
+// Indicator retourne l'indicateur de proximité d'un serveur ([+++] ...).
+func (r Report) Indicator(res Result) string {
+    return r.proximity.indicator(res.rankScore(r.rank))
+}
+
+// MaxDivergenceKm retourne l'écart entre méthodes 1 et 2 au-delà duquel le
+// résultat est signalé comme peu fiable.
+func (Report) MaxDivergenceKm() float64 {
+    return maxMethodDivergenceKm
+}
+
+// Place décrit loc, les cartes étant zoomées selon radiusKm.
+func (r Report) Place(loc Location, radiusKm float64) ReportPlace {
+    place := ReportPlace{Location: loc, Country: countryLine(loc, r.servers), Maps: mapLinks(loc, radiusKm)}
+    if city, d, ok := nearestCity(loc, r.cities); ok {
+        place.NearestCity = fmt.Sprintf(tr("tri.nearestCity"), city.Name, city.Country, formatDistance(d))
+    }
+    return place
+}
+
+// reportFuncs sont les fonctions disponibles dans les gabarits, en plus de
+// celles de text/template.
+var reportFuncs = template.FuncMap{
+    "tr":       tr,
+    "distance": formatDistance, // dans l'unité de --units
+    "area":     formatArea,
+    "km":       func(km float64) string { return fmt.Sprintf("%.0f km", km) },
+    "mi":       func(km float64) string { return fmt.Sprintf("%.0f mi", km/kmPerMile) },
+    "maps":     mapLinks,
+    "repeat":   strings.Repeat,
+    "join":     strings.Join,
+    "add":      func(a, b int) int { return a + b },
+    "mul":      func(a, b float64) float64 { return a * b },
+    "max":      math.Max,
+    "between": func(a, b Location) float64 {
+        return geo.Distance(a.Lat, a.Lon, b.Lat, b.Lon)
+    },
+}
+
+// loadReportTemplate lit et analyse le gabarit path (defaultTemplateName :
+// le gabarit intégré).
+func loadReportTemplate(path string) (*template.Template, error) {
+    name, text := "report.tmpl", defaultReportTemplate
+    if path != defaultTemplateName {
+        data, err := os.ReadFile(path)
+        if err != nil {
+            return nil, err
+        }
+        name, text = path, string(data)
+    }
+    return template.New(name).Funcs(reportFuncs).Parse(text)
+}
+
+// renderReport applique le gabarit au rapport. Rien n'est écrit si
+// l'exécution échoue en cours de route.
+func renderReport(w io.Writer, tmpl *template.Template, report Report) error {
+    var buf bytes.Buffer
+    if err := tmpl.Execute(&buf, report); err != nil {
+        return err
+    }
+    _, err := buf.WriteTo(w)
+    return err
+}
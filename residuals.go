@@ -0,0 +1,102 @@
+package main
+
+import (
+    "encoding/csv"
+    "log/slog"
+    "os"
+    "strconv"
+
+    "triangula/geo"
+)
+
+// ServerResidual compare, pour un serveur de la triangulation, la distance
+// déduite de sa latence à sa distance géographique de la position finale
+// (méthode 3). Un serveur dont le résidu garde le même signe d'une
+// exécution à l'autre est probablement mal localisé ou biaisé.
+type ServerResidual struct {
+    Server       string
+    MeasuredKm   float64 // distance convertie depuis le delta de RTT
+    MinKm        float64 // anneau de l'inégalité triangulaire (voir Result)
+    MaxKm        float64
+    GeographicKm float64 // distance du serveur à l'estimation
+    // ResidualKm : écart de GeographicKm à MeasuredKm, ou à l'anneau
+    // [MinKm, MaxKm] avec --geometry bounds (nul à l'intérieur), comme dans
+    // l'ajustement ; > 0, serveur plus loin que ne le dit sa latence
+    ResidualKm float64
+    Weight     float64 // poids effectif dans les moindres carrés (pondération et poids robuste)
+    // Excluded : serveur hors des moindres carrés (au-delà des
+    // multilatServers premiers) ou écarté par le solveur robuste (poids nul)
+    Excluded bool
+}
+
+// serverResiduals calcule les résidus de tous les serveurs de est.Solved,
+// dans l'ordre du classement, selon la géométrie de la méthode 3.
+func serverResiduals(est Estimates, opts Options) []ServerResidual {
+    opts = opts.withDefaults()
+    points := anchors(est.Solved[:est.MultilatServers], opts)
+    rings := anchors(est.Solved, opts)
+    p := est.LeastSquares
+
+    residuals := make([]ServerResidual, len(est.Solved))
+    for i, r := range est.Solved {
+        loc := serverLocation(r.Server)
+        res := ServerResidual{
+            Server:       r.Server.Name,
+            MeasuredKm:   r.Distance,
+            MinKm:        r.Distance,
+            MaxKm:        r.MaxDistance,
+            GeographicKm: geo.Distance(p.Lat, p.Lon, loc.Lat, loc.Lon),
+            ResidualKm:   rings[i].Residual(p),
+            Excluded:     i >= len(points),
+        }
+        if !res.Excluded {
+            res.Weight = points[i].Weight
+            if i < len(est.SolverWeights) {
+                res.Weight *= est.SolverWeights[i]
+            }
+            res.Excluded = res.Weight == 0
+        }
+        residuals[i] = res
+    }
+    return residuals
+}
+
+// exportResiduals écrit les résidus de est dans path (--residuals-csv) et
+// retourne le nombre de serveurs écrits ; 0 en cas d'échec, journalisé.
+func exportResiduals(path string, est Estimates, opts Options) int {
+    residuals := serverResiduals(est, opts)
+    if err := writeResidualsCSV(path, residuals); err != nil {
+        slog.Error("cannot write residuals", "path", path, "error", err)
+        return 0
+    }
+    return len(residuals)
+}
+
+// writeResidualsCSV écrit les résidus en CSV (server, measured_km, min_km,
+// max_km, geographic_km, residual_km, weight, excluded).
+func writeResidualsCSV(path string, residuals []ServerResidual) error {
+    f, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+    w := csv.NewWriter(f)
+    w.Write([]string{"server", "measured_km", "min_km", "max_km", "geographic_km", "residual_km", "weight", "excluded"})
+    for _, r := range residuals {
+        w.Write([]string{
+            r.Server,
+            strconv.FormatFloat(r.MeasuredKm, 'f', 1, 64),
+            strconv.FormatFloat(r.MinKm, 'f', 1, 64),
+            strconv.FormatFloat(r.MaxKm, 'f', 1, 64),
+            strconv.FormatFloat(r.GeographicKm, 'f', 1, 64),
+            strconv.FormatFloat(r.ResidualKm, 'f', 1, 64),
+            strconv.FormatFloat(r.Weight, 'f', 3, 64),
+            strconv.FormatBool(r.Excluded),
+        })
+    }
+    w.Flush()
+    if err := w.Error(); err != nil {
+        f.Close()
+        return err
+    }
+    return f.Close()
+}
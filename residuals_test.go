@@ -0,0 +1,39 @@
+package main
+
+import (
+    "math"
+    "testing"
+
+    "triangula/geo"
+)
+
+func TestServerResidualsFollowGeometry(t *testing.T) {
+    // Serveur à Paris, estimation à 200 km plus au nord
+    r := resultAt("paris", Location{Lat: 48.8566, Lon: 2.3522}, 0)
+    r.Distance, r.MaxDistance = 100, 300
+    est := Estimates{
+        Solved:          []Result{r},
+        MultilatServers: 1,
+        LeastSquares:    Location{Lat: 48.8566 + 200/(geo.EarthRadius*math.Pi/180), Lon: 2.3522},
+    }
+
+    tests := []struct {
+        geometry string
+        want     float64
+    }{
+        {geometry: geometryDelta, want: 100},
+        {geometry: geometryBounds, want: 0}, // dans l'anneau [100, 300]
+    }
+    for _, tt := range tests {
+        got := serverResiduals(est, Options{Geometry: tt.geometry})
+        if len(got) != 1 {
+            t.Fatalf("%s: %d residuals, want 1", tt.geometry, len(got))
+        }
+        if math.Abs(got[0].ResidualKm-tt.want) > 1e-6 {
+            t.Errorf("%s: residual = %v km, want %v", tt.geometry, got[0].ResidualKm, tt.want)
+        }
+        if got[0].MinKm != 100 || got[0].MaxKm != 300 {
+            t.Errorf("%s: bounds = [%v, %v], want [100, 300]", tt.geometry, got[0].MinKm, got[0].MaxKm)
+        }
+    }
+}
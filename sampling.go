@@ -0,0 +1,24 @@
+package main
+
+import "time"
+
+// Nombre minimal de réponses par défaut avant l'arrêt anticipé (--min-count)
+const defaultMinPingCount = 3
+
+// sequentialSampling arrête une mesure avant son nombre de paquets (qui
+// devient un maximum) dès que l'écart-type courant des RTT reçus passe sous
+// TargetStdDev, après au moins MinCount réponses : les serveurs stables
+// sont mesurés vite, les instables reçoivent le reste du budget. Désactivé
+// si TargetStdDev est nul.
+type sequentialSampling struct {
+    MinCount     int
+    TargetStdDev time.Duration
+}
+
+// done indique si les échantillons reçus suffisent.
+func (s sequentialSampling) done(samples []time.Duration) bool {
+    if s.TargetStdDev <= 0 || len(samples) < max(s.MinCount, 2) {
+        return false
+    }
+    return statsFromSamples(samples, len(samples)).StdDev <= s.TargetStdDev
+}
@@ -0,0 +1,265 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "log/slog"
+    "net"
+    "net/http"
+    "sync"
+    "time"
+
+    "github.com/gorilla/websocket"
+    "github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Nombre de serveurs retournés dans la réponse de /triangulate
+const apiTopResults = 15
+
+// Triangulations simultanées par défaut (/triangulate et /ws confondus)
+const defaultServeConcurrency = 4
+
+// Délais du serveur HTTP. Une triangulation dure autant qu'une campagne de
+// mesures : l'écriture n'est pas bornée par requête mais par réponse
+// (serveWriteTimeout) ou par message de la socket (wsWriteTimeout).
+const (
+    serveReadHeaderTimeout = 10 * time.Second
+    serveReadTimeout       = 30 * time.Second
+    serveIdleTimeout       = 2 * time.Minute
+    serveWriteTimeout      = 30 * time.Second
+    wsWriteTimeout         = 10 * time.Second
+    // Attente des requêtes en cours à l'arrêt du service
+    serveShutdownTimeout = 30 * time.Second
+)
+
+// triangulateResponse est le corps JSON retourné par /triangulate.
+type triangulateResponse struct {
+    Metadata   Metadata         `json:"metadata"`
+    Target     Target           `json:"target"`
+    Responded  int              `json:"responded"`
+    Results    []Result         `json:"results"`
+    Estimates  *Estimates       `json:"estimates,omitempty"`
+    Similarity *SimilarityMatch `json:"similarity,omitempty"`
+}
+
+// apiServer expose la triangulation en HTTP (--serve). servers est
+// remplacé après chaque campagne par les serveurs mesurés, pour que les
+// requêtes suivantes réutilisent les mesures fraîches (Options.ServerTTL).
+type apiServer struct {
+    mu      sync.Mutex
+    servers []Server
+    opts    Options
+    meta    Metadata      // complété à chaque triangulation
+    limit   chan struct{} // une place par triangulation en cours
+}
+
+// serve démarre le mode service : /triangulate?target=..., /ws?target=...
+// et /metrics. Au plus concurrency triangulations tournent en même temps,
+// les suivantes attendent une place. L'annulation de ctx arrête le service :
+// les triangulations en cours sont annulées et leurs réponses attendues au
+// plus serveShutdownTimeout.
+func serve(ctx context.Context, addr string, servers []Server, opts Options, concurrency int, meta Metadata) error {
+    registerMetrics()
+
+    opts.Measurer = newInstrumentedMeasurer(opts.withDefaults().Measurer, servers)
+    // Chaque requête crée sa propre source aléatoire (rand.Rand n'est pas thread-safe)
+    opts.Rand = nil
+    api := &apiServer{servers: servers, opts: opts, meta: meta, limit: make(chan struct{}, concurrency)}
+
+    mux := http.NewServeMux()
+    mux.HandleFunc("/triangulate", api.handleTriangulate)
+    mux.HandleFunc("/ws", api.handleWebSocket)
+    mux.Handle("/metrics", promhttp.Handler())
+
+    srv := &http.Server{
+        Addr:              addr,
+        Handler:           mux,
+        ReadHeaderTimeout: serveReadHeaderTimeout,
+        ReadTimeout:       serveReadTimeout,
+        IdleTimeout:       serveIdleTimeout,
+        // Les requêtes héritent de ctx : l'arrêt annule les triangulations,
+        // y compris celles des sockets, que Shutdown ne suit pas
+        BaseContext: func(net.Listener) context.Context { return ctx },
+    }
+    errs := make(chan error, 1)
+    go func() {
+        slog.Info("serving", "addr", addr)
+        errs <- srv.ListenAndServe()
+    }()
+
+    select {
+    case err := <-errs:
+        return err
+    case <-ctx.Done():
+    }
+    slog.Info("shutting down", "addr", addr)
+    shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+    defer cancel()
+    return srv.Shutdown(shutdownCtx)
+}
+
+// snapshot retourne les serveurs à mesurer pour une nouvelle requête.
+func (a *apiServer) snapshot() []Server {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    return a.servers
+}
+
+// update conserve les serveurs mesurés par une campagne.
+func (a *apiServer) update(measurements []Measurement) {
+    a.mu.Lock()
+    defer a.mu.Unlock()
+    a.servers = measuredServers(measurements)
+}
+
+func (a *apiServer) handleTriangulate(w http.ResponseWriter, r *http.Request) {
+    input, port, err := normalizeTarget(r.URL.Query().Get("target"))
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    if !a.acquire(r.Context()) {
+        return
+    }
+    defer a.release()
+
+    start := time.Now()
+    target, err := newTarget(input, port, a.opts.DNSCache)
+    if err != nil {
+        triangulationsTotal.WithLabelValues("error").Inc()
+        http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+        return
+    }
+    measurements, err := runMeasurements(r.Context(), &target, a.snapshot(), a.opts.withDefaults())
+    if err != nil {
+        triangulationsTotal.WithLabelValues("error").Inc()
+        slog.Warn("triangulation failed", "target", input, "error", err)
+        http.Error(w, err.Error(), http.StatusBadGateway)
+        return
+    }
+
+    a.update(measurements)
+    results := buildResults(measurements, target.RTT, a.opts)
+    resp := triangulateResponse{Metadata: a.meta.withSweep(start, summarizeSweep(measurements)), Target: target, Responded: len(results), Results: results}
+    if len(resp.Results) > apiTopResults {
+        resp.Results = resp.Results[:apiTopResults]
+    }
+    if est, err := estimatePositions(results, a.opts); err == nil {
+        resp.Estimates = &est
+    }
+    if match, ok := matchSimilarity(results); ok {
+        resp.Similarity = &match
+    }
+
+    triangulationsTotal.WithLabelValues("success").Inc()
+    triangulationDuration.Observe(time.Since(start).Seconds())
+
+    if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(serveWriteTimeout)); err != nil {
+        slog.Debug("cannot set response write deadline", "error", err)
+    }
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(resp); err != nil {
+        slog.Debug("cannot write response", "target", input, "error", err)
+    }
+}
+
+// acquire réserve une place de triangulation ; false si ctx est annulé
+// avant qu'une place se libère.
+func (a *apiServer) acquire(ctx context.Context) bool {
+    select {
+    case a.limit <- struct{}{}:
+        return true
+    case <-ctx.Done():
+        return false
+    }
+}
+
+func (a *apiServer) release() {
+    <-a.limit
+}
+
+// Origine vérifiée par défaut : seules les pages servies par le même hôte
+// peuvent ouvrir la socket
+var wsUpgrader = websocket.Upgrader{}
+
+// handleWebSocket diffuse sur la socket les événements de --output=ndjson
+// (cible, chaque serveur, synthèse) au fil d'une triangulation. La
+// fermeture de la socket par le client annule la triangulation, comme un
+// message qui ne peut être écrit en wsWriteTimeout (client bloqué) : la
+// place de triangulation est alors libérée.
+func (a *apiServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+    input, port, err := normalizeTarget(r.URL.Query().Get("target"))
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+    conn, err := wsUpgrader.Upgrade(w, r, nil)
+    if err != nil {
+        slog.Debug("websocket upgrade failed", "error", err) // réponse déjà envoyée
+        return
+    }
+    defer conn.Close()
+
+    ctx, cancel := context.WithCancel(r.Context())
+    defer cancel()
+    go func() {
+        defer cancel()
+        for {
+            if _, _, err := conn.NextReader(); err != nil {
+                return
+            }
+        }
+    }()
+
+    if !a.acquire(ctx) {
+        return
+    }
+    defer a.release()
+
+    events := &eventStream{write: func(event any) error {
+        err := conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+        if err == nil {
+            err = conn.WriteJSON(event)
+        }
+        if err != nil {
+            cancel()
+        }
+        return err
+    }}
+    opts := a.opts.withDefaults()
+    opts.Observer = events
+
+    start := time.Now()
+    target, err := newTarget(input, port, a.opts.DNSCache)
+    if err != nil {
+        triangulationsTotal.WithLabelValues("error").Inc()
+        events.Error(err.Error())
+        return
+    }
+    measurements, err := runMeasurements(ctx, &target, a.snapshot(), opts)
+    if ctx.Err() != nil {
+        slog.Info("websocket closed or stalled, triangulation cancelled", "target", input)
+        return
+    }
+    if err != nil {
+        triangulationsTotal.WithLabelValues("error").Inc()
+        slog.Warn("triangulation failed", "target", input, "error", err)
+        events.Error(err.Error())
+        return
+    }
+
+    a.update(measurements)
+    results := buildResults(measurements, target.RTT, opts)
+    var estimates *Estimates
+    if est, err := estimatePositions(results, opts); err == nil {
+        estimates = &est
+    }
+    summary := summarizeSweep(measurements)
+    events.Result(a.meta.withSweep(start, summary), target, summary, results, estimates, nil, opts.PacketSize)
+
+    triangulationsTotal.WithLabelValues("success").Inc()
+    triangulationDuration.Observe(time.Since(start).Seconds())
+    conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+        time.Now().Add(wsWriteTimeout))
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "os"
+    "time"
+)
+
+// Version du format des sessions et rapports d'agents. Un fichier d'un
+// format plus récent est refusé ; 0 désigne les sessions antérieures au
+// marquage, compatibles.
+const reportFormat = 1
+
+// Session contient toutes les mesures brutes d'une exécution, pour rejouer
+// la triangulation hors ligne (--replay) sans trafic réseau. C'est aussi le
+// rapport qu'un agent transmet au coordinateur (--coordinate) : Agent et
+// Vantage identifient alors le point de mesure.
+type Session struct {
+    Format       int           `json:"format"`
+    Version      string        `json:"version"`
+    Timestamp    time.Time     `json:"timestamp"`
+    Seed         int64         `json:"seed"`
+    Agent        string        `json:"agent,omitempty"`       // nom du point de mesure (--agent-name)
+    Vantage      *Location     `json:"vantage,omitempty"`     // position du point de mesure (--vantage), nil si inconnue
+    PacketSize   int           `json:"packet_size,omitempty"` // charge utile ICMP (--packet-size), 0 : taille par défaut
+    Source       string        `json:"source,omitempty"`      // adresse source des sondes (--source, --interface)
+    Target       Target        `json:"target"`
+    Measurements []Measurement `json:"measurements"`
+
+    // Biais par IP appliqués (--calibration), repris au rejeu sans --calibration
+    Skews map[string]time.Duration `json:"skews_ns,omitempty"`
+}
+
+func saveSession(path string, session Session) error {
+    data, err := json.MarshalIndent(session, "", "  ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(path, data, 0644)
+}
+
+func loadSession(path string) (Session, error) {
+    var session Session
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return session, err
+    }
+    if err := json.Unmarshal(data, &session); err != nil {
+        return session, fmt.Errorf("%s: %v", path, err)
+    }
+    if session.Format > reportFormat {
+        return session, fmt.Errorf("%s: format %d is not supported (this version reads up to %d)",
+            path, session.Format, reportFormat)
+    }
+    if session.Target.RTT <= 0 {
+        return session, fmt.Errorf("%s: missing target RTT", path)
+    }
+    return session, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestLoadSession(t *testing.T) {
+    tests := []struct {
+        name string
+        data string
+        want string // extrait de l'erreur attendue ; vide : chargée
+    }{
+        {name: "format courant", data: `{"format": 1, "target": {"ip": "192.0.2.1", "rtt_ns": 1000000}}`},
+        {name: "antérieure au marquage", data: `{"target": {"ip": "192.0.2.1", "rtt_ns": 1000000}}`},
+        {name: "format plus récent", data: `{"format": 2, "target": {"ip": "192.0.2.1", "rtt_ns": 1000000}}`,
+            want: "format 2 is not supported"},
+        {name: "RTT de la cible manquant", data: `{"format": 1, "target": {"ip": "192.0.2.1"}}`, want: "missing target RTT"},
+        {name: "JSON invalide", data: `{"format": `, want: "unexpected end of JSON input"},
+    }
+    dir := t.TempDir()
+    for i, tt := range tests {
+        path := filepath.Join(dir, fmt.Sprintf("%d.json", i))
+        if err := os.WriteFile(path, []byte(tt.data), 0644); err != nil {
+            t.Fatal(err)
+        }
+        _, err := loadSession(path)
+        if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+            t.Errorf("%s: loadSession error = %v, want %q", tt.name, err, tt.want)
+        }
+    }
+}
+
+func TestSessionRoundTrip(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "session.json")
+    saved := Session{
+        Format: reportFormat,
+        Seed:   42,
+        Target: Target{Input: "example.com", IP: "192.0.2.1", RTT: 12 * time.Millisecond},
+        Measurements: []Measurement{
+            {Server: Server{Name: "paris", IP: "192.0.2.2"}, RTT: 3 * time.Millisecond},
+            {Server: Server{Name: "muet", IP: "192.0.2.3"}, Error: "no reply"},
+        },
+    }
+    if err := saveSession(path, saved); err != nil {
+        t.Fatal(err)
+    }
+    loaded, err := loadSession(path)
+    if err != nil {
+        t.Fatalf("loadSession: %v", err)
+    }
+    if loaded.Format != reportFormat || loaded.Seed != saved.Seed || loaded.Target.RTT != saved.Target.RTT ||
+        len(loaded.Measurements) != len(saved.Measurements) || loaded.Measurements[1].Error != "no reply" {
+        t.Errorf("loadSession = %+v, want %+v", loaded, saved)
+    }
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+    "net"
+    "sort"
+
+    "triangula/geo"
+)
+
+const (
+    // Nombre de serveurs mono-localisés les plus similaires retenus
+    similarityServers = 5
+    // Dispersion maximale (km) des serveurs retenus pour une confiance élevée
+    similarityTightKm = 500.0
+)
+
+// Niveaux de confiance de la méthode par similarité
+const (
+    similarityHigh   = "high"
+    similarityMedium = "medium"
+    similarityLow    = "low"
+)
+
+// Préfixes anycast connus : la même adresse répond depuis de nombreux sites,
+// sa position dans la base ne dit donc rien de la cible.
+var anycastPrefixes = mustParseCIDRs(
+    "1.1.1.0/24", "1.0.0.0/24",           // Cloudflare DNS
+    "104.16.0.0/13", "162.158.0.0/15",    // Cloudflare CDN
+    "8.8.8.0/24", "8.8.4.0/24",           // Google Public DNS
+    "9.9.9.0/24",                         // Quad9
+    "208.67.222.0/24", "208.67.220.0/24", // OpenDNS
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+    nets := make([]*net.IPNet, len(cidrs))
+    for i, c := range cidrs {
+        _, n, err := net.ParseCIDR(c)
+        if err != nil {
+            panic(err)
+        }
+        nets[i] = n
+    }
+    return nets
+}
+
+// isAnycast indique si le serveur n'a pas d'emplacement unique : entrée
+// "Global" de la base ou adresse d'un préfixe anycast connu.
+func isAnycast(s Server) bool {
+    if s.Country == "Global" {
+        return true
+    }
+    ip := net.ParseIP(s.IP)
+    for _, n := range anycastPrefixes {
+        if ip != nil && n.Contains(ip) {
+            return true
+        }
+    }
+    return false
+}
+
+// SimilarityMatch est la région estimée par similarité de latence : la cible
+// est supposée proche des serveurs dont le RTT mesuré depuis le poste est le
+// plus proche du sien.
+type SimilarityMatch struct {
+    Region       Location `json:"region"`        // barycentre pondéré des serveurs retenus
+    Country      string   `json:"country"`       // pays majoritaire
+    CountryShare float64  `json:"country_share"`
+    SpreadKm     float64  `json:"spread_km"`     // distance moyenne des serveurs retenus à la région
+    Confidence   string   `json:"confidence"`    // similarity*
+    Servers      []string `json:"servers"`
+}
+
+// matchSimilarity retient les serveurs mono-localisés au RTT le plus proche
+// de celui de la cible (results est trié par delta). false s'il n'y en a aucun.
+func matchSimilarity(results []Result) (SimilarityMatch, bool) {
+    var best []Result
+    for _, r := range results {
+        if len(best) == similarityServers {
+            break
+        }
+        if !isAnycast(r.Server) {
+            best = append(best, r)
+        }
+    }
+    if len(best) == 0 {
+        return SimilarityMatch{}, false
+    }
+
+    match := SimilarityMatch{
+        Region: multilateralTriangulation(best, len(best)),
+    }
+    if len(best) < 3 {
+        // multilateralTriangulation exige 3 serveurs : repli sur le meilleur
+        match.Region = serverLocation(best[0].Server)
+    }
+
+    counts := make(map[string]int)
+    for _, r := range best {
+        match.Servers = append(match.Servers, r.Server.Name)
+        counts[r.Server.Country]++
+        match.SpreadKm += geo.Distance(match.Region.Lat, match.Region.Lon, r.Server.Lat, r.Server.Lon)
+    }
+    match.SpreadKm /= float64(len(best))
+
+    countries := make([]string, 0, len(counts))
+    for c := range counts {
+        countries = append(countries, c)
+    }
+    sort.Slice(countries, func(i, j int) bool {
+        if counts[countries[i]] != counts[countries[j]] {
+            return counts[countries[i]] > counts[countries[j]]
+        }
+        return countries[i] < countries[j]
+    })
+    match.Country = countries[0]
+    match.CountryShare = float64(counts[match.Country]) / float64(len(best))
+
+    switch {
+    case len(best) >= 3 && match.CountryShare >= 0.8 && match.SpreadKm <= similarityTightKm:
+        match.Confidence = similarityHigh
+    case match.CountryShare >= countryMajority:
+        match.Confidence = similarityMedium
+    default:
+        match.Confidence = similarityLow
+    }
+    return match, true
+}
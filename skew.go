@@ -0,0 +1,148 @@
+package main
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "os"
+    "sort"
+    "strings"
+    "time"
+
+    "triangula/geo"
+)
+
+// Sous-commande de calibration des serveurs de référence depuis un poste de
+// position connue (--vantage)
+const calibrateCommand = "calibrate"
+
+// Nombre de serveurs les plus biaisés signalés par la calibration
+const reportedSkews = 3
+
+// RTT maximal considéré par rttForDistance : au-delà d'un aller-retour aux
+// antipodes, même au modèle le plus lent
+const maxCalibrationRTT = 2 * time.Second
+
+var errNotEnoughAnchors = errors.New("fewer than 3 located servers responded")
+
+// Calibration est le fichier écrit par la sous-commande calibrate (--out) et
+// relu par --calibration. Skews associe l'IP d'un serveur à son biais : le
+// temps dont il répond plus lentement (positif) ou plus vite (négatif) que
+// ne le prédit le modèle pour sa distance au poste, une fois retiré le
+// délai commun à tous les serveurs (dernier kilomètre du poste).
+type Calibration struct {
+    Format    int                      `json:"format"`
+    Version   string                   `json:"version"`
+    Timestamp time.Time                `json:"timestamp"`
+    Vantage   Location                 `json:"vantage"`
+    Model     string                   `json:"model"`
+    Common    time.Duration            `json:"common_ns"` // délai médian commun, non inclus dans Skews
+    Skews     map[string]time.Duration `json:"skews_ns"`
+}
+
+// ServerSkew est le biais mesuré d'un serveur de référence.
+type ServerSkew struct {
+    Server Server
+    Skew   time.Duration
+}
+
+// rttForDistance inverse le modèle : RTT pour lequel model prédit km, par
+// dichotomie (les modèles sont croissants en RTT).
+func rttForDistance(model geo.DistanceModel, km float64, baseline time.Duration) time.Duration {
+    lo, hi := time.Duration(0), maxCalibrationRTT
+    for hi-lo > time.Microsecond {
+        mid := (lo + hi) / 2
+        if model.Distance(mid, baseline) < km {
+            lo = mid
+        } else {
+            hi = mid
+        }
+    }
+    return (lo + hi) / 2
+}
+
+// calibrate mesure les serveurs depuis vantage et ajuste à chacun un biais
+// additif : écart entre son RTT et celui que le modèle prédit pour sa
+// distance au poste, diminué de l'écart médian (commun à tous les serveurs).
+// Les serveurs anycast, sans emplacement unique, sont ignorés. Les biais
+// sont retournés du plus fort au plus faible en valeur absolue.
+func calibrate(ctx context.Context, servers []Server, vantage Location, opts Options) ([]ServerSkew, time.Duration, error) {
+    opts = opts.withDefaults()
+
+    var skews []ServerSkew
+    var offsets []time.Duration
+    for _, m := range sweepServers(ctx, servers, 0, opts) {
+        if m.Error != "" || isAnycast(m.Server) {
+            continue
+        }
+        km := geo.Distance(vantage.Lat, vantage.Lon, m.Server.Lat, m.Server.Lon)
+        offset := m.RTT - rttForDistance(opts.Model, km, opts.baselineFor(m.Server))
+        skews = append(skews, ServerSkew{Server: m.Server, Skew: offset})
+        offsets = append(offsets, offset)
+    }
+    if len(skews) < 3 {
+        return nil, 0, errNotEnoughAnchors
+    }
+
+    sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+    common := percentile(offsets, 0.5)
+    for i := range skews {
+        skews[i].Skew -= common
+    }
+    sort.SliceStable(skews, func(i, j int) bool {
+        return absDuration(skews[i].Skew) > absDuration(skews[j].Skew)
+    })
+    return skews, common, nil
+}
+
+func absDuration(d time.Duration) time.Duration {
+    if d < 0 {
+        return -d
+    }
+    return d
+}
+
+func saveCalibration(path string, c Calibration) error {
+    data, err := json.MarshalIndent(c, "", "  ")
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(path, data, 0644)
+}
+
+// loadCalibration lit les biais par IP d'un fichier de calibrate.
+func loadCalibration(path string) (map[string]time.Duration, error) {
+    var c Calibration
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    if err := json.Unmarshal(data, &c); err != nil {
+        return nil, fmt.Errorf("%s: %v", path, err)
+    }
+    if c.Format > reportFormat {
+        return nil, fmt.Errorf("%s: format %d is not supported (this version reads up to %d)",
+            path, c.Format, reportFormat)
+    }
+    return c.Skews, nil
+}
+
+// skewFor retourne le biais de calibration du serveur, 0 s'il est inconnu.
+func (o Options) skewFor(s Server) time.Duration {
+    return o.Skews[s.IP]
+}
+
+// displaySkews affiche les serveurs les plus biaisés, candidats à --exclude.
+func displaySkews(w io.Writer, skews []ServerSkew, common time.Duration) {
+    fmt.Fprintln(w, "\n"+strings.Repeat("=", 80))
+    fmt.Fprintln(w, tr("calib.title"))
+    fmt.Fprintln(w, strings.Repeat("=", 80))
+    fmt.Fprintf(w, tr("calib.common"), common, len(skews))
+    fmt.Fprintln(w, tr("calib.top"))
+    for i := 0; i < reportedSkews && i < len(skews); i++ {
+        s := skews[i]
+        fmt.Fprintf(w, "   %-20s | %-15s | %-12s | %+v\n", s.Server.Name, s.Server.IP, s.Server.City, s.Skew)
+    }
+}
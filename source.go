@@ -0,0 +1,69 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "net"
+)
+
+var errSourceAndInterface = errors.New("--source and --interface are mutually exclusive")
+
+// resolveSource détermine l'adresse source des sondes (--source ou
+// --interface, dont on prend la première adresse IPv4, à défaut IPv6) et
+// vérifie qu'elle peut être liée : une adresse absente de l'hôte ferait
+// sinon échouer chaque ping séparément. Vide si aucune n'est demandée.
+func resolveSource(source, iface string) (string, error) {
+    if source != "" && iface != "" {
+        return "", errSourceAndInterface
+    }
+    if iface != "" {
+        var err error
+        if source, err = interfaceAddress(iface); err != nil {
+            return "", err
+        }
+    }
+    if source == "" {
+        return "", nil
+    }
+
+    ip := net.ParseIP(source)
+    if ip == nil {
+        return "", fmt.Errorf("%q is not an IP address", source)
+    }
+    conn, err := net.ListenPacket("udp", net.JoinHostPort(ip.String(), "0"))
+    if err != nil {
+        return "", fmt.Errorf("cannot bind %s: %v", ip, err)
+    }
+    conn.Close()
+    return ip.String(), nil
+}
+
+// interfaceAddress retourne la première adresse de l'interface, IPv4 de
+// préférence.
+func interfaceAddress(name string) (string, error) {
+    iface, err := net.InterfaceByName(name)
+    if err != nil {
+        return "", err
+    }
+    addrs, err := iface.Addrs()
+    if err != nil {
+        return "", fmt.Errorf("%s: %v", name, err)
+    }
+    var fallback net.IP
+    for _, addr := range addrs {
+        ipnet, ok := addr.(*net.IPNet)
+        if !ok || ipnet.IP.IsLinkLocalUnicast() {
+            continue
+        }
+        if ipnet.IP.To4() != nil {
+            return ipnet.IP.String(), nil
+        }
+        if fallback == nil {
+            fallback = ipnet.IP
+        }
+    }
+    if fallback == nil {
+        return "", fmt.Errorf("interface %s has no usable address", name)
+    }
+    return fallback.String(), nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "math"
+    "net"
+    "os/exec"
+    "regexp"
+    "runtime"
+    "strconv"
+    "time"
+)
+
+// Mesures disponibles via --backend
+const (
+    backendICMP   = "icmp"   // sockets ICMP de go-ping (root ou net.ipv4.ping_group_range)
+    backendSystem = "system" // commande ping du système (souvent setuid)
+)
+
+var (
+    // Linux : "rtt min/avg/max/mdev = 9.8/10.1/10.6/0.3 ms"
+    // BSD/macOS : "round-trip min/avg/max/stddev = 9.8/10.1/10.6/0.3 ms"
+    pingSummaryRe = regexp.MustCompile(`(?:rtt|round-trip) min/avg/max/(?:mdev|stddev) = ([\d.]+)/([\d.]+)/([\d.]+)/([\d.]+) ms`)
+    // "3 packets transmitted, 3 received" (Linux), "3 packets received" (BSD)
+    pingCountRe = regexp.MustCompile(`(\d+) packets transmitted, (\d+) (?:packets )?received`)
+)
+
+// systemMeasurer mesure le RTT en exécutant la commande ping du système,
+// pour les hôtes où les sockets ICMP ne sont pas autorisées.
+type systemMeasurer struct {
+    Timeout time.Duration
+    Size    int    // charge utile ICMP (-s) ; 0 : taille par défaut de ping
+    Source  string // adresse source (-I sous Linux, -S sous BSD) ; vide : route par défaut
+}
+
+func (m systemMeasurer) Measure(ctx context.Context, ip string, count int) (PingStats, error) {
+    ctx, cancel := context.WithTimeout(ctx, m.Timeout)
+    defer cancel()
+
+    name, args := systemPingCommand(ip, count, m.Timeout, m.Size, m.Source)
+    out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+    if ctx.Err() == context.Canceled {
+        return PingStats{}, ctx.Err()
+    }
+
+    stats, parseErr := parsePingOutput(string(out))
+    if parseErr != nil {
+        if err != nil {
+            return PingStats{}, fmt.Errorf("%s: %v", name, err)
+        }
+        return PingStats{}, parseErr
+    }
+    return stats, nil
+}
+
+// systemPingCommand construit la commande selon la plateforme : le délai
+// d'attente s'exprime en secondes, par réponse avec -W sous Linux et pour
+// l'ensemble de la commande avec -t sous BSD/macOS ; de même l'adresse
+// source se passe avec -I ou -S.
+func systemPingCommand(ip string, count int, timeout time.Duration, size int, source string) (string, []string) {
+    seconds := strconv.Itoa(int(math.Max(1, math.Ceil(timeout.Seconds()))))
+    name := "ping"
+    args := []string{"-n", "-c", strconv.Itoa(count)}
+    if size > 0 {
+        args = append(args, "-s", strconv.Itoa(size))
+    }
+
+    switch runtime.GOOS {
+    case "linux":
+        if source != "" {
+            args = append(args, "-I", source)
+        }
+        args = append(args, "-W", seconds)
+    case "darwin", "freebsd", "netbsd", "openbsd", "dragonfly":
+        if source != "" {
+            args = append(args, "-S", source)
+        }
+        if parsed := net.ParseIP(ip); parsed != nil && parsed.To4() == nil {
+            // ping6 ne connaît pas -t
+            return "ping6", append(args, ip)
+        }
+        args = append(args, "-t", seconds)
+    }
+    return name, append(args, ip)
+}
+
+// parsePingOutput extrait les statistiques de la sortie de ping (Linux ou BSD).
+func parsePingOutput(out string) (PingStats, error) {
+    var stats PingStats
+    if m := pingCountRe.FindStringSubmatch(out); m != nil {
+        stats.Sent, _ = strconv.Atoi(m[1])
+        stats.Received, _ = strconv.Atoi(m[2])
+    }
+
+    m := pingSummaryRe.FindStringSubmatch(out)
+    if m == nil || stats.Received == 0 {
+        return PingStats{}, fmt.Errorf("%s", tr("ping.noReply"))
+    }
+    avg, err := strconv.ParseFloat(m[2], 64)
+    if err != nil {
+        return PingStats{}, err
+    }
+    stddev, err := strconv.ParseFloat(m[4], 64)
+    if err != nil {
+        return PingStats{}, err
+    }
+
+    stats.Avg = time.Duration(avg * float64(time.Millisecond))
+    stats.StdDev = time.Duration(stddev * float64(time.Millisecond))
+    return stats, nil
+}
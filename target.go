@@ -0,0 +1,138 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "net"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// Target regroupe les informations connues sur la cible analysée.
+type Target struct {
+    Input  string        `json:"input"`                // saisie utilisateur (IP ou domaine)
+    IP     string        `json:"ip"`                   // adresse résolue
+    Port   int           `json:"port,omitempty"`       // port TCP (hôte:port), 0 si non précisé
+    RTT    time.Duration `json:"rtt_ns"`
+    Jitter time.Duration `json:"jitter_ns,omitempty"`  // écart-type des pings vers la cible
+    Cached bool          `json:"cached,omitempty"`     // RTT repris du cache (--target-cache-ttl)
+    PTR    []string      `json:"ptr,omitempty"`        // enregistrements DNS inverses, vide si aucun
+    ASN    *ASNInfo      `json:"asn,omitempty"`        // nil si non demandé (--asn) ou introuvable
+}
+
+// normalizeTarget extrait l'hôte et le port éventuel d'une saisie (URL
+// collée, chemin final, "hôte:port", "[IPv6]:port") et vérifie qu'il s'agit
+// d'une IP ou d'un nom d'hôte valide, avant toute activité réseau.
+// Le port vaut 0 s'il n'est pas précisé.
+func normalizeTarget(input string) (string, int, error) {
+    host := strings.TrimSpace(input)
+    if host == "" {
+        return "", 0, errors.New(tr("input.empty.short"))
+    }
+
+    portStr := ""
+    if strings.Contains(host, "://") {
+        u, err := url.Parse(host)
+        if err != nil || u.Hostname() == "" {
+            return "", 0, fmt.Errorf(tr("input.invalidURL"), input)
+        }
+        host, portStr = u.Hostname(), u.Port()
+    } else {
+        if i := strings.IndexAny(host, "/?#"); i >= 0 {
+            host = host[:i]
+        }
+        // "hôte:port" ou "[IPv6]:port" ; une IPv6 nue contient plusieurs ":"
+        if strings.HasPrefix(host, "[") || strings.Count(host, ":") == 1 {
+            if h, p, err := net.SplitHostPort(host); err == nil {
+                host, portStr = h, p
+            }
+        }
+    }
+    host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+
+    port := 0
+    if portStr != "" {
+        n, err := strconv.Atoi(portStr)
+        if err != nil || n < 1 || n > 65535 {
+            return "", 0, fmt.Errorf(tr("input.invalidPort"), portStr)
+        }
+        port = n
+    }
+
+    if ip := net.ParseIP(host); ip != nil {
+        return ip.String(), port, nil
+    }
+    if !validHostname(host) {
+        return "", 0, fmt.Errorf(tr("input.invalidHost"), input)
+    }
+    return strings.ToLower(strings.TrimSuffix(host, ".")), port, nil
+}
+
+// validHostname vérifie la syntaxe d'un nom d'hôte (RFC 1123).
+func validHostname(host string) bool {
+    host = strings.TrimSuffix(host, ".")
+    if host == "" || len(host) > 253 {
+        return false
+    }
+    for _, label := range strings.Split(host, ".") {
+        if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+            return false
+        }
+        for _, c := range label {
+            if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+                return false
+            }
+        }
+    }
+    return true
+}
+
+// newTarget résout la cible normalisée via dns (nil : sans cache). Un nom
+// sans adresse IPv4 retourne une *ipv6OnlyError, plus claire que l'échec du
+// ping qui s'ensuivrait. En cas d'autre échec de résolution, l'IP reste la
+// saisie brute et l'erreur remontera lors du ping.
+func newTarget(input string, port int, dns *dnsCache) (Target, error) {
+    t := Target{Input: input, IP: input, Port: port}
+    ip, err := dns.ResolveIPv4(input)
+    var v6 *ipv6OnlyError
+    if errors.As(err, &v6) {
+        return t, err
+    }
+    if err == nil {
+        t.IP = ip
+        t.PTR = reverseLookup(ip)
+    }
+    return t, nil
+}
+
+// lookupASN complète la cible avec son ASN ; un échec laisse ASN à nil.
+func (t *Target) lookupASN(source ASNSource) error {
+    info, err := source.LookupASN(t.IP)
+    if err != nil {
+        return err
+    }
+    t.ASN = &info
+    return nil
+}
+
+// reverseLookup retourne les noms PTR de l'IP, sans le point final.
+func reverseLookup(ip string) []string {
+    names, err := net.LookupAddr(ip)
+    if err != nil {
+        return nil
+    }
+    for i, name := range names {
+        names[i] = strings.TrimSuffix(name, ".")
+    }
+    return names
+}
+
+// Label retourne l'identifiant affiché de la cible ("domaine (IP)" si différents).
+func (t Target) Label() string {
+    if t.Input == t.IP {
+        return t.IP
+    }
+    return t.Input + " (" + t.IP + ")"
+}
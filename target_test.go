@@ -0,0 +1,78 @@
+package main
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestNormalizeTarget(t *testing.T) {
+    tests := []struct {
+        input string
+        host  string
+        port  int
+        fails bool
+    }{
+        {input: "192.0.2.1", host: "192.0.2.1"},
+        {input: "  192.0.2.1\n", host: "192.0.2.1"},
+        {input: "192.0.2.1:8080", host: "192.0.2.1", port: 8080},
+        {input: "2001:DB8::1", host: "2001:db8::1"},
+        {input: "[2001:db8::1]", host: "2001:db8::1"},
+        {input: "[2001:db8::1]:443", host: "2001:db8::1", port: 443},
+        {input: "Example.COM.", host: "example.com"},
+        {input: "example.com:22", host: "example.com", port: 22},
+        {input: "example.com/path?q=1#frag", host: "example.com"},
+        {input: "https://www.example.com/index.html", host: "www.example.com"},
+        {input: "http://example.com:8080/", host: "example.com", port: 8080},
+        {input: "http://[2001:db8::1]:8443", host: "2001:db8::1", port: 8443},
+        {input: "localhost", host: "localhost"},
+        {input: "", fails: true},
+        {input: "   ", fails: true},
+        {input: "example.com:0", fails: true},
+        {input: "example.com:65536", fails: true},
+        {input: "http://example.com:99999/", fails: true},
+        {input: "http:///path", fails: true},
+        {input: "exa mple.com", fails: true},
+        {input: "-example.com", fails: true},
+        {input: "example..com", fails: true},
+        {input: "ex_ample.com", fails: true},
+        {input: "999.1.1.1.1:x", fails: true},
+    }
+    for _, tt := range tests {
+        host, port, err := normalizeTarget(tt.input)
+        if tt.fails {
+            if err == nil {
+                t.Errorf("normalizeTarget(%q) = %q, %d, want an error", tt.input, host, port)
+            }
+            continue
+        }
+        if err != nil || host != tt.host || port != tt.port {
+            t.Errorf("normalizeTarget(%q) = %q, %d, %v, want %q, %d", tt.input, host, port, err, tt.host, tt.port)
+        }
+    }
+}
+
+func TestValidHostname(t *testing.T) {
+    tests := []struct {
+        host string
+        want bool
+    }{
+        {host: "example.com", want: true},
+        {host: "example.com.", want: true},
+        {host: "a-b.c0.example", want: true},
+        {host: "xn--bcher-kva.example", want: true},
+        {host: strings.Repeat("a", 63) + ".com", want: true},
+        {host: strings.Repeat("a", 64) + ".com", want: false},
+        {host: strings.Repeat("a.", 126) + "com", want: false}, // 255 caractères
+        {host: "", want: false},
+        {host: ".", want: false},
+        {host: ".example.com", want: false},
+        {host: "example-.com", want: false},
+        {host: "exa_mple.com", want: false},
+        {host: "exämple.com", want: false},
+    }
+    for _, tt := range tests {
+        if got := validHostname(tt.host); got != tt.want {
+            t.Errorf("validHostname(%q) = %v, want %v", tt.host, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "net"
+    "strconv"
+    "time"
+)
+
+const (
+    // Port utilisé par le repli TCP quand la cible n'en précise pas
+    defaultTCPPort = 443
+    tcpDialTimeout = 3 * time.Second
+)
+
+// tcpMeasurer mesure le temps d'établissement d'une connexion TCP
+// (SYN -> SYN/ACK), proche d'un RTT réseau, pour les hôtes filtrant l'ICMP.
+type tcpMeasurer struct {
+    Port     int
+    Source   string             // adresse source ; vide : route par défaut
+    Sampling sequentialSampling // arrêt anticipé ; désactivé par défaut
+}
+
+func (m tcpMeasurer) Measure(ctx context.Context, ip string, count int) (PingStats, error) {
+    addr := net.JoinHostPort(ip, strconv.Itoa(m.Port))
+    dialer := net.Dialer{Timeout: tcpDialTimeout}
+    if m.Source != "" {
+        dialer.LocalAddr = &net.TCPAddr{IP: net.ParseIP(m.Source)}
+    }
+
+    var samples []time.Duration
+    var lastErr error
+    sent := 0
+    for ; sent < count && !m.Sampling.done(samples); sent++ {
+        start := time.Now()
+        conn, err := dialer.DialContext(ctx, "tcp", addr)
+        if ctx.Err() != nil {
+            return PingStats{}, ctx.Err()
+        }
+        if err != nil {
+            lastErr = err
+            continue
+        }
+        samples = append(samples, time.Since(start))
+        conn.Close()
+    }
+
+    if len(samples) == 0 {
+        return PingStats{}, fmt.Errorf("%s (tcp/%d): %v", tr("ping.noReply"), m.Port, lastErr)
+    }
+    return statsFromSamples(samples, sent), nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+    "encoding/json"
+    "fmt"
+    "math"
+    "os"
+    "time"
+)
+
+// TimezoneGuess est le fuseau horaire probable d'une position estimée.
+type TimezoneGuess struct {
+    Name        string // identifiant IANA
+    Offset      int    // décalage UTC en heures de l'approximation par longitude
+    Approximate bool   // déduit de la longitude (pas de polygones chargés ou hors polygones)
+}
+
+// timezonePolygon est une zone des polygones de fuseaux : anneau extérieur
+// puis trous éventuels, en [lon, lat].
+type timezonePolygon struct {
+    tzid           string
+    rings          [][][2]float64
+    minLon, maxLon float64 // boîte englobante de l'anneau extérieur
+    minLat, maxLat float64
+}
+
+// timezoneIndex contient les polygones de fuseaux chargés par --timezones.
+type timezoneIndex struct {
+    polygons []timezonePolygon
+}
+
+// loadTimezones lit une FeatureCollection GeoJSON de fuseaux horaires
+// (format de timezone-boundary-builder : propriété tzid, géométries Polygon
+// ou MultiPolygon).
+func loadTimezones(path string) (*timezoneIndex, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    var doc struct {
+        Features []struct {
+            Properties struct {
+                TZID string `json:"tzid"`
+            } `json:"properties"`
+            Geometry struct {
+                Type        string          `json:"type"`
+                Coordinates json.RawMessage `json:"coordinates"`
+            } `json:"geometry"`
+        } `json:"features"`
+    }
+    if err := json.Unmarshal(data, &doc); err != nil {
+        return nil, fmt.Errorf("%s: %v", path, err)
+    }
+
+    idx := &timezoneIndex{}
+    for _, f := range doc.Features {
+        var polygons [][][][2]float64
+        switch f.Geometry.Type {
+        case "Polygon":
+            var rings [][][2]float64
+            if err := json.Unmarshal(f.Geometry.Coordinates, &rings); err != nil {
+                return nil, fmt.Errorf("%s: %s: %v", path, f.Properties.TZID, err)
+            }
+            polygons = append(polygons, rings)
+        case "MultiPolygon":
+            if err := json.Unmarshal(f.Geometry.Coordinates, &polygons); err != nil {
+                return nil, fmt.Errorf("%s: %s: %v", path, f.Properties.TZID, err)
+            }
+        default:
+            continue
+        }
+        for _, rings := range polygons {
+            if f.Properties.TZID == "" || len(rings) == 0 {
+                continue
+            }
+            idx.polygons = append(idx.polygons, newTimezonePolygon(f.Properties.TZID, rings))
+        }
+    }
+    if len(idx.polygons) == 0 {
+        return nil, fmt.Errorf("%s: no timezone polygon", path)
+    }
+    return idx, nil
+}
+
+func newTimezonePolygon(tzid string, rings [][][2]float64) timezonePolygon {
+    p := timezonePolygon{tzid: tzid, rings: rings,
+        minLon: math.Inf(1), maxLon: math.Inf(-1), minLat: math.Inf(1), maxLat: math.Inf(-1)}
+    for _, pt := range rings[0] {
+        p.minLon, p.maxLon = math.Min(p.minLon, pt[0]), math.Max(p.maxLon, pt[0])
+        p.minLat, p.maxLat = math.Min(p.minLat, pt[1]), math.Max(p.maxLat, pt[1])
+    }
+    return p
+}
+
+// contains teste l'appartenance au polygone (lancer de rayon) : dans
+// l'anneau extérieur et hors des trous.
+func (p timezonePolygon) contains(loc Location) bool {
+    if loc.Lon < p.minLon || loc.Lon > p.maxLon || loc.Lat < p.minLat || loc.Lat > p.maxLat {
+        return false
+    }
+    if !ringContains(p.rings[0], loc) {
+        return false
+    }
+    for _, hole := range p.rings[1:] {
+        if ringContains(hole, loc) {
+            return false
+        }
+    }
+    return true
+}
+
+func ringContains(ring [][2]float64, loc Location) bool {
+    inside := false
+    for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+        a, b := ring[i], ring[j]
+        if (a[1] > loc.Lat) != (b[1] > loc.Lat) &&
+            loc.Lon < (b[0]-a[0])*(loc.Lat-a[1])/(b[1]-a[1])+a[0] {
+            inside = !inside
+        }
+    }
+    return inside
+}
+
+// guessTimezone retourne le fuseau de loc : celui du polygone qui la
+// contient si idx est chargé, sinon le fuseau Etc/GMT déduit de la
+// longitude (15° par heure), qui ignore frontières et heure d'été.
+func guessTimezone(loc Location, idx *timezoneIndex) TimezoneGuess {
+    offset := int(math.Round(normalizeLon(loc.Lon) / 15))
+    if idx != nil {
+        for _, p := range idx.polygons {
+            if p.contains(loc) {
+                return TimezoneGuess{Name: p.tzid, Offset: offset}
+            }
+        }
+    }
+
+    // Les zones Etc/GMT inversent le signe : Etc/GMT-1 est UTC+1
+    name := "Etc/GMT"
+    if offset > 0 {
+        name = fmt.Sprintf("Etc/GMT-%d", offset)
+    } else if offset < 0 {
+        name = fmt.Sprintf("Etc/GMT+%d", -offset)
+    }
+    return TimezoneGuess{Name: name, Offset: offset, Approximate: true}
+}
+
+// LocalTime retourne l'heure actuelle dans le fuseau. Sans base de fuseaux
+// sur le système, l'approximation retombe sur un décalage fixe et un
+// fuseau IANA retourne false.
+func (g TimezoneGuess) LocalTime(now time.Time) (time.Time, bool) {
+    if zone, err := time.LoadLocation(g.Name); err == nil {
+        return now.In(zone), true
+    }
+    if g.Approximate {
+        return now.In(time.FixedZone(g.Name, g.Offset*3600)), true
+    }
+    return time.Time{}, false
+}
+
+// displayTimezone affiche le fuseau probable de loc et l'heure locale.
+func displayTimezone(loc Location, idx *timezoneIndex) {
+    guess := guessTimezone(loc, idx)
+    name := guess.Name
+    if guess.Approximate {
+        name = fmt.Sprintf(tr("tz.approximate"), guess.Offset, guess.Name)
+    }
+    if local, ok := guess.LocalTime(time.Now()); ok {
+        fmt.Printf(tr("tz.line"), name, local.Format("15:04 Mon"))
+    } else {
+        fmt.Printf(tr("tz.lineNoTime"), name)
+    }
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log/slog"
+    "net"
+    "os"
+    "time"
+
+    "golang.org/x/net/icmp"
+    "golang.org/x/net/ipv4"
+)
+
+const (
+    // Nombre maximal de sauts sondés par traceroute
+    tracerouteMaxHops = 16
+    // Attente maximale de la réponse d'un saut
+    tracerouteHopTimeout = time.Second
+    // Serveurs affinés par traceroute : les trois de la trilatération
+    tracerouteServers = 3
+)
+
+// Hop est un saut d'un traceroute. IP est vide si le saut n'a pas répondu.
+type Hop struct {
+    TTL int           `json:"ttl"`
+    IP  string        `json:"ip,omitempty"`
+    RTT time.Duration `json:"rtt_ns,omitempty"`
+}
+
+// traceroute sonde le chemin vers ip (IPv4) par des échos ICMP de TTL
+// croissant, jusqu'à la destination ou maxHops. Nécessite une socket ICMP
+// brute (root).
+func traceroute(ctx context.Context, ip string, maxHops int) ([]Hop, error) {
+    dst := net.ParseIP(ip).To4()
+    if dst == nil {
+        return nil, fmt.Errorf("traceroute: %s is not an IPv4 address", ip)
+    }
+
+    conn, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+    if err != nil {
+        return nil, err
+    }
+    defer conn.Close()
+
+    // Fermer la socket débloque la lecture en cours à l'annulation
+    done := make(chan struct{})
+    defer close(done)
+    go func() {
+        select {
+        case <-ctx.Done():
+            conn.Close()
+        case <-done:
+        }
+    }()
+
+    id := os.Getpid() & 0xffff
+    var hops []Hop
+    for ttl := 1; ttl <= maxHops; ttl++ {
+        if ctx.Err() != nil {
+            return hops, ctx.Err()
+        }
+        hop, reached, err := probeHop(conn, dst, id, ttl)
+        if ctx.Err() != nil {
+            return hops, ctx.Err()
+        }
+        if err != nil {
+            return hops, err
+        }
+        hops = append(hops, hop)
+        if reached {
+            break
+        }
+    }
+    return hops, nil
+}
+
+// probeHop envoie un écho de TTL ttl et attend la réponse du saut
+// correspondant (temps dépassé) ou de la destination (réponse d'écho).
+func probeHop(conn *icmp.PacketConn, dst net.IP, id, ttl int) (Hop, bool, error) {
+    hop := Hop{TTL: ttl}
+    if err := conn.IPv4PacketConn().SetTTL(ttl); err != nil {
+        return hop, false, err
+    }
+
+    msg := icmp.Message{
+        Type: ipv4.ICMPTypeEcho,
+        Body: &icmp.Echo{ID: id, Seq: ttl, Data: []byte("triangula")},
+    }
+    packet, err := msg.Marshal(nil)
+    if err != nil {
+        return hop, false, err
+    }
+
+    start := time.Now()
+    if _, err := conn.WriteTo(packet, &net.IPAddr{IP: dst}); err != nil {
+        return hop, false, err
+    }
+
+    if err := conn.SetReadDeadline(start.Add(tracerouteHopTimeout)); err != nil {
+        return hop, false, err
+    }
+    buf := make([]byte, 1500)
+    for {
+        n, peer, err := conn.ReadFrom(buf)
+        if err != nil {
+            var netErr net.Error
+            if errors.As(err, &netErr) && netErr.Timeout() {
+                return hop, false, nil // saut muet
+            }
+            return hop, false, err
+        }
+        reply, err := icmp.ParseMessage(1, buf[:n])
+        if err != nil {
+            continue
+        }
+
+        switch reply.Type {
+        case ipv4.ICMPTypeTimeExceeded:
+            body, ok := reply.Body.(*icmp.TimeExceeded)
+            if !ok || !matchesProbe(body.Data, id, ttl) {
+                continue
+            }
+            hop.IP, hop.RTT = peer.String(), time.Since(start)
+            return hop, false, nil
+        case ipv4.ICMPTypeEchoReply:
+            echo, ok := reply.Body.(*icmp.Echo)
+            if !ok || echo.ID != id || echo.Seq != ttl {
+                continue
+            }
+            hop.IP, hop.RTT = peer.String(), time.Since(start)
+            return hop, true, nil
+        }
+    }
+}
+
+// matchesProbe vérifie que le datagramme cité dans un « temps dépassé »
+// (en-tête IP + 8 octets ICMP) est bien notre écho.
+func matchesProbe(quoted []byte, id, seq int) bool {
+    if len(quoted) < ipv4.HeaderLen {
+        return false
+    }
+    headerLen := int(quoted[0]&0x0f) * 4
+    if len(quoted) < headerLen+8 {
+        return false
+    }
+    echo := quoted[headerLen:]
+    return int(echo[4])<<8|int(echo[5]) == id && int(echo[6])<<8|int(echo[7]) == seq
+}
+
+// lastCommonHop retourne le dernier saut commun aux deux chemins avant leur
+// divergence (même adresse au même TTL). false si les chemins divergent
+// dès le premier saut.
+func lastCommonHop(a, b []Hop) (Hop, bool) {
+    var common Hop
+    found := false
+    for i := 0; i < len(a) && i < len(b); i++ {
+        if a[i].IP == "" || a[i].IP != b[i].IP {
+            break
+        }
+        common, found = a[i], true
+    }
+    return common, found
+}
+
+// TracerouteRefinement décrit l'affinage d'un résultat par traceroute.
+type TracerouteRefinement struct {
+    Server      string        `json:"server"`
+    CommonHop   string        `json:"common_hop"`
+    HopRTT      time.Duration `json:"hop_rtt_ns"`
+    OldDistance float64       `json:"old_distance_km"`
+    NewDistance float64       `json:"new_distance_km"`
+    // Sauts entre le point de divergence et la cible puis le serveur ; leur
+    // délai (--hop-delay) est retiré du RTT avant conversion
+    Hops int `json:"hops"`
+}
+
+// hopsAfter compte les sauts d'un chemin au-delà du saut de TTL ttl.
+func hopsAfter(hops []Hop, ttl int) int {
+    if n := len(hops) - ttl; n > 0 {
+        return n
+    }
+    return 0
+}
+
+// withoutHopDelay retire du RTT le délai de traitement estimé de hops sauts
+// (Options.HopDelay), sans descendre sous zéro.
+func (o Options) withoutHopDelay(rtt time.Duration, hops int) time.Duration {
+    rtt -= time.Duration(hops) * o.HopDelay
+    if rtt < 0 {
+        return 0
+    }
+    return rtt
+}
+
+// refineWithTraceroute trace la cible et les meilleurs serveurs et, quand un
+// chemin partage un tronçon avec celui de la cible, remplace la distance du
+// serveur par modèle(RTT cible + RTT serveur - 2 x RTT du dernier saut
+// commun) : la route cible-serveur passe par ce point de divergence, ce qui
+// est plus réaliste que le simple écart des RTT. Avec opts.HopDelay, le
+// délai de traitement des sauts de cette route est retiré en plus.
+func refineWithTraceroute(ctx context.Context, target Target, results []Result, opts Options) []TracerouteRefinement {
+    targetHops, err := traceroute(ctx, target.IP, tracerouteMaxHops)
+    if err != nil && len(targetHops) == 0 {
+        slog.Warn("traceroute to target failed", "ip", target.IP, "error", err)
+        return nil
+    }
+
+    var refinements []TracerouteRefinement
+    for i := 0; i < tracerouteServers && i < len(results); i++ {
+        r := &results[i]
+        serverHops, err := traceroute(ctx, r.Server.IP, tracerouteMaxHops)
+        if ctx.Err() != nil {
+            slog.Warn("traceroute refinement interrupted", "refined", len(refinements))
+            break
+        }
+        if err != nil && len(serverHops) == 0 {
+            slog.Warn("traceroute to server failed", "server", r.Server.Name, "ip", r.Server.IP, "error", err)
+            continue
+        }
+        hop, ok := lastCommonHop(targetHops, serverHops)
+        if !ok || hop.RTT >= target.RTT || hop.RTT >= r.Server.AvgRTT {
+            slog.Debug("no usable common hop", "server", r.Server.Name)
+            continue
+        }
+
+        hops := hopsAfter(targetHops, hop.TTL) + hopsAfter(serverHops, hop.TTL)
+        rtt := opts.withoutHopDelay(target.RTT+r.Server.AvgRTT-2*hop.RTT, hops)
+        refined := opts.Model.Distance(rtt, opts.baselineFor(r.Server)+opts.Baseline)
+        refinements = append(refinements, TracerouteRefinement{
+            Server:      r.Server.Name,
+            CommonHop:   hop.IP,
+            HopRTT:      hop.RTT,
+            OldDistance: r.Distance,
+            NewDistance: refined,
+            Hops:        hops,
+        })
+        r.Distance = refined
+    }
+    return refinements
+}
@@ -0,0 +1,68 @@
+package main
+
+import "testing"
+
+// quotedEcho construit un datagramme cité : en-tête IPv4 de headerLen octets
+// suivi de l'en-tête d'un écho ICMP d'identifiant id et de séquence seq.
+func quotedEcho(headerLen, id, seq int) []byte {
+    b := make([]byte, headerLen+8)
+    b[0] = 0x40 | byte(headerLen/4)
+    echo := b[headerLen:]
+    echo[0] = 8 // echo request
+    echo[4], echo[5] = byte(id>>8), byte(id)
+    echo[6], echo[7] = byte(seq>>8), byte(seq)
+    return b
+}
+
+func TestMatchesProbe(t *testing.T) {
+    tests := []struct {
+        name   string
+        quoted []byte
+        want   bool
+    }{
+        {name: "notre écho", quoted: quotedEcho(20, 0x1234, 7), want: true},
+        {name: "options IP", quoted: quotedEcho(24, 0x1234, 7), want: true},
+        {name: "autre identifiant", quoted: quotedEcho(20, 0x1235, 7)},
+        {name: "autre séquence", quoted: quotedEcho(20, 0x1234, 8)},
+        {name: "vide"},
+        {name: "en-tête IP tronqué", quoted: quotedEcho(20, 0x1234, 7)[:19]},
+        {name: "écho tronqué", quoted: quotedEcho(20, 0x1234, 7)[:27]},
+        // L'en-tête annonce des options absentes du datagramme
+        {name: "longueur d'en-tête hors limites", quoted: append([]byte{0x4f}, quotedEcho(20, 0x1234, 7)[1:]...)},
+    }
+    for _, tt := range tests {
+        if got := matchesProbe(tt.quoted, 0x1234, 7); got != tt.want {
+            t.Errorf("%s: matchesProbe = %v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
+
+func TestLastCommonHop(t *testing.T) {
+    path := func(ips ...string) []Hop {
+        hops := make([]Hop, len(ips))
+        for i, ip := range ips {
+            hops[i] = Hop{TTL: i + 1, IP: ip}
+        }
+        return hops
+    }
+    tests := []struct {
+        name string
+        a, b []Hop
+        want int // TTL du saut commun, 0 si aucun
+    }{
+        {name: "divergence immédiate", a: path("10.0.0.1", "a"), b: path("10.0.0.2", "b")},
+        {name: "chemins vides"},
+        {name: "préfixe commun", a: path("10.0.0.1", "10.0.1.1", "a"), b: path("10.0.0.1", "10.0.1.1", "b"), want: 2},
+        {name: "chemin plus court", a: path("10.0.0.1", "10.0.1.1"), b: path("10.0.0.1", "10.0.1.1", "b"), want: 2},
+        // Un saut muet interrompt le préfixe, même si la suite coïncide
+        {name: "saut muet", a: path("10.0.0.1", "", "10.0.2.1"), b: path("10.0.0.1", "", "10.0.2.1"), want: 1},
+        {name: "premier saut muet", a: path("", "10.0.1.1"), b: path("", "10.0.1.1")},
+        {name: "reconvergence ignorée", a: path("10.0.0.1", "x", "10.0.2.1"), b: path("10.0.0.1", "y", "10.0.2.1"), want: 1},
+    }
+    for _, tt := range tests {
+        got, ok := lastCommonHop(tt.a, tt.b)
+        if ok != (tt.want > 0) || ok && got.TTL != tt.want {
+            t.Errorf("%s: lastCommonHop = %v, %v, want TTL %d", tt.name, got, ok, tt.want)
+        }
+    }
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "strings"
+
+    "triangula/geo"
+)
+
+// Dimensions de la visualisation du triangle : sommet en colonne
+// triangleApex, triangleHeight lignes jusqu'à la base
+const (
+    triangleWidth  = 80
+    triangleApex   = 40
+    triangleHeight = 7
+)
+
+// asciiCanvas est une grille de caractères de largeur fixe.
+type asciiCanvas [][]rune
+
+func newASCIICanvas(rows, width int) asciiCanvas {
+    c := make(asciiCanvas, rows)
+    for i := range c {
+        c[i] = []rune(strings.Repeat(" ", width))
+    }
+    return c
+}
+
+// put écrit s à partir de la colonne col, tronqué aux bords.
+func (c asciiCanvas) put(row, col int, s string) {
+    for i, r := range []rune(s) {
+        if x := col + i; x >= 0 && x < len(c[row]) {
+            c[row][x] = r
+        }
+    }
+}
+
+// putRight écrit s pour qu'il se termine juste avant la colonne end.
+func (c asciiCanvas) putRight(row, end int, s string) {
+    c.put(row, end-len([]rune(s)), s)
+}
+
+// putCentered écrit s centré sur la colonne col.
+func (c asciiCanvas) putCentered(row, col int, s string) {
+    c.put(row, col-len([]rune(s))/2, s)
+}
+
+func (c asciiCanvas) write(w io.Writer) {
+    for _, line := range c {
+        fmt.Fprintln(w, strings.TrimRight(string(line), " "))
+    }
+}
+
+// displayTriangle dessine les trois serveurs de la méthode 1 autour de la
+// position trilatérée pos. Chaque sommet porte son serveur, la distance
+// estimée depuis le RTT et la distance réelle à pos ; chaque côté, la
+// distance géographique entre les deux serveurs qu'il relie, lue dans
+// distances (anchors y sont les indices 0 à 2).
+func displayTriangle(w io.Writer, anchors [3]Result, distances *DistanceMatrix, pos Location) {
+    vertex := func(r Result) string {
+        return fmt.Sprintf(tr("tri.vertex"), formatDistance(r.Distance),
+            formatDistance(geo.Distance(r.Server.Lat, r.Server.Lon, pos.Lat, pos.Lon)))
+    }
+    edge := func(i, j int) string {
+        return formatDistance(distances.Get(i, j))
+    }
+    s1, s2, s3 := anchors[0], anchors[1], anchors[2]
+
+    // Lignes 0-1 : sommet 1, lignes 2 à 2+triangleHeight-1 : côtés,
+    // puis sommets 2 et 3 et le côté de base
+    top := 2
+    base := top + triangleHeight - 1
+    c := newASCIICanvas(base+4, triangleWidth)
+    c.putCentered(0, triangleApex, s1.Server.Name)
+    c.putCentered(1, triangleApex, vertex(s1))
+    for i := 0; i < triangleHeight; i++ {
+        left, right := triangleApex-1-i, triangleApex+i
+        c.put(top+i, left, "/")
+        c.put(top+i, right, `\`)
+        if i == triangleHeight-1 {
+            c.put(top+i, left+1, strings.Repeat("_", right-left-1))
+        }
+    }
+    middle := top + triangleHeight/2
+    c.putRight(middle, triangleApex-triangleHeight/2-2, edge(0, 1))
+    c.put(middle, triangleApex+triangleHeight/2+2, edge(0, 2))
+    c.putCentered(middle+1, triangleApex, "[*]")
+    c.putCentered(middle+2, triangleApex, tr("tri.target"))
+
+    c.putRight(base+1, triangleApex-triangleHeight, s2.Server.Name)
+    c.putRight(base+2, triangleApex-triangleHeight, vertex(s2))
+    c.put(base+1, triangleApex+triangleHeight, s3.Server.Name)
+    c.put(base+2, triangleApex+triangleHeight, vertex(s3))
+    c.putCentered(base+3, triangleApex, edge(1, 2))
+    fmt.Fprintln(w)
+    c.write(w)
+}
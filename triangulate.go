@@ -0,0 +1,482 @@
+package main
+
+import (
+    "errors"
+    "fmt"
+    "math"
+    "sort"
+    "strings"
+    "time"
+
+    "triangula/geo"
+)
+
+// Nombre de serveurs utilisés par la multilatération pondérée
+const multilatServers = 10
+
+// Pondération des résidus des moindres carrés. inverse-variance et
+// colocation se combinent (--weighting inverse-variance,colocation).
+const (
+    weightingEqual           = "equal"
+    weightingInverseVariance = "inverse-variance"
+    weightingColocation      = "colocation"
+)
+
+// validWeighting vérifie une pondération : equal seule, ou une liste
+// d'inverse-variance et colocation séparées par des virgules.
+func validWeighting(weighting string) bool {
+    if weighting == weightingEqual {
+        return true
+    }
+    for _, mode := range strings.Split(weighting, ",") {
+        if mode != weightingInverseVariance && mode != weightingColocation {
+            return false
+        }
+    }
+    return true
+}
+
+// hasWeighting indique si la pondération inclut mode.
+func hasWeighting(weighting, mode string) bool {
+    for _, m := range strings.Split(weighting, ",") {
+        if m == mode {
+            return true
+        }
+    }
+    return false
+}
+
+// colocationCounts compte les serveurs de la base à chaque emplacement.
+func colocationCounts(servers []Server) map[Location]int {
+    counts := make(map[Location]int)
+    for _, s := range servers {
+        counts[serverLocation(s)]++
+    }
+    return counts
+}
+
+// Étendue géographique minimale des serveurs ayant répondu : en deçà (par
+// exemple uniquement des serveurs parisiens), la géométrie ne contraint pas
+// la position et toute estimation serait trompeuse
+const minGeometricSpreadKm = 200.0
+
+// Nombre minimal par défaut d'emplacements distincts (--min-locations) :
+// trois serveurs de Francfort ne forment pas un triangle
+const defaultMinLocations = 3
+
+var (
+    errNoResponse       = errors.New("no server responded")
+    errNotEnoughServers = errors.New("fewer than 3 servers responded")
+    errLowDiversity     = errors.New("insufficient geometric diversity")
+    errFewLocations     = errors.New("too few distinct server locations responded")
+)
+
+// Écart entre trilatération et multilatération au-delà duquel les mesures
+// sont jugées incohérentes et le résultat peu fiable
+const maxMethodDivergenceKm = 1000.0
+
+// Distances cible-serveur ajustées par la méthode 3
+const (
+    geometryDelta  = "delta"  // borne inférieure modèle(|RTT serveur - RTT cible|), biaisée vers le bas
+    geometryBounds = "bounds" // anneau [borne inférieure, borne supérieure]
+)
+
+// Classement des serveurs
+const (
+    rankDelta       = "delta"        // écart de RTT avec la cible
+    rankDeltaJitter = "delta+jitter" // écart de RTT pénalisé par la gigue du serveur
+)
+
+// rankScore retourne la clé de classement du résultat : plus elle est basse,
+// plus le serveur est considéré proche et fiable.
+func (r Result) rankScore(rank string) time.Duration {
+    if rank == rankDeltaJitter {
+        return r.Delta + r.Server.Jitter
+    }
+    return r.Delta
+}
+
+// sortResults trie les résultats selon rank. Les égalités (serveurs
+// colocalisés notamment) sont départagées par nom puis par IP, pour que les
+// ancres de la trilatération ne changent pas d'une exécution à l'autre.
+func sortResults(results []Result, rank string) {
+    sort.SliceStable(results, func(i, j int) bool {
+        a, b := results[i], results[j]
+        if sa, sb := a.rankScore(rank), b.rankScore(rank); sa != sb {
+            return sa < sb
+        }
+        if a.Server.Name != b.Server.Name {
+            return a.Server.Name < b.Server.Name
+        }
+        return a.Server.IP < b.Server.IP
+    })
+}
+
+// Gigue plancher pour la pondération : évite qu'un serveur à gigue quasi
+// nulle écrase tous les autres
+const minWeightingJitter = 100 * time.Microsecond
+
+// Estimates regroupe les positions calculées par chaque méthode.
+type Estimates struct {
+    Trilateration   Location `json:"trilateration"`
+    Multilateration Location `json:"multilateration"`
+    MultilatServers int      `json:"multilateration_servers"`
+    LeastSquares    Location `json:"least_squares"`
+    // Intersection des cercles sur le plan tangent (méthode 4) ; valable
+    // seulement si CirclesIntersect
+    CircleIntersection Location `json:"circle_intersection"`
+    CirclesIntersect   bool     `json:"circles_intersect"`
+    CircleLocations    int      `json:"circle_locations"`
+    // Intersection des boîtes des serveurs (méthode 5), grossière ; mise en
+    // avant (Fallback) quand les cercles ne se coupent pas et que les
+    // moindres carrés sont mal conditionnés
+    BoundingBox BoundingBox `json:"bounding_box"`
+    Fallback    bool        `json:"fallback"`
+    // Nombre de coordonnées distinctes parmi les serveurs ayant répondu
+    DistinctLocations int `json:"distinct_locations"`
+    Cities            int `json:"cities,omitempty"` // villes résolues avec --collapse=city
+    // Poids robustes finaux des serveurs de la méthode 3 (mêmes serveurs et
+    // même ordre que la multilatération) ; vide si le solveur n'en produit pas
+    SolverWeights []float64 `json:"solver_weights,omitempty"`
+
+    // Plus petit résidu RMS (km) atteignable en une position quelconque ;
+    // au-delà du rayon de confiance, la cible est probablement derrière un
+    // VPN ou un proxy (profil de latence incompatible avec un point unique)
+    ResidualFloorKm float64 `json:"residual_floor_km"`
+    PossibleProxy   bool    `json:"possible_proxy"`
+
+    // Distance (km) entre les positions des méthodes 1 et 2 ; Divergent
+    // au-delà de maxMethodDivergenceKm
+    DivergenceKm float64 `json:"divergence_km"`
+    Divergent    bool    `json:"divergent"`
+
+    // Remesure du serveur le plus proche de l'estimation (--verify) ; nil
+    // sans vérification ou si elle a échoué
+    Verification *Verification `json:"verification,omitempty"`
+    // Ellipse d'incertitude des recalculs Monte Carlo (--monte-carlo) ; nil
+    // sans recalculs ou s'ils ont tous échoué
+    Uncertainty *Ellipse `json:"uncertainty,omitempty"`
+    // Positions de la cible selon les sources de --compare et --geoip
+    Comparisons []GeoComparison `json:"comparisons,omitempty"`
+    // Résolution restreinte au continent le plus probable (--continent-solve) ;
+    // nil sans continent désigné ou avec trop peu de serveurs
+    Continent *ContinentEstimate `json:"continent,omitempty"`
+
+    // Résultats dont sont issues les méthodes, dans l'ordre du classement :
+    // les résultats eux-mêmes, ou un par emplacement avec --dedupe-locations
+    Solved []Result `json:"-"`
+    // Distances entre les serveurs de Solved, partagées par les affichages
+    Distances *DistanceMatrix `json:"-"`
+}
+
+// Locations retourne les positions dans l'ordre d'affichage des méthodes.
+func (e Estimates) Locations() []Location {
+    return []Location{e.Trilateration, e.Multilateration, e.LeastSquares}
+}
+
+// geometricSpread retourne la plus grande distance (km) entre deux serveurs.
+func geometricSpread(results []Result) float64 {
+    return newDistanceMatrix(resultServers(results)).Max()
+}
+
+// distinctLocations compte les coordonnées distinctes des serveurs.
+func distinctLocations(results []Result) int {
+    seen := make(map[Location]bool)
+    for _, r := range results {
+        seen[serverLocation(r.Server)] = true
+    }
+    return len(seen)
+}
+
+// estimatePositions applique les méthodes de triangulation aux résultats
+// triés par delta. errNotEnoughServers si moins de 3 serveurs ont répondu,
+// errFewLocations s'ils occupent moins de opts.MinLocations emplacements
+// distincts, errLowDiversity s'ils sont trop regroupés géographiquement.
+func estimatePositions(results []Result, opts Options) (Estimates, error) {
+    opts = opts.withDefaults()
+
+    if len(results) < 3 {
+        return Estimates{}, errNotEnoughServers
+    }
+    locations := distinctLocations(results)
+    if locations < opts.MinLocations {
+        return Estimates{}, errFewLocations
+    }
+    distances := newDistanceMatrix(resultServers(results))
+    if distances.Max() < minGeometricSpreadKm {
+        return Estimates{}, errLowDiversity
+    }
+    coherence := assessCoherence(results)
+    if opts.DedupeLocations {
+        results = dedupeLocations(results, opts.Rank)
+        if len(results) < 3 {
+            return Estimates{}, errLowDiversity
+        }
+        distances = newDistanceMatrix(resultServers(results))
+    }
+    var cities int
+    if opts.Collapse == collapseCity {
+        results = collapseCities(results, opts.Rank)
+        if len(results) < 3 {
+            return Estimates{}, errLowDiversity
+        }
+        distances = newDistanceMatrix(resultServers(results))
+        cities = len(results)
+    }
+
+    // Méthode 1 : Trilatération simple (3 meilleurs serveurs)
+    r1, r2, r3 := results[0], results[1], results[2]
+    trilat := geo.Trilaterate(serverLocation(r1.Server), serverLocation(r2.Server), serverLocation(r3.Server),
+        r1.Distance, r2.Distance, r3.Distance)
+
+    // Méthode 2 : Multilatération (10 meilleurs serveurs)
+    numServers := multilatServers
+    if len(results) < numServers {
+        numServers = len(results)
+    }
+
+    // Méthode 3 : Moindres carrés sur les mêmes serveurs, amorcés par le
+    // barycentre pondéré de la méthode 2
+    multilat := multilateralTriangulation(results, numServers)
+    points := anchors(results[:numServers], opts)
+    var leastSquares Location
+    var weights []float64
+    if ws, ok := opts.Solver.(geo.WeightedSolver); ok {
+        leastSquares, weights = ws.SolveWeighted(points, multilat)
+    } else {
+        leastSquares = opts.Solver.Solve(points, multilat)
+    }
+    _, floor := geo.ResidualFloor(opts.Solver, points, leastSquares, multilat, trilat)
+    divergence := geo.Distance(trilat.Lat, trilat.Lon, multilat.Lat, multilat.Lon)
+
+    // Méthode 4 : Intersection des cercles sur le plan tangent, pour
+    // comparaison avec la résolution sur la sphère
+    circles, intersect := circleIntersect(results)
+
+    // Méthode 5 : Intersection des boîtes, dernier recours quand aucune
+    // autre méthode n'est exploitable
+    box := boundingBoxIntersection(results[:numServers])
+    illConditioned := math.IsNaN(leastSquares.Lat) || math.IsNaN(leastSquares.Lon) || floor > coherence.Precision
+
+    return Estimates{
+        Trilateration:   trilat,
+        Multilateration: multilat,
+        MultilatServers: numServers,
+        LeastSquares:    leastSquares,
+        SolverWeights:   weights,
+
+        CircleIntersection: circles,
+        CirclesIntersect:   intersect,
+        CircleLocations:    min(circleServers, distinctLocations(results)),
+        BoundingBox:        box,
+        Fallback:           !intersect && illConditioned,
+
+        DistinctLocations: locations,
+
+        ResidualFloorKm: floor,
+        PossibleProxy:   floor > coherence.Precision,
+        DivergenceKm:    divergence,
+        Divergent:       divergence > maxMethodDivergenceKm,
+        Solved:          results,
+        Distances:       distances,
+        Cities:          cities,
+    }, nil
+}
+
+// dedupeLocations fusionne les serveurs aux coordonnées identiques en un
+// serveur virtuel, celui de plus petit RTT du groupe, pour que chaque
+// emplacement ne compte qu'une fois dans la résolution. Le nom indique le
+// nombre de serveurs fusionnés ; le résultat est retrié selon rank.
+func dedupeLocations(results []Result, rank string) []Result {
+    type group struct {
+        best  Result
+        count int
+    }
+    var order []Location
+    groups := make(map[Location]*group)
+    for _, r := range results {
+        loc := serverLocation(r.Server)
+        g, ok := groups[loc]
+        if !ok {
+            groups[loc] = &group{best: r, count: 1}
+            order = append(order, loc)
+            continue
+        }
+        g.count++
+        if r.Server.AvgRTT < g.best.Server.AvgRTT {
+            g.best = r
+        }
+    }
+
+    deduped := make([]Result, 0, len(order))
+    for _, loc := range order {
+        g := groups[loc]
+        if g.count > 1 {
+            g.best.Server.Name = fmt.Sprintf("%s (+%d)", g.best.Server.Name, g.count-1)
+        }
+        deduped = append(deduped, g.best)
+    }
+    sortResults(deduped, rank)
+    return deduped
+}
+
+// Modes de fusion des serveurs avant la triangulation (--collapse)
+const (
+    collapseNone = "none"
+    collapseCity = "city"
+)
+
+// collapseCities fusionne les serveurs d'une même ville (pays et nom de
+// ville, sans tenir compte de la casse ; l'emplacement exact pour un
+// serveur sans ville) en un serveur virtuel placé au barycentre du groupe,
+// avec le résultat du serveur de plus petit RTT : le chemin le plus rapide
+// représente le mieux le délai de propagation vers la ville, et chaque ville
+// ne compte qu'une fois quel que soit son nombre de fournisseurs. Le nom
+// indique le nombre de serveurs fusionnés ; le résultat est retrié selon
+// rank.
+func collapseCities(results []Result, rank string) []Result {
+    type group struct {
+        best           Result
+        count          int
+        sumLat, sumLon float64
+    }
+    var order []string
+    groups := make(map[string]*group)
+    for _, r := range results {
+        key := strings.ToLower(strings.TrimSpace(r.Server.Country) + "|" + strings.TrimSpace(r.Server.City))
+        if strings.TrimSpace(r.Server.City) == "" {
+            key = fmt.Sprintf("%.4f,%.4f", r.Server.Lat, r.Server.Lon)
+        }
+        g, ok := groups[key]
+        if !ok {
+            g = &group{best: r}
+            groups[key] = g
+            order = append(order, key)
+        } else if r.Server.AvgRTT < g.best.Server.AvgRTT {
+            g.best = r
+        }
+        g.count++
+        g.sumLat += r.Server.Lat
+        g.sumLon += r.Server.Lon
+    }
+
+    collapsed := make([]Result, 0, len(order))
+    for _, key := range order {
+        g := groups[key]
+        if g.count > 1 {
+            s := &g.best.Server
+            s.Name = fmt.Sprintf("%s (%d)", s.City, g.count)
+            s.Lat, s.Lon = g.sumLat/float64(g.count), g.sumLon/float64(g.count)
+            s.Provider = ""
+        }
+        collapsed = append(collapsed, g.best)
+    }
+    sortResults(collapsed, rank)
+    return collapsed
+}
+
+// anchors convertit les résultats en points de référence pour le solveur.
+// En inverse-variance, chaque résidu est pondéré par 1/gigue² ; sans gigue
+// connue (session ancienne), ce facteur reste uniforme. En colocation, il
+// est divisé par le nombre de serveurs de la base au même emplacement (sauf
+// avec DedupeLocations, où chaque emplacement ne compte déjà qu'une fois).
+// Les facteurs se multiplient, puis les poids sont normalisés pour que leur
+// moyenne vaille 1.
+func anchors(results []Result, opts Options) []geo.Anchor {
+    anchors := make([]geo.Anchor, len(results))
+    for i, r := range results {
+        anchors[i] = geo.Anchor{Location: serverLocation(r.Server), Distance: r.Distance, Weight: 1}
+        if opts.Geometry == geometryBounds {
+            anchors[i].MinDistance, anchors[i].MaxDistance = r.Distance, r.MaxDistance
+        }
+    }
+
+    weighted := false
+    if hasWeighting(opts.Weighting, weightingInverseVariance) && jittersKnown(results) {
+        for i, r := range results {
+            jitter := r.Server.Jitter
+            if jitter < minWeightingJitter {
+                jitter = minWeightingJitter
+            }
+            anchors[i].Weight /= jitter.Seconds() * jitter.Seconds()
+        }
+        weighted = true
+    }
+    if hasWeighting(opts.Weighting, weightingColocation) && !opts.DedupeLocations && opts.Collapse != collapseCity &&
+        opts.Colocation != nil {
+        for i, r := range results {
+            if n := opts.Colocation[serverLocation(r.Server)]; n > 1 {
+                anchors[i].Weight /= float64(n)
+            }
+        }
+        weighted = true
+    }
+    if !weighted {
+        return anchors
+    }
+
+    var total float64
+    for _, a := range anchors {
+        total += a.Weight
+    }
+    for i := range anchors {
+        anchors[i].Weight *= float64(len(anchors)) / total
+    }
+    return anchors
+}
+
+// jittersKnown indique si la gigue de chaque serveur a été mesurée.
+func jittersKnown(results []Result) bool {
+    for _, r := range results {
+        if r.Server.Jitter <= 0 {
+            return false
+        }
+    }
+    return true
+}
+
+// Niveaux de cohérence, utilisés comme identifiants de message
+const (
+    coherenceExcellent = "coherence.excellent"
+    coherenceGood      = "coherence.good"
+    coherenceAverage   = "coherence.average"
+    coherenceWeak      = "coherence.weak"
+)
+
+// Coherence résume la qualité de la triangulation.
+type Coherence struct {
+    Level     string        // niveau (coherence*)
+    AvgDelta  time.Duration // delta moyen des 5 meilleurs serveurs
+    Precision float64       // précision estimée, en km
+}
+
+func assessCoherence(results []Result) Coherence {
+    avgDelta := time.Duration(0)
+    for i := 0; i < 5 && i < len(results); i++ {
+        avgDelta += results[i].Delta
+    }
+    avgDelta /= time.Duration(5)
+
+    coherence := coherenceExcellent
+    if avgDelta > 50*time.Millisecond {
+        coherence = coherenceGood
+    }
+    if avgDelta > 100*time.Millisecond {
+        coherence = coherenceAverage
+    }
+    if avgDelta > 200*time.Millisecond {
+        coherence = coherenceWeak
+    }
+
+    // Estimation de la précision
+    precision := 500.0 // km par défaut
+    if avgDelta < 20*time.Millisecond {
+        precision = 100.0
+    } else if avgDelta < 50*time.Millisecond {
+        precision = 200.0
+    } else if avgDelta < 100*time.Millisecond {
+        precision = 300.0
+    }
+
+    return Coherence{Level: coherence, AvgDelta: avgDelta, Precision: precision}
+}
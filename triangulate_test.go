@@ -0,0 +1,54 @@
+package main
+
+import (
+    "reflect"
+    "testing"
+    "time"
+)
+
+func TestSortResults(t *testing.T) {
+    result := func(name, ip string, delta, jitter time.Duration) Result {
+        return Result{Server: Server{Name: name, IP: ip, Jitter: jitter}, Delta: delta}
+    }
+    tests := []struct {
+        name    string
+        rank    string
+        results []Result
+        want    []string // noms puis IP, dans l'ordre attendu
+    }{
+        {name: "delta", rank: rankDelta, results: []Result{
+            result("c", "192.0.2.3", 3*time.Millisecond, 0),
+            result("a", "192.0.2.1", 1*time.Millisecond, 0),
+            result("b", "192.0.2.2", 2*time.Millisecond, 0),
+        }, want: []string{"a/192.0.2.1", "b/192.0.2.2", "c/192.0.2.3"}},
+        // Égalités de serveurs colocalisés : nom, puis IP
+        {name: "égalité par nom", rank: rankDelta, results: []Result{
+            result("Vultr", "192.0.2.9", time.Millisecond, 0),
+            result("OVH", "192.0.2.8", time.Millisecond, 0),
+            result("Cloudflare", "192.0.2.7", time.Millisecond, 0),
+        }, want: []string{"Cloudflare/192.0.2.7", "OVH/192.0.2.8", "Vultr/192.0.2.9"}},
+        {name: "égalité par IP", rank: rankDelta, results: []Result{
+            result("anycast", "192.0.2.20", time.Millisecond, 0),
+            result("anycast", "192.0.2.10", time.Millisecond, 0),
+        }, want: []string{"anycast/192.0.2.10", "anycast/192.0.2.20"}},
+        // La gigue départage avant le nom avec delta+jitter, pas avec delta
+        {name: "delta+jitter", rank: rankDeltaJitter, results: []Result{
+            result("a", "192.0.2.1", time.Millisecond, 5*time.Millisecond),
+            result("b", "192.0.2.2", 2*time.Millisecond, 0),
+        }, want: []string{"b/192.0.2.2", "a/192.0.2.1"}},
+        {name: "delta ignore la gigue", rank: rankDelta, results: []Result{
+            result("b", "192.0.2.2", time.Millisecond, 0),
+            result("a", "192.0.2.1", time.Millisecond, 5*time.Millisecond),
+        }, want: []string{"a/192.0.2.1", "b/192.0.2.2"}},
+    }
+    for _, tt := range tests {
+        sortResults(tt.results, tt.rank)
+        var got []string
+        for _, r := range tt.results {
+            got = append(got, r.Server.Name+"/"+r.Server.IP)
+        }
+        if !reflect.DeepEqual(got, tt.want) {
+            t.Errorf("%s: sortResults = %v, want %v", tt.name, got, tt.want)
+        }
+    }
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+    "math"
+    "time"
+
+    "triangula/geo"
+)
+
+const (
+    // Quantile du chi² à 2 degrés de liberté pour une ellipse à 95%
+    ellipseChi2_95 = 5.991
+    // Gigue supposée (fraction du RTT) quand elle n'a pas été mesurée,
+    // par exemple pour une session enregistrée avant sa capture
+    defaultJitterFraction = 0.05
+)
+
+// Ellipse est une ellipse d'incertitude autour d'une position estimée.
+// Les axes sont en km, l'orientation est l'azimut du demi-grand axe en
+// degrés depuis le nord (0-180, sens horaire).
+type Ellipse struct {
+    Center         Location `json:"center"`
+    SemiMajorKm    float64  `json:"semi_major_km"`
+    SemiMinorKm    float64  `json:"semi_minor_km"`
+    OrientationDeg float64  `json:"orientation_deg"`
+    Confidence     float64  `json:"confidence"`
+    Samples        int      `json:"samples"`
+}
+
+// Polygon approxime le contour de l'ellipse par points sommets (premier et
+// dernier identiques), pour le tracé en KML/GeoJSON.
+func (e Ellipse) Polygon(points int) []Location {
+    if points < 3 {
+        points = 3
+    }
+    theta := e.OrientationDeg * math.Pi / 180
+    cosLat := math.Cos(e.Center.Lat * math.Pi / 180)
+    kmPerDeg := geo.EarthRadius * math.Pi / 180
+
+    polygon := make([]Location, 0, points+1)
+    for i := 0; i <= points; i++ {
+        t := 2 * math.Pi * float64(i%points) / float64(points)
+        a, b := e.SemiMajorKm*math.Cos(t), e.SemiMinorKm*math.Sin(t)
+        // Rotation dans le plan local (x vers l'est, y vers le nord)
+        east := a*math.Sin(theta) + b*math.Cos(theta)
+        north := a*math.Cos(theta) - b*math.Sin(theta)
+
+        lon := e.Center.Lon
+        if cosLat > 1e-9 {
+            lon += east / (kmPerDeg * cosLat)
+        }
+        polygon = append(polygon, Location{
+            Lat: e.Center.Lat + north/kmPerDeg,
+            Lon: normalizeLon(lon),
+        })
+    }
+    return polygon
+}
+
+func normalizeLon(lon float64) float64 {
+    for lon > 180 {
+        lon -= 360
+    }
+    for lon < -180 {
+        lon += 360
+    }
+    return lon
+}
+
+// perturb tire un RTT autour de rtt selon une loi normale d'écart-type jitter.
+func perturb(opts Options, rtt, jitter time.Duration) time.Duration {
+    if jitter <= 0 {
+        jitter = time.Duration(float64(rtt) * defaultJitterFraction)
+    }
+    perturbed := rtt + time.Duration(opts.Rand.NormFloat64()*float64(jitter))
+    if perturbed < 0 {
+        return 0
+    }
+    return perturbed
+}
+
+// monteCarloEllipse perturbe les RTT mesurés (serveurs et cible) selon leur
+// gigue, recalcule la position trials fois par multilatération et résume la
+// dispersion obtenue par l'ellipse de covariance à 95%.
+// false si la triangulation est impossible (voir estimatePositions).
+func monteCarloEllipse(measurements []Measurement, target Target, opts Options, trials int) (Ellipse, bool) {
+    positions := make([]Location, 0, trials)
+    for i := 0; i < trials; i++ {
+        targetRTT := perturb(opts, target.RTT, target.Jitter)
+
+        var results []Result
+        for _, m := range measurements {
+            if m.Error != "" {
+                continue
+            }
+            results = append(results, newResult(m.Server, perturb(opts, m.RTT, m.Jitter), m.Jitter, targetRTT, opts))
+        }
+        sortResults(results, opts.Rank)
+
+        est, err := estimatePositions(results, opts)
+        if err != nil {
+            return Ellipse{}, false
+        }
+        positions = append(positions, est.Multilateration)
+    }
+    if len(positions) == 0 {
+        return Ellipse{}, false
+    }
+
+    return covarianceEllipse(positions), true
+}
+
+// covarianceEllipse projette les positions sur le plan tangent au point moyen
+// (en km) et dérive l'ellipse des valeurs propres de leur covariance.
+func covarianceEllipse(positions []Location) Ellipse {
+    n := float64(len(positions))
+
+    // Moyenne des longitudes relative à la première, pour traverser l'antiméridien
+    ref := positions[0].Lon
+    var meanLat, meanDLon float64
+    for _, p := range positions {
+        meanLat += p.Lat
+        meanDLon += normalizeLon(p.Lon - ref)
+    }
+    meanLat /= n
+    meanDLon /= n
+    center := Location{Lat: meanLat, Lon: normalizeLon(ref + meanDLon)}
+
+    kmPerDeg := geo.EarthRadius * math.Pi / 180
+    cosLat := math.Cos(meanLat * math.Pi / 180)
+
+    var sxx, syy, sxy float64
+    for _, p := range positions {
+        x := normalizeLon(p.Lon-center.Lon) * kmPerDeg * cosLat
+        y := (p.Lat - center.Lat) * kmPerDeg
+        sxx += x * x
+        syy += y * y
+        sxy += x * y
+    }
+    sxx /= n
+    syy /= n
+    sxy /= n
+
+    // Valeurs propres de la matrice [[sxx sxy] [sxy syy]]
+    mid := (sxx + syy) / 2
+    diff := math.Sqrt(((sxx-syy)/2)*((sxx-syy)/2) + sxy*sxy)
+    l1, l2 := mid+diff, math.Max(mid-diff, 0)
+
+    // Angle du vecteur propre principal depuis l'axe est, converti en azimut
+    angle := 0.5 * math.Atan2(2*sxy, sxx-syy)
+    bearing := math.Mod(90-angle*180/math.Pi+360, 180)
+
+    k := math.Sqrt(ellipseChi2_95)
+    return Ellipse{
+        Center:         center,
+        SemiMajorKm:    k * math.Sqrt(l1),
+        SemiMinorKm:    k * math.Sqrt(l2),
+        OrientationDeg: bearing,
+        Confidence:     0.95,
+        Samples:        len(positions),
+    }
+}
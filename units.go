@@ -0,0 +1,39 @@
+package main
+
+import "fmt"
+
+const kmPerMile = 1.609344
+
+// Unités d'affichage des distances ; les calculs internes restent en km.
+const (
+    unitKm = "km"
+    unitMi = "mi"
+)
+
+var distanceUnit = unitKm
+
+// setDistanceUnit sélectionne l'unité d'affichage, false si elle est inconnue.
+func setDistanceUnit(unit string) bool {
+    switch unit {
+    case unitKm, unitMi:
+        distanceUnit = unit
+        return true
+    }
+    return false
+}
+
+// formatArea affiche une aire (km²) dans l'unité choisie.
+func formatArea(km2 float64) string {
+    if distanceUnit == unitMi {
+        return fmt.Sprintf("%.0f mi²", km2/(kmPerMile*kmPerMile))
+    }
+    return fmt.Sprintf("%.0f km²", km2)
+}
+
+// formatDistance convertit une distance en km vers l'unité d'affichage.
+func formatDistance(km float64) string {
+    if distanceUnit == unitMi {
+        return fmt.Sprintf("%.0f mi", km/kmPerMile)
+    }
+    return fmt.Sprintf("%.0f km", km)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+    "fmt"
+    "math"
+    "sort"
+    "strings"
+
+    "triangula/geo"
+)
+
+// Serveurs de plus petit RTT examinés par farNearestServers
+const vantageNearestServers = 5
+
+// Éloignement (km) au-delà duquel un serveur plus rapide que le serveur
+// géographiquement le plus proche du poste est jugé anormal
+const vantageFarKm = 1000.0
+
+// FarServer est un serveur qui répond plus vite que le serveur le plus
+// proche du poste (Nearest) tout en étant bien plus loin : son adresse est
+// vraisemblablement anycast, ou sa position erronée.
+type FarServer struct {
+    Server    Server
+    DistKm    float64 // du poste au serveur
+    Nearest   Server  // serveur localisé le plus proche du poste
+    NearestKm float64
+}
+
+// distanceFrom retourne la distance (km) entre le poste et le serveur.
+func distanceFrom(vantage Location, s Server) float64 {
+    loc := serverLocation(s)
+    return geo.Distance(vantage.Lat, vantage.Lon, loc.Lat, loc.Lon)
+}
+
+// farNearestServers examine les vantageNearestServers serveurs de plus
+// petit RTT depuis le poste : ils devraient être proches de lui. Sont
+// retournés ceux qui, plus rapides que le serveur ayant répondu le plus
+// proche du poste, en sont plus loin que lui de vantageFarKm. Les serveurs
+// anycast connus, déjà traités à part, ne sont pas examinés.
+func farNearestServers(results []Result, vantage Location) []FarServer {
+    var located []Result
+    for _, r := range results {
+        if !isAnycast(r.Server) {
+            located = append(located, r)
+        }
+    }
+    if len(located) == 0 {
+        return nil
+    }
+
+    nearest, nearestKm := located[0].Server, math.Inf(1)
+    for _, r := range located {
+        if d := distanceFrom(vantage, r.Server); d < nearestKm {
+            nearest, nearestKm = r.Server, d
+        }
+    }
+
+    sort.SliceStable(located, func(i, j int) bool { return located[i].Server.AvgRTT < located[j].Server.AvgRTT })
+    var far []FarServer
+    for _, r := range located[:min(vantageNearestServers, len(located))] {
+        if r.Server.AvgRTT >= nearest.AvgRTT {
+            break
+        }
+        if d := distanceFrom(vantage, r.Server); d > nearestKm+vantageFarKm {
+            far = append(far, FarServer{Server: r.Server, DistKm: d, Nearest: nearest, NearestKm: nearestKm})
+        }
+    }
+    return far
+}
+
+// displayFarNearestServers signale les serveurs rapides mais éloignés du
+// poste (voir farNearestServers).
+func displayFarNearestServers(results []Result, vantage Location) {
+    far := farNearestServers(results, vantage)
+    if len(far) == 0 {
+        return
+    }
+    names := make([]string, len(far))
+    for i, f := range far {
+        names[i] = fmt.Sprintf(tr("vantage.farServer"), f.Server.Name, f.Server.AvgRTT, formatDistance(f.DistKm))
+    }
+    fmt.Printf(tr("vantage.farWarning"), strings.Join(names, ", "), far[0].Nearest.Name, far[0].Nearest.AvgRTT,
+        formatDistance(far[0].NearestKm))
+}
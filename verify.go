@@ -0,0 +1,116 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "math"
+
+    "triangula/geo"
+)
+
+// Pings de la mesure de vérification (--verify), plus nombreux que ceux de
+// la campagne pour un RTT moins bruité
+const verifyPingCount = 10
+
+// Au-delà de ce multiple de la tolérance, l'écart n'est plus du bruit et
+// l'estimation est suspecte
+const verifySuspectFactor = 2.0
+
+// Verification confronte l'estimation au serveur ayant répondu le plus proche
+// d'elle, remesuré plus longuement : sa distance à la cible déduite du RTT
+// doit correspondre à sa distance géographique à l'estimation, à la tolérance
+// près (rayon de confiance plus incertitude de la mesure due à la gigue).
+// Au-delà de verifySuspectFactor fois cette tolérance, l'estimation est
+// suspecte.
+type Verification struct {
+    Server       string  `json:"server"`
+    Pings        int     `json:"pings"`
+    GeographicKm float64 `json:"geographic_km"` // du serveur à l'estimation
+    MeasuredKm   float64 `json:"measured_km"`   // du serveur à la cible, d'après le RTT
+    GapKm        float64 `json:"gap_km"`
+    RadiusKm     float64 `json:"radius_km"`    // rayon de confiance de l'analyse de cohérence
+    ToleranceKm  float64 `json:"tolerance_km"` // RadiusKm + incertitude de MeasuredKm
+    Consistent   bool    `json:"consistent"`   // GapKm <= ToleranceKm
+    Suspect      bool    `json:"suspect"`
+}
+
+// Outcome retourne la clé de message du verdict.
+func (v Verification) Outcome() string {
+    switch {
+    case v.Consistent:
+        return "tri.verifyOK"
+    case v.Suspect:
+        return "tri.verifySuspect"
+    default:
+        return "tri.verifyLoose"
+    }
+}
+
+// errNoVerificationServer : aucun serveur localisé (hors anycast) n'a répondu
+var errNoVerificationServer = errors.New("no located server to verify against")
+
+// nearestResult retourne le résultat du serveur (hors anycast) le plus
+// proche de loc et sa distance (km).
+func nearestResult(results []Result, loc Location) (Result, float64, bool) {
+    var nearest Result
+    best := math.Inf(1)
+    for _, r := range results {
+        if isAnycast(r.Server) {
+            continue
+        }
+        s := serverLocation(r.Server)
+        if d := geo.Distance(loc.Lat, loc.Lon, s.Lat, s.Lon); d < best {
+            nearest, best = r, d
+        }
+    }
+    return nearest, best, !math.IsInf(best, 1)
+}
+
+// verifyEstimate remesure le serveur le plus proche de l'estimation des
+// moindres carrés avec verifyPingCount pings et compare sa distance
+// mesurée à sa distance géographique. Le serveur retenu est renseigné même
+// si sa mesure échoue.
+func verifyEstimate(ctx context.Context, target Target, results []Result, est Estimates, opts Options) (Verification, error) {
+    opts = opts.withDefaults()
+    r, geographic, ok := nearestResult(results, est.LeastSquares)
+    if !ok {
+        return Verification{}, errNoVerificationServer
+    }
+    v := Verification{Server: r.Server.Name, Pings: verifyPingCount, GeographicKm: geographic,
+        RadiusKm: assessCoherence(results).Precision}
+
+    ip, err := opts.DNSCache.Resolve(r.Server.IP)
+    var stats PingStats
+    if err == nil {
+        stats, err = opts.serverMeasurer().Measure(ctx, ip, verifyPingCount)
+    }
+    if err != nil {
+        return v, err
+    }
+    measured := newResult(r.Server, stats.Avg, stats.StdDev, target.RTT, opts)
+    v.MeasuredKm = measured.Distance
+    v.GapKm = math.Abs(v.GeographicKm - v.MeasuredKm)
+    v.ToleranceKm = v.RadiusKm + measured.DistanceError
+    v.Consistent = v.GapKm <= v.ToleranceKm
+    v.Suspect = v.GapKm > verifySuspectFactor*v.ToleranceKm
+    return v, nil
+}
+
+// displayVerification mesure et affiche la vérification à la suite de
+// l'analyse de cohérence ; nil si elle n'a pas pu être menée.
+func displayVerification(ctx context.Context, target Target, results []Result, est Estimates, opts Options) *Verification {
+    v, err := verifyEstimate(ctx, target, results, est, opts)
+    if errors.Is(err, errNoVerificationServer) {
+        fmt.Println(tr("tri.verifyNone"))
+        return nil
+    }
+    if err != nil {
+        fmt.Printf(tr("tri.verifyFailed"), v.Server, err)
+        return nil
+    }
+    fmt.Printf(tr("tri.verify"), v.Server, formatDistance(v.GeographicKm), v.Pings, formatDistance(v.MeasuredKm),
+        formatDistance(v.GapKm), formatDistance(v.ToleranceKm))
+    fmt.Println(tr(v.Outcome()))
+    return &v
+}
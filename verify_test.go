@@ -0,0 +1,54 @@
+package main
+
+import (
+    "context"
+    "errors"
+    "testing"
+
+    "triangula/geo"
+)
+
+func TestNearestResult(t *testing.T) {
+    paris := resultAt("paris", Location{Lat: 48.8566, Lon: 2.3522}, 0)
+    london := resultAt("london", Location{Lat: 51.5074, Lon: -0.1278}, 0)
+    // Anycast : localisé à Paris dans la base mais répond de partout
+    global := resultAt("global", Location{Lat: 48.8566, Lon: 2.3522}, 0)
+    global.Server.Country = "Global"
+    cloudflare := resultAt("cloudflare", Location{Lat: 48.8566, Lon: 2.3522}, 0)
+    cloudflare.Server.IP = "1.1.1.1"
+
+    tests := []struct {
+        name    string
+        results []Result
+        loc     Location
+        want    string // "" : aucun serveur retenu
+    }{
+        {name: "aucun résultat", loc: Location{Lat: 48, Lon: 2}},
+        {name: "le plus proche", results: []Result{london, paris}, loc: Location{Lat: 48, Lon: 2}, want: "paris"},
+        {name: "ordre indifférent", results: []Result{paris, london}, loc: Location{Lat: 52, Lon: 0}, want: "london"},
+        {name: "anycast écarté", results: []Result{global, cloudflare, london}, loc: Location{Lat: 48, Lon: 2}, want: "london"},
+        {name: "seulement de l'anycast", results: []Result{global, cloudflare}, loc: Location{Lat: 48, Lon: 2}},
+    }
+    for _, tt := range tests {
+        got, d, ok := nearestResult(tt.results, tt.loc)
+        if ok != (tt.want != "") || got.Server.Name != tt.want {
+            t.Errorf("%s: nearestResult = %q, %v, want %q", tt.name, got.Server.Name, ok, tt.want)
+            continue
+        }
+        if ok {
+            s := serverLocation(got.Server)
+            if want := geo.Distance(tt.loc.Lat, tt.loc.Lon, s.Lat, s.Lon); d != want {
+                t.Errorf("%s: nearestResult distance = %.3f km, want %.3f", tt.name, d, want)
+            }
+        }
+    }
+}
+
+func TestVerifyEstimateWithoutServer(t *testing.T) {
+    global := resultAt("global", Location{Lat: 48.8566, Lon: 2.3522}, 0)
+    global.Server.Country = "Global"
+    _, err := verifyEstimate(context.Background(), Target{}, []Result{global}, Estimates{}, Options{})
+    if !errors.Is(err, errNoVerificationServer) {
+        t.Errorf("verifyEstimate with only anycast servers: error = %v, want errNoVerificationServer", err)
+    }
+}
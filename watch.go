@@ -0,0 +1,107 @@
+package main
+
+import (
+    "context"
+    "fmt"
+    "log/slog"
+    "time"
+
+    "triangula/geo"
+)
+
+const (
+    // Bruit de processus par défaut : dérive admise de la position entre deux
+    // itérations (écart-type, km)
+    defaultProcessNoiseKm = 5.0
+    // Bruit de mesure par défaut : dispersion d'une estimation isolée
+    // (écart-type, km)
+    defaultMeasurementNoiseKm = 150.0
+)
+
+// positionFilter est un filtre de Kalman à modèle de position constante,
+// appliqué indépendamment sur chaque coordonnée cartésienne (km).
+type positionFilter struct {
+    processVar     float64 // Q, km²
+    measurementVar float64 // R, km²
+
+    state    [3]float64
+    variance float64 // P, identique sur les trois axes
+    ready    bool
+}
+
+func newPositionFilter(processNoiseKm, measurementNoiseKm float64) *positionFilter {
+    return &positionFilter{
+        processVar:     processNoiseKm * processNoiseKm,
+        measurementVar: measurementNoiseKm * measurementNoiseKm,
+    }
+}
+
+// Update fusionne une nouvelle estimation avec l'état précédent et retourne
+// la position lissée.
+func (f *positionFilter) Update(loc Location) Location {
+    x, y, z := geo.GeoToCartesian(loc.Lat, loc.Lon)
+    measured := [3]float64{x, y, z}
+
+    if !f.ready {
+        f.state = measured
+        f.variance = f.measurementVar
+        f.ready = true
+    } else {
+        // Prédiction (position constante) puis correction
+        f.variance += f.processVar
+        gain := f.variance / (f.variance + f.measurementVar)
+        for i := range f.state {
+            f.state[i] += gain * (measured[i] - f.state[i])
+        }
+        f.variance *= 1 - gain
+    }
+
+    lat, lon := geo.CartesianToGeo(f.state[0], f.state[1], f.state[2])
+    return Location{Lat: lat, Lon: lon}
+}
+
+// watch remesure la cible toutes les interval et affiche, à chaque itération,
+// la position brute (multilatération) et la position lissée par le filtre.
+// Chaque itération repasse les serveurs mesurés à la suivante (voir
+// measuredServers, Options.ServerTTL). S'arrête à l'annulation de ctx.
+func watch(ctx context.Context, target Target, servers []Server, opts Options,
+    interval, deadline time.Duration, filter *positionFilter) {
+    fmt.Println(tr("watch.header"))
+
+    for iteration := 1; ; iteration++ {
+        iterCtx, cancel := ctx, context.CancelFunc(func() {})
+        if deadline > 0 {
+            iterCtx, cancel = context.WithTimeout(ctx, deadline)
+        }
+        measurements, err := runMeasurements(iterCtx, &target, servers, opts)
+        cancel()
+        if ctx.Err() != nil {
+            return
+        }
+
+        if err == nil {
+            servers = measuredServers(measurements)
+        }
+        if err != nil {
+            slog.Warn("watch iteration failed", "iteration", iteration, "error", err)
+        } else if est, err := estimatePositions(buildResults(measurements, target.RTT, opts), opts); err == nil {
+            raw := est.Multilateration
+            smoothed := filter.Update(raw)
+            fmt.Printf(tr("watch.line"), iteration, time.Now().Format("15:04:05"),
+                raw.Lat, raw.Lon, smoothed.Lat, smoothed.Lon,
+                formatDistance(geo.Distance(raw.Lat, raw.Lon, smoothed.Lat, smoothed.Lon)))
+            if target.Cached {
+                fmt.Print(tr("watch.cached"))
+            }
+            fmt.Println()
+        } else {
+            slog.Warn("watch iteration not triangulated", "iteration", iteration, "error", err)
+        }
+
+        select {
+        case <-ctx.Done():
+            return
+        case <-time.After(interval):
+        }
+    }
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+    "fmt"
+    "io"
+    "strings"
+)
+
+// Carte ASCII (--ascii-map) : projection équirectangulaire de la latitude
+// mapNorth à mapSouth (l'Antarctique est omise), deux fois plus de colonnes
+// que de lignes par degré pour compenser la hauteur des caractères
+const (
+    defaultASCIIMapWidth = 80
+    minASCIIMapWidth     = 36
+    maxASCIIMapWidth     = 400
+    mapNorth             = 84.0
+    mapSouth             = -60.0
+)
+
+// Caractères de la carte
+const (
+    mapLand     = '.'
+    mapAnchor   = 'o' // serveur de la multilatération
+    mapEstimate = 'X' // position estimée (méthode 3)
+    mapVantage  = 'M' // poste de mesure (--vantage, --my-location)
+)
+
+// landPolygons est le masque des terres émergées : contours grossiers
+// (longitude, latitude) des continents et des grandes îles, suffisants à
+// quelques degrés près pour situer une estimation.
+var landPolygons = [][][2]float64{
+    // Amérique du Nord
+    {{-165, 62}, {-168, 66}, {-156, 71}, {-140, 69.5}, {-125, 70}, {-110, 68}, {-95, 68}, {-85, 70},
+        {-80, 66}, {-90, 63}, {-94, 59}, {-88, 56}, {-82, 52}, {-79, 55}, {-77, 60}, {-72, 61},
+        {-65, 60}, {-61, 56}, {-56, 52}, {-60, 47}, {-66, 44}, {-70, 42}, {-74, 40}, {-76, 35},
+        {-81, 31}, {-80, 26}, {-81, 25}, {-83, 29}, {-89, 30}, {-95, 29}, {-97, 26}, {-97, 22},
+        {-95, 19}, {-91, 19}, {-87, 21}, {-88, 16}, {-84, 15}, {-83, 11}, {-79, 9}, {-77, 8},
+        {-80, 7}, {-83, 8.5}, {-86, 12}, {-92, 14}, {-96, 16}, {-105, 20}, {-110, 23}, {-117, 32},
+        {-121, 35}, {-124, 40}, {-124, 47}, {-123, 49}, {-128, 51}, {-133, 56}, {-138, 59},
+        {-145, 60}, {-152, 59}, {-158, 57}, {-162, 55}, {-158, 58}, {-162, 60}},
+    // Terre de Baffin
+    {{-80, 73}, {-68, 70}, {-62, 66.5}, {-66, 62}, {-73, 63}, {-78, 64.5}, {-85, 70}},
+    // Groenland
+    {{-73, 78}, {-60, 82}, {-30, 83.5}, {-20, 81}, {-18, 76}, {-22, 70}, {-32, 68}, {-40, 65},
+        {-43, 60}, {-49, 61}, {-53, 66}, {-55, 70}, {-60, 75.5}},
+    // Cuba
+    {{-85, 22}, {-80, 23}, {-74, 20}, {-77, 20}},
+    // Amérique du Sud
+    {{-77, 8}, {-72, 12}, {-64, 10.5}, {-60, 8.5}, {-52, 5}, {-50, 0}, {-44, -2}, {-35, -5},
+        {-35, -9}, {-39, -15}, {-41, -22}, {-48, -26}, {-53, -34}, {-58, -38}, {-62, -39},
+        {-65, -42}, {-67, -46}, {-69, -51}, {-68.5, -54}, {-72, -53}, {-75, -48}, {-73, -40},
+        {-72, -30}, {-71, -20}, {-76, -14}, {-81, -6}, {-80, -1}, {-77, 4}},
+    // Afrique
+    {{-17, 21}, {-16, 27}, {-10, 30}, {-6, 35.8}, {10, 37}, {11, 33}, {20, 31}, {25, 32},
+        {32, 31.5}, {34, 28}, {37, 22}, {39, 16}, {43, 12}, {51, 12}, {51, 10}, {47, 4},
+        {40, -3}, {39, -7}, {40, -13}, {35, -20}, {35, -24}, {33, -26}, {32, -29}, {27, -34},
+        {20, -35}, {18, -33}, {15, -27}, {12, -17}, {13, -9}, {9, -1}, {9, 4}, {5, 6},
+        {-2, 5}, {-8, 4.5}, {-13, 8}, {-17, 14}},
+    // Madagascar
+    {{49.3, -12}, {50.5, -15.5}, {47.5, -24.5}, {45, -25.5}, {43.5, -22}, {44.5, -16}},
+    // Eurasie
+    {{-9, 37}, {-9, 43}, {-2, 43.5}, {-1, 46}, {-5, 48}, {-1, 49.5}, {2, 51}, {5, 53}, {8, 54},
+        {8, 57}, {11, 58}, {11, 56}, {13, 55}, {20, 55}, {21, 57}, {24, 59}, {22, 61}, {25, 65},
+        {21, 64}, {17, 61}, {19, 60}, {16, 56}, {12, 56}, {10, 59}, {5, 58}, {5, 62}, {14, 67},
+        {20, 70}, {28, 71}, {33, 69.5}, {41, 67}, {44, 68}, {53, 68.5}, {60, 69}, {68, 70},
+        {73, 72}, {80, 73}, {87, 75}, {100, 77}, {113, 74}, {130, 71}, {140, 72}, {150, 71},
+        {160, 70}, {170, 70}, {180, 69}, {180, 65}, {170, 60}, {163, 58}, {162, 55}, {157, 51},
+        {156, 57}, {155, 59.5}, {143, 59}, {137, 54}, {141, 52}, {140, 48}, {133, 43}, {129, 41},
+        {129, 35}, {126, 35}, {126.5, 37}, {125, 40}, {121, 40}, {122, 37}, {119, 37}, {121, 32},
+        {122, 30}, {120, 26}, {116, 23}, {110, 21}, {108, 21.5}, {106, 19}, {109, 13}, {105, 9},
+        {103, 10.5}, {100, 13}, {100, 8}, {103.5, 1.5}, {101, 3}, {98, 8}, {98.5, 16}, {94, 18},
+        {92, 22}, {88, 22}, {86, 20}, {80, 15.5}, {80, 10}, {77, 8}, {73, 16}, {72.5, 21},
+        {68, 23.5}, {66, 25.5}, {62, 25}, {57, 25.5}, {56.5, 27}, {51, 28.5}, {48, 30}, {50, 26},
+        {51, 24}, {56, 24}, {57, 23}, {59, 22.5}, {55, 17}, {52, 16}, {43, 12.7}, {42.5, 15},
+        {39, 21}, {35, 28}, {34.5, 28}, {33.5, 31}, {34.8, 32.5}, {35.8, 35.5}, {36, 36.5},
+        {32, 36.2}, {29, 36.5}, {26.3, 39}, {26.5, 40.3}, {29, 41.2}, {31, 41.1}, {36, 41.7},
+        {41.5, 41.5}, {39, 44.5}, {38, 46.5}, {35, 45}, {33, 45.5}, {31, 46.6}, {29.5, 45.3},
+        {28, 43}, {28, 41.5}, {26, 40.8}, {24, 40.6}, {23, 39.5}, {22.5, 37}, {21.5, 38.5},
+        {19.5, 41.5}, {15.5, 44}, {13.5, 45.7}, {12.3, 45.3}, {13, 43.5}, {16, 41.5}, {18.5, 40.2},
+        {16, 38}, {15.7, 40}, {12, 42}, {10, 44}, {8.5, 44.3}, {6, 43}, {3, 43.3}, {3.2, 41.9},
+        {0.5, 40.5}, {-0.5, 38.5}, {-2.2, 36.7}, {-5.6, 36}, {-7, 37}},
+    // Grande-Bretagne, Irlande, Islande
+    {{-5.7, 50}, {1.5, 51}, {1.7, 52.7}, {0, 53.5}, {-1.6, 55.5}, {-2, 57.5}, {-3, 58.6},
+        {-5, 58.6}, {-6, 56.5}, {-5, 55}, {-3, 54.5}, {-4.5, 53.3}, {-5, 51.7}},
+    {{-6, 52}, {-6, 54.5}, {-7.5, 55.3}, {-10, 54.2}, {-10, 51.6}},
+    {{-24, 65.5}, {-22, 66.5}, {-15, 66.5}, {-13.5, 65}, {-18, 63.4}, {-22.5, 63.8}},
+    // Japon
+    {{130, 31}, {131, 34}, {135, 34.5}, {140, 35}, {141, 38}, {142, 40}, {141.5, 41.5},
+        {145, 43.5}, {145, 44.5}, {142, 45.5}, {141, 43}, {140, 40}, {139, 38}, {136.5, 36.5},
+        {133, 35.5}, {130.5, 34}},
+    // Philippines, Bornéo, Sumatra, Java, Nouvelle-Guinée
+    {{120, 18.5}, {122.3, 18.5}, {124, 13}, {126, 7}, {126, 6}, {122, 7}, {119.5, 10}, {121, 13},
+        {120, 15}},
+    {{109, 1.5}, {111, 2.5}, {115, 5}, {117, 7}, {119, 5}, {118, 1}, {116.5, -2}, {114, -4},
+        {110, -3}, {109, -1}},
+    {{95, 5.5}, {98, 4}, {104, -2}, {106, -6}, {104, -5.5}, {101, -2.5}, {98.5, 1}},
+    {{105, -6.8}, {108, -6}, {114.5, -7.5}, {114.5, -8.7}, {111, -8.2}},
+    {{131, -1}, {135, -3.5}, {138, -1.5}, {142, -3}, {147, -6}, {150, -10.5}, {147, -10},
+        {144, -8}, {141, -9}, {138, -8}, {137, -5}, {132, -4}},
+    // Australie, Nouvelle-Zélande
+    {{114, -22}, {114, -26}, {115, -34}, {118, -35}, {124, -34}, {129, -31.5}, {134, -32.5},
+        {138, -35}, {140, -38}, {146, -39}, {150, -37.5}, {153, -31}, {153, -25}, {149, -20},
+        {146, -18}, {145.5, -15}, {143, -10.8}, {142, -14}, {141.5, -17}, {139.5, -17.5},
+        {137, -15.5}, {136.5, -12}, {132, -11.5}, {130, -13}, {127, -14}, {125, -15}, {122, -17.5},
+        {121, -19.5}, {117, -20.7}},
+    {{173, -34.5}, {175.5, -36.5}, {178.5, -37.5}, {177, -39.5}, {175, -41.5}, {173.5, -39.5},
+        {174.5, -38}},
+    {{172.5, -40.5}, {174, -41.5}, {171, -44.5}, {169, -46.6}, {166.5, -46}, {168, -44}},
+}
+
+// isLand indique si le point (lon, lat) est dans un polygone du masque
+// (test du rayon horizontal).
+func isLand(lon, lat float64) bool {
+    for _, poly := range landPolygons {
+        inside := false
+        for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+            xi, yi, xj, yj := poly[i][0], poly[i][1], poly[j][0], poly[j][1]
+            if (yi > lat) != (yj > lat) && lon < (xj-xi)*(lat-yi)/(yj-yi)+xi {
+                inside = !inside
+            }
+        }
+        if inside {
+            return true
+        }
+    }
+    return false
+}
+
+// mapCell retourne la ligne et la colonne d'une position sur une carte de
+// rows x width caractères ; false hors de la bande de latitudes affichée.
+func mapCell(loc Location, rows, width int) (int, int, bool) {
+    if loc.Lat > mapNorth || loc.Lat < mapSouth {
+        return 0, 0, false
+    }
+    row := int((mapNorth - loc.Lat) / (mapNorth - mapSouth) * float64(rows))
+    col := int((loc.Lon + 180) / 360 * float64(width))
+    if row >= rows {
+        row = rows - 1
+    }
+    if col >= width {
+        col = width - 1
+    }
+    return row, col, true
+}
+
+// renderASCIIMap dessine sur w une carte du monde de width colonnes avec les
+// serveurs anchors, le poste de mesure s'il est connu et la position
+// estimate, tracée en dernier.
+func renderASCIIMap(w io.Writer, estimate Location, anchors []Result, width int, vantage *Location) {
+    rows := int(float64(width) * (mapNorth - mapSouth) / 360 / 2)
+    grid := make([][]rune, rows)
+    for r := range grid {
+        grid[r] = make([]rune, width)
+        lat := mapNorth - (float64(r)+0.5)*(mapNorth-mapSouth)/float64(rows)
+        for c := range grid[r] {
+            grid[r][c] = ' '
+            if isLand(-180+(float64(c)+0.5)*360/float64(width), lat) {
+                grid[r][c] = mapLand
+            }
+        }
+    }
+    for _, a := range anchors {
+        if r, c, ok := mapCell(serverLocation(a.Server), rows, width); ok {
+            grid[r][c] = mapAnchor
+        }
+    }
+    if vantage != nil {
+        if r, c, ok := mapCell(*vantage, rows, width); ok {
+            grid[r][c] = mapVantage
+        }
+    }
+    if r, c, ok := mapCell(estimate, rows, width); ok {
+        grid[r][c] = mapEstimate
+    }
+
+    border := "+" + strings.Repeat("-", width) + "+"
+    fmt.Fprintln(w, border)
+    for _, line := range grid {
+        fmt.Fprintf(w, "|%s|\n", string(line))
+    }
+    fmt.Fprintln(w, border)
+    fmt.Fprintf(w, tr("map.legend"), mapEstimate, estimate.Lat, estimate.Lon, mapAnchor, len(anchors))
+    if vantage != nil {
+        fmt.Fprintf(w, tr("map.legendVantage"), mapVantage, vantage.Lat, vantage.Lon)
+    }
+}